@@ -2,6 +2,7 @@ package alerts
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 	"net/smtp"
@@ -13,7 +14,9 @@ import (
 
 // EmailChannel implements AlertChannel for email notifications
 type EmailChannel struct {
-	config config.EmailConfig
+	config      config.EmailConfig
+	templates   *TemplateManager
+	templateIDs map[AlertType]string // Alert types rendered with a template instead of the built-in email
 }
 
 // NewEmailChannel creates a new email alert channel
@@ -23,22 +26,31 @@ func NewEmailChannel(cfg config.EmailConfig) *EmailChannel {
 	}
 }
 
+// SetTemplates renders the given alert types with templates from tm
+func (e *EmailChannel) SetTemplates(tm *TemplateManager, ids map[AlertType]string) {
+	e.templates = tm
+	e.templateIDs = ids
+}
+
 // Name returns the channel name
 func (e *EmailChannel) Name() string {
 	return "Email"
 }
 
-// Send sends an alert via email
-func (e *EmailChannel) Send(alert Alert) error {
+// Send sends an alert via email. SMTP delivery itself is not cancellable,
+// so ctx is checked between recipients.
+func (e *EmailChannel) Send(ctx context.Context, alert Alert) error {
 	// Prepare email content
-	subject := e.generateSubject(alert)
-	body, err := e.generateBody(alert)
+	subject, body, err := e.render(alert)
 	if err != nil {
 		return fmt.Errorf("failed to generate email body: %w", err)
 	}
 
 	// Send to all recipients
 	for _, recipient := range e.config.Recipients {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("email delivery aborted before %s: %w", recipient, err)
+		}
 		if err := e.sendEmail(recipient, subject, body); err != nil {
 			return fmt.Errorf("failed to send email to %s: %w", recipient, err)
 		}
@@ -79,6 +91,17 @@ func (e *EmailChannel) Test() error {
 	return e.sendEmail(e.config.Recipients[0], subject, body)
 }
 
+// render returns the subject and body of an alert email, using the
+// configured template for the alert type if any
+func (e *EmailChannel) render(alert Alert) (string, string, error) {
+	if id, ok := e.templateIDs[alert.Type]; ok && e.templates != nil {
+		return e.templates.RenderTemplate(id, alert)
+	}
+
+	body, err := e.generateBody(alert)
+	return e.generateSubject(alert), body, err
+}
+
 // generateSubject creates the email subject line
 func (e *EmailChannel) generateSubject(alert Alert) string {
 	var prefix string
@@ -100,6 +123,12 @@ func (e *EmailChannel) generateSubject(alert Alert) string {
 		return fmt.Sprintf("%s Site Monitor - %s SLOW RESPONSE", prefix, alert.SiteName)
 	case AlertTypeLowUptime:
 		return fmt.Sprintf("%s Site Monitor - %s LOW UPTIME", prefix, alert.SiteName)
+	case AlertTypeChange:
+		return fmt.Sprintf("%s Site Monitor - %s CHANGED", prefix, alert.SiteName)
+	case AlertTypeDegraded:
+		return fmt.Sprintf("%s Site Monitor - %s DEGRADED", prefix, alert.SiteName)
+	case AlertTypeSSLExpiry:
+		return fmt.Sprintf("%s Site Monitor - %s CERTIFICATE EXPIRING", prefix, alert.SiteName)
 	default:
 		return fmt.Sprintf("%s Site Monitor - %s ALERT", prefix, alert.SiteName)
 	}
@@ -250,6 +279,12 @@ func getTypeIcon(alertType AlertType) string {
 		return "⚠️"
 	case AlertTypeLowUptime:
 		return "📉"
+	case AlertTypeChange:
+		return "🔀"
+	case AlertTypeDegraded:
+		return "⚠️"
+	case AlertTypeSSLExpiry:
+		return "🔐"
 	default:
 		return "🔔"
 	}
@@ -1,6 +1,7 @@
 package alerts
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"site-monitor/config"
@@ -31,28 +32,58 @@ func NewManager(alertConfig config.AlertConfig, storage storage.Storage) *Manage
 	}
 
 	// Initialize alert channels based on configuration
-	manager.initializeChannels()
+	manager.channels = newChannels(alertConfig)
+	log.Printf("📧 Initialized %d alert channels", len(manager.channels))
 
 	return manager
 }
 
-// initializeChannels sets up the configured alert channels
-func (m *Manager) initializeChannels() {
-	if m.config.Email.Enabled {
-		emailChannel := NewEmailChannel(m.config.Email)
-		m.channels = append(m.channels, emailChannel)
+// newChannels builds the alert channels enabled in the configuration
+func newChannels(alertConfig config.AlertConfig) []AlertChannel {
+	channels := make([]AlertChannel, 0)
+	templates := NewTemplateManager()
+	selection := SelectTemplates(alertConfig.Templates)
+
+	if alertConfig.Email.Enabled {
+		email := NewEmailChannel(alertConfig.Email)
+		email.SetTemplates(templates, selection[ChannelEmail])
+		channels = append(channels, email)
 	}
 
-	if m.config.Webhook.Enabled {
-		webhookChannel := NewWebhookChannel(m.config.Webhook)
-		m.channels = append(m.channels, webhookChannel)
+	if alertConfig.Webhook.Enabled {
+		webhook := NewWebhookChannel(alertConfig.Webhook)
+		webhook.SetTemplates(templates, selection[webhook.TemplateChannel()])
+		channels = append(channels, webhook)
 	}
 
-	log.Printf("📧 Initialized %d alert channels", len(m.channels))
+	return channels
+}
+
+// Reload swaps the alert configuration and channels. Site states are kept,
+// so a site that is down stays down and is not re-alerted. The swap waits for
+// any result being processed, which therefore sees either the old or the new
+// configuration but never a mix of both.
+func (m *Manager) Reload(alertConfig config.AlertConfig) {
+	channels := newChannels(alertConfig)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config = alertConfig
+	m.channels = channels
+	log.Printf("📧 Reloaded %d alert channels", len(channels))
+}
+
+// Forget drops the alert state of a site that is no longer monitored
+func (m *Manager) Forget(siteName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.states, siteName)
 }
 
 // ProcessResult processes a monitoring result and generates alerts if needed
-func (m *Manager) ProcessResult(result monitor.Result) error {
+func (m *Manager) ProcessResult(ctx context.Context, result monitor.Result) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -67,14 +98,16 @@ func (m *Manager) ProcessResult(result monitor.Result) error {
 
 	// Send any generated alerts
 	for _, alert := range alerts {
-		if err := m.sendAlert(alert); err != nil {
+		if err := m.sendAlert(ctx, alert); err != nil {
 			log.Printf("❌ Failed to send alert: %v", err)
 		} else {
 			log.Printf("📧 Alert sent: %s", alert.String())
 		}
 
 		// Update state with alert information
-		state.LastAlertTime = time.Now()
+		if !alert.bypassesCooldown() {
+			state.LastAlertTime = time.Now()
+		}
 		if alert.Type == AlertTypeSiteDown {
 			state.ActiveAlerts = append(state.ActiveAlerts, alert.ID)
 		}
@@ -100,28 +133,23 @@ func (m *Manager) getOrCreateState(siteName string) *AlertState {
 	return state
 }
 
-// updateState updates the alert state based on the current result
+// updateState updates the alert state counters based on the current result.
+// Down/up transitions are applied by the alert checks so that they can
+// detect the transition before it is recorded.
 func (m *Manager) updateState(state *AlertState, result monitor.Result) {
 	if result.Success {
 		// Reset failure counter on success
-		if state.ConsecutiveFails > 0 {
-			state.ConsecutiveFails = 0
-		}
+		state.ConsecutiveFails = 0
 		state.LastSuccessTime = result.Timestamp
-
-		// Mark site as up if it was down
-		if state.IsDown {
-			state.IsDown = false
-		}
 	} else {
 		// Increment failure counter
 		state.ConsecutiveFails++
 		state.LastFailTime = result.Timestamp
+	}
 
-		// Mark site as down if threshold exceeded
-		if state.ConsecutiveFails >= m.config.Thresholds.ConsecutiveFailures {
-			state.IsDown = true
-		}
+	// A degraded state ends as soon as the severity is no longer a warning
+	if result.GetSeverity() != monitor.SeverityWarning {
+		state.IsDegraded = false
 	}
 }
 
@@ -129,6 +157,21 @@ func (m *Manager) updateState(state *AlertState, result monitor.Result) {
 func (m *Manager) checkAlertConditions(state *AlertState, result monitor.Result) []Alert {
 	var alerts []Alert
 
+	// Changes are one-off events, so they are never suppressed by the cooldown
+	if alert := m.checkChangeAlert(result); alert != nil {
+		alerts = append(alerts, *alert)
+	}
+
+	// Certificate reminders are sent once per threshold, also regardless of the cooldown
+	if alert := m.checkSSLExpiryAlert(state, result); alert != nil {
+		alerts = append(alerts, *alert)
+
+		// The expiry warning already explains why the site is degraded
+		if result.GetSeverity() == monitor.SeverityWarning {
+			state.IsDegraded = true
+		}
+	}
+
 	// Check if we should send alerts (respect cooldown)
 	if m.shouldSkipDueToCooldown(state) {
 		return alerts
@@ -144,6 +187,11 @@ func (m *Manager) checkAlertConditions(state *AlertState, result monitor.Result)
 		alerts = append(alerts, *alert)
 	}
 
+	// Check for degraded (warning severity) alert
+	if alert := m.checkDegradedAlert(state, result); alert != nil {
+		alerts = append(alerts, *alert)
+	}
+
 	// Check for slow response alert
 	if alert := m.checkSlowResponseAlert(state, result); alert != nil {
 		alerts = append(alerts, *alert)
@@ -175,11 +223,19 @@ func (m *Manager) shouldSkipDueToCooldown(state *AlertState) bool {
 // checkSiteDownAlert checks for site down conditions
 func (m *Manager) checkSiteDownAlert(state *AlertState, result monitor.Result) *Alert {
 	// Only alert if site just went down (threshold reached) and wasn't already down
-	if state.ConsecutiveFails == m.config.Thresholds.ConsecutiveFailures && !state.IsDown {
+	if !state.IsDown && state.ConsecutiveFails >= m.config.Thresholds.ConsecutiveFailures {
+		state.IsDown = true
+
+		// A check that could not determine the state is less certain than an outage
+		severity := SeverityCritical
+		if result.GetSeverity() == monitor.SeverityUnknown {
+			severity = SeverityWarning
+		}
+
 		return &Alert{
 			ID:               uuid.New().String(),
 			Type:             AlertTypeSiteDown,
-			Severity:         SeverityCritical,
+			Severity:         severity,
 			SiteName:         result.Name,
 			SiteURL:          result.URL,
 			Message:          fmt.Sprintf("Site %s is down", result.Name),
@@ -196,8 +252,9 @@ func (m *Manager) checkSiteDownAlert(state *AlertState, result monitor.Result) *
 // checkSiteRecoveryAlert checks for site recovery conditions
 func (m *Manager) checkSiteRecoveryAlert(state *AlertState, result monitor.Result) *Alert {
 	// Alert if site just recovered (was down and now successful)
-	if state.IsDown && result.Success && len(state.ActiveAlerts) > 0 {
+	if state.IsDown && result.Success {
 		// Clear active alerts
+		state.IsDown = false
 		state.ActiveAlerts = make([]string, 0)
 
 		return &Alert{
@@ -217,6 +274,105 @@ func (m *Manager) checkSiteRecoveryAlert(state *AlertState, result monitor.Resul
 	return nil
 }
 
+// checkDegradedAlert checks for a successful result reported with warning severity
+func (m *Manager) checkDegradedAlert(state *AlertState, result monitor.Result) *Alert {
+	if state.IsDegraded || !result.Success || result.GetSeverity() != monitor.SeverityWarning {
+		return nil
+	}
+	state.IsDegraded = true
+
+	return &Alert{
+		ID:            uuid.New().String(),
+		Type:          AlertTypeDegraded,
+		Severity:      SeverityWarning,
+		SiteName:      result.Name,
+		SiteURL:       result.URL,
+		Message:       fmt.Sprintf("Site %s is degraded", result.Name),
+		Details:       fmt.Sprintf("Check reported a warning: %s", result.Error),
+		Timestamp:     time.Now(),
+		CurrentStatus: result.Status,
+		ResponseTime:  result.Duration,
+		ErrorMessage:  result.Error,
+	}
+}
+
+// checkChangeAlert checks whether the probed target changed (e.g. DNS answer set)
+func (m *Manager) checkChangeAlert(result monitor.Result) *Alert {
+	if result.Change == "" {
+		return nil
+	}
+
+	return &Alert{
+		ID:            uuid.New().String(),
+		Type:          AlertTypeChange,
+		Severity:      SeverityWarning,
+		SiteName:      result.Name,
+		SiteURL:       result.URL,
+		Message:       fmt.Sprintf("Site %s changed", result.Name),
+		Details:       result.Change,
+		Timestamp:     time.Now(),
+		CurrentStatus: result.Status,
+		ErrorMessage:  result.Error,
+	}
+}
+
+// checkSSLExpiryAlert checks whether the certificate crossed one of the
+// ssl_expiry_warning_days thresholds since the last reminder
+func (m *Manager) checkSSLExpiryAlert(state *AlertState, result monitor.Result) *Alert {
+	thresholds := m.config.Thresholds.SSLExpiryWarningDays
+
+	days, ok := certificateDays(result)
+	if !ok || len(thresholds) == 0 {
+		return nil
+	}
+
+	// The smallest threshold the certificate is within, zero if none
+	crossed, lowest := 0, thresholds[0]
+	for _, threshold := range thresholds {
+		lowest = min(lowest, threshold)
+		if days <= threshold && (crossed == 0 || threshold < crossed) {
+			crossed = threshold
+		}
+	}
+
+	if crossed == 0 {
+		state.SSLWarnedDays = 0 // Renewed, remind again next time
+		return nil
+	}
+	if state.SSLWarnedDays != 0 && crossed >= state.SSLWarnedDays {
+		return nil
+	}
+	state.SSLWarnedDays = crossed
+
+	severity := SeverityWarning
+	if crossed == lowest {
+		severity = SeverityCritical
+	}
+
+	return &Alert{
+		ID:              uuid.New().String(),
+		Type:            AlertTypeSSLExpiry,
+		Severity:        severity,
+		SiteName:        result.Name,
+		SiteURL:         result.URL,
+		Message:         fmt.Sprintf("Certificate for %s expires in %d days", result.Name, days),
+		Details:         fmt.Sprintf("The certificate is within the %d day warning threshold", crossed),
+		Timestamp:       time.Now(),
+		CurrentStatus:   result.Status,
+		CertificateDays: days,
+	}
+}
+
+// certificateDays returns the days left on the certificate reported by a check
+func certificateDays(result monitor.Result) (int, bool) {
+	for _, metric := range result.Metrics {
+		if metric.Label == monitor.CertificateDaysMetric {
+			return int(metric.Value), true
+		}
+	}
+	return 0, false
+}
+
 // checkSlowResponseAlert checks for slow response conditions
 func (m *Manager) checkSlowResponseAlert(state *AlertState, result monitor.Result) *Alert {
 	if !result.Success {
@@ -252,6 +408,10 @@ func (m *Manager) checkLowUptimeAlert(state *AlertState, result monitor.Result)
 		return nil
 	}
 
+	if m.storage == nil {
+		return nil
+	}
+
 	// Get uptime stats for the configured window
 	window, err := m.config.Thresholds.GetUptimeWindow()
 	if err != nil {
@@ -281,7 +441,7 @@ func (m *Manager) checkLowUptimeAlert(state *AlertState, result monitor.Result)
 }
 
 // sendAlert sends an alert through all configured channels
-func (m *Manager) sendAlert(alert Alert) error {
+func (m *Manager) sendAlert(ctx context.Context, alert Alert) error {
 	if len(m.channels) == 0 {
 		log.Printf("⚠️ No alert channels configured, alert not sent: %s", alert.String())
 		return nil
@@ -289,7 +449,7 @@ func (m *Manager) sendAlert(alert Alert) error {
 
 	var errors []error
 	for _, channel := range m.channels {
-		if err := channel.Send(alert); err != nil {
+		if err := channel.Send(ctx, alert); err != nil {
 			errors = append(errors, fmt.Errorf("channel %s: %w", channel.Name(), err))
 		}
 	}
@@ -0,0 +1,271 @@
+package alerts
+
+import (
+	"context"
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingChannel captures every alert sent through it
+type recordingChannel struct {
+	sent []Alert
+}
+
+func (c *recordingChannel) Send(ctx context.Context, alert Alert) error {
+	c.sent = append(c.sent, alert)
+	return nil
+}
+
+func (c *recordingChannel) Test() error  { return nil }
+func (c *recordingChannel) Name() string { return "Recording" }
+
+func newTestManager(channel AlertChannel) *Manager {
+	m := NewManager(config.AlertConfig{
+		Thresholds: config.ThresholdConfig{
+			ConsecutiveFailures:   2,
+			ResponseTimeThreshold: "5s",
+			UptimeWindow:          "24h",
+			PerformanceWindow:     "1h",
+			AlertCooldown:         "0s",
+		},
+	}, nil)
+	m.channels = []AlertChannel{channel}
+	return m
+}
+
+func TestManager_SiteDownAndRecovery(t *testing.T) {
+	channel := &recordingChannel{}
+	m := newTestManager(channel)
+
+	results := []bool{true, false, false, false, true}
+	for _, success := range results {
+		if err := m.ProcessResult(context.Background(), monitor.Result{
+			Name:      "Example",
+			URL:       "https://example.com",
+			Success:   success,
+			Duration:  100 * time.Millisecond,
+			Timestamp: time.Now(),
+		}); err != nil {
+			t.Fatalf("ProcessResult failed: %v", err)
+		}
+	}
+
+	if len(channel.sent) != 2 {
+		t.Fatalf("Expected 2 alerts (down + recovery), got %d", len(channel.sent))
+	}
+	if channel.sent[0].Type != AlertTypeSiteDown {
+		t.Errorf("Expected first alert to be %s, got %s", AlertTypeSiteDown, channel.sent[0].Type)
+	}
+	if channel.sent[0].ConsecutiveFails != 2 {
+		t.Errorf("Expected down alert after 2 failures, got %d", channel.sent[0].ConsecutiveFails)
+	}
+	if channel.sent[1].Type != AlertTypeSiteUp {
+		t.Errorf("Expected second alert to be %s, got %s", AlertTypeSiteUp, channel.sent[1].Type)
+	}
+
+	state := m.GetAlertStates()["Example"]
+	if state.IsDown {
+		t.Error("Expected site to be marked up after recovery")
+	}
+}
+
+func TestManager_ChangeAlertIgnoresCooldown(t *testing.T) {
+	channel := &recordingChannel{}
+	manager := newTestManager(channel)
+	manager.config.Thresholds.AlertCooldown = "1h"
+
+	ctx := context.Background()
+	manager.ProcessResult(ctx, monitor.Result{Name: "dns", Success: true, Change: "a A answer changed from [1] to [2]"})
+	manager.ProcessResult(ctx, monitor.Result{Name: "dns", Success: true, Change: "a A answer changed from [2] to [3]"})
+
+	if len(channel.sent) != 2 {
+		t.Fatalf("Expected 2 change alerts, got %d", len(channel.sent))
+	}
+	for _, alert := range channel.sent {
+		if alert.Type != AlertTypeChange {
+			t.Errorf("Expected %s alert, got %s", AlertTypeChange, alert.Type)
+		}
+	}
+}
+
+func TestManager_ChangeAlertsDoNotStartCooldown(t *testing.T) {
+	channel := &recordingChannel{}
+	manager := newTestManager(channel)
+	manager.config.Thresholds.AlertCooldown = "1h"
+	manager.config.Thresholds.SSLExpiryWarningDays = []int{30}
+
+	ctx := context.Background()
+	manager.ProcessResult(ctx, monitor.Result{Name: "dns", Success: true, Change: "a A answer changed from [1] to [2]"})
+	manager.ProcessResult(ctx, monitor.Result{Name: "dns", Success: true,
+		Metrics: []monitor.Metric{{Label: monitor.CertificateDaysMetric, Value: 20}}})
+
+	// The outage right after is alerted within the cooldown
+	for i := 0; i < 2; i++ {
+		manager.ProcessResult(ctx, monitor.Result{Name: "dns", Success: false, Error: "SERVFAIL", Timestamp: time.Now()})
+	}
+
+	if len(channel.sent) != 3 || channel.sent[2].Type != AlertTypeSiteDown {
+		var types []AlertType
+		for _, alert := range channel.sent {
+			types = append(types, alert.Type)
+		}
+		t.Fatalf("Expected change, ssl_expiry then site_down alerts, got %v", types)
+	}
+}
+
+func TestManager_SeverityAlerts(t *testing.T) {
+	channel := &recordingChannel{}
+	manager := newTestManager(channel)
+
+	ctx := context.Background()
+	severities := []monitor.Severity{
+		monitor.SeverityOK,
+		monitor.SeverityWarning,
+		monitor.SeverityWarning, // Still degraded, no new alert
+		monitor.SeverityOK,
+		monitor.SeverityUnknown,
+		monitor.SeverityUnknown,
+	}
+	for _, severity := range severities {
+		manager.ProcessResult(ctx, monitor.Result{
+			Name:     "plugin",
+			Success:  severity == monitor.SeverityOK || severity == monitor.SeverityWarning,
+			Severity: severity,
+		})
+	}
+
+	if len(channel.sent) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d: %+v", len(channel.sent), channel.sent)
+	}
+	if channel.sent[0].Type != AlertTypeDegraded || channel.sent[0].Severity != SeverityWarning {
+		t.Errorf("Expected a warning degraded alert, got %s/%s", channel.sent[0].Type, channel.sent[0].Severity)
+	}
+	// Unknown results count as failures but alert with a lower severity
+	if channel.sent[1].Type != AlertTypeSiteDown || channel.sent[1].Severity != SeverityWarning {
+		t.Errorf("Expected a warning site down alert, got %s/%s", channel.sent[1].Type, channel.sent[1].Severity)
+	}
+}
+
+func TestManager_ReloadKeepsState(t *testing.T) {
+	manager := newTestManager(&recordingChannel{})
+
+	ctx := context.Background()
+	manager.ProcessResult(ctx, monitor.Result{Name: "site", Success: false})
+
+	reloaded := manager.config
+	reloaded.Thresholds.ConsecutiveFailures = 1
+	reloaded.Webhook = config.WebhookConfig{Enabled: true, URL: "https://hooks.example.com", Format: "generic", Timeout: "5s"}
+	manager.Reload(reloaded)
+
+	if len(manager.channels) != 1 || manager.channels[0].Name() == "Recording" {
+		t.Errorf("Expected channels to be rebuilt from the new config, got %d", len(manager.channels))
+	}
+
+	state := manager.GetAlertStates()["site"]
+	if state.ConsecutiveFails != 1 || state.IsDown {
+		t.Fatalf("Expected the failure count to survive the reload, got %+v", state)
+	}
+
+	// The next failure is evaluated against the new threshold
+	manager.channels = nil
+	manager.ProcessResult(ctx, monitor.Result{Name: "site", Success: false})
+	if !manager.GetAlertStates()["site"].IsDown {
+		t.Error("Expected the site to be marked down under the new threshold")
+	}
+
+	manager.Forget("site")
+	if _, exists := manager.GetAlertStates()["site"]; exists {
+		t.Error("Expected Forget to drop the site state")
+	}
+}
+
+func TestManager_SSLExpiryAlertsOncePerThreshold(t *testing.T) {
+	channel := &recordingChannel{}
+	manager := newTestManager(channel)
+	manager.config.Thresholds.SSLExpiryWarningDays = []int{30, 7, 1}
+
+	ctx := context.Background()
+	for _, days := range []float64{40, 29, 20, 6, 6, 1, 90, 25} {
+		severity := monitor.SeverityOK
+		if days <= 30 {
+			severity = monitor.SeverityWarning // As reported by a check with ssl_warn_days 30
+		}
+		manager.ProcessResult(ctx, monitor.Result{
+			Name:     "tls",
+			Success:  true,
+			Severity: severity,
+			Metrics:  []monitor.Metric{{Label: monitor.CertificateDaysMetric, Value: days}},
+		})
+	}
+
+	var reminders []int
+	for _, alert := range channel.sent {
+		if alert.Type == AlertTypeDegraded {
+			t.Errorf("Expected the expiry alert to stand in for the degraded alert, got %s", alert)
+			continue
+		}
+		reminders = append(reminders, alert.CertificateDays)
+	}
+
+	// 29 crosses 30, 6 crosses 7, 1 crosses 1; 90 is a renewal, so 25 alerts again
+	expected := []int{29, 6, 1, 25}
+	if len(reminders) != len(expected) {
+		t.Fatalf("Expected reminders at %v days, got %v", expected, reminders)
+	}
+	for i := range expected {
+		if reminders[i] != expected[i] {
+			t.Errorf("Expected reminders at %v days, got %v", expected, reminders)
+		}
+	}
+	if channel.sent[2].Severity != SeverityCritical {
+		t.Errorf("Expected the last threshold to be critical, got %s", channel.sent[2].Severity)
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	valid := config.AlertConfig{Templates: map[string]string{
+		"site_down_email":  "default-site-down-email",
+		"site_down_slack":  "default-site-down-slack",
+		"ssl_expiry_email": "default-ssl-expiry-email",
+	}}
+	if err := ValidateTemplates(valid); err != nil {
+		t.Fatalf("Expected templates to be valid, got %v", err)
+	}
+
+	selection := SelectTemplates(valid.Templates)
+	if selection[ChannelSlack][AlertTypeSiteDown] != "default-site-down-slack" || selection[ChannelEmail][AlertTypeSSLExpiry] == "" {
+		t.Errorf("Unexpected template selection %v", selection)
+	}
+
+	for key, id := range map[string]string{
+		"site_gone_email": "default-site-down-email",
+		"site_down_email": "no-such-template",
+		"site_up_slack":   "default-site-up-email",
+		"site_up_email":   "default-site-down-email",
+	} {
+		if err := ValidateTemplates(config.AlertConfig{Templates: map[string]string{key: id}}); err == nil {
+			t.Errorf("Expected %s: %s to be rejected", key, id)
+		}
+	}
+}
+
+func TestEmailChannel_RendersSelectedTemplate(t *testing.T) {
+	email := NewEmailChannel(config.EmailConfig{})
+	email.SetTemplates(NewTemplateManager(), map[AlertType]string{AlertTypeSSLExpiry: "default-ssl-expiry-email"})
+
+	subject, body, err := email.render(Alert{Type: AlertTypeSSLExpiry, SiteName: "shop", CertificateDays: 6, Timestamp: time.Now()})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if subject != "🔐 CERTIFICATE: shop expires in 6 days" || !strings.Contains(body, "expires in 6 days") {
+		t.Errorf("Unexpected rendering: %q\n%s", subject, body)
+	}
+
+	subject, _, err = email.render(Alert{Type: AlertTypeSiteDown, Severity: SeverityCritical, SiteName: "shop"})
+	if err != nil || subject != "[CRITICAL] Site Monitor - shop is DOWN" {
+		t.Errorf("Expected the built-in email without a template, got %q (%v)", subject, err)
+	}
+}
@@ -3,8 +3,11 @@ package alerts
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"site-monitor/config"
+	"sort"
 	"strings"
 	textTemplate "text/template"
 	"time"
@@ -115,7 +118,7 @@ func NewTemplateManager() *TemplateManager {
 // initializeDefaultTemplates creates built-in templates
 func (tm *TemplateManager) initializeDefaultTemplates() {
 	// Initialize nested maps
-	for _, alertType := range []AlertType{AlertTypeSiteDown, AlertTypeSiteUp, AlertTypeSlowResponse, AlertTypeLowUptime} {
+	for _, alertType := range alertTypes {
 		tm.defaults[alertType] = make(map[ChannelType]*AlertTemplate)
 	}
 
@@ -309,6 +312,49 @@ func (tm *TemplateManager) initializeDefaultTemplates() {
 		IsDefault: true,
 	})
 
+	// Certificate Expiry Template
+	tm.addDefaultTemplate(&AlertTemplate{
+		ID:        "default-ssl-expiry-email",
+		Name:      "SSL Expiry - Email",
+		AlertType: AlertTypeSSLExpiry,
+		Channel:   ChannelEmail,
+		Format:    FormatHTML,
+		Subject:   "🔐 CERTIFICATE: {{.SiteName}} expires in {{.CertificateDays}} days",
+		Body: `<!DOCTYPE html>
+<html>
+<head><title>Certificate Expiry Alert</title></head>
+<body style="font-family: Arial, sans-serif; margin: 20px; background-color: #f8f9fa;">
+	<div style="background: linear-gradient(135deg, #fd7e14, #e8590c); color: white; padding: 20px; border-radius: 8px 8px 0 0;">
+		<h1 style="margin: 0; font-size: 24px;">🔐 CERTIFICATE EXPIRING</h1>
+		<p style="margin: 5px 0 0 0; opacity: 0.9;">Certificate renewal reminder</p>
+	</div>
+	
+	<div style="background: white; padding: 20px; border-radius: 0 0 8px 8px; border: 1px solid #dee2e6;">
+		<div style="background: #fff3cd; color: #856404; padding: 15px; border-radius: 4px; margin-bottom: 20px;">
+			<h2 style="margin: 0 0 10px 0;">The certificate for {{.SiteName}} expires in {{.CertificateDays}} days</h2>
+			<p style="margin: 0;">{{.Details}}</p>
+		</div>
+		
+		<table style="width: 100%; border-collapse: collapse; margin: 20px 0;">
+			<tr><td style="padding: 8px; font-weight: bold; width: 30%;">Site:</td><td style="padding: 8px;">{{.SiteName}}</td></tr>
+			<tr><td style="padding: 8px; font-weight: bold;">URL:</td><td style="padding: 8px;"><a href="{{.SiteURL}}">{{.SiteURL}}</a></td></tr>
+			<tr><td style="padding: 8px; font-weight: bold;">Checked At:</td><td style="padding: 8px;">{{.Timestamp | formatTime}}</td></tr>
+		</table>
+		
+		<div style="background: #e2e3e5; color: #383d41; padding: 15px; border-radius: 4px;">
+			<h4 style="margin: 0 0 10px 0;">🔧 Recommended Actions:</h4>
+			<ul style="margin: 0; padding-left: 20px;">
+				<li>Renew the certificate with your certificate authority</li>
+				<li>Install it together with its intermediate certificates</li>
+				<li>Check that automated renewal (e.g. ACME) is working</li>
+			</ul>
+		</div>
+	</div>
+</body>
+</html>`,
+		IsDefault: true,
+	})
+
 	// Custom minimalist template
 	tm.addDefaultTemplate(&AlertTemplate{
 		ID:        "minimal-site-down-email",
@@ -549,6 +595,7 @@ func (tm *TemplateManager) prepareTemplateData(alert Alert, tmpl *AlertTemplate)
 		"UptimePercent":    alert.UptimePercent,
 		"ErrorMessage":     alert.ErrorMessage,
 		"ResolvedAt":       alert.ResolvedAt,
+		"CertificateDays":  alert.CertificateDays,
 	}
 
 	// Add custom fields if present
@@ -695,3 +742,79 @@ func formatTemplateDuration(d time.Duration) string {
 func unixTime(t time.Time) int64 {
 	return t.Unix()
 }
+
+// TemplateSelection maps each alert type to the template rendering it on a channel
+type TemplateSelection map[ChannelType]map[AlertType]string
+
+// SelectTemplates resolves the alerts.templates keys (<alert type>_<channel>)
+// into a TemplateSelection. Keys that do not resolve are reported by
+// ValidateTemplates and ignored here.
+func SelectTemplates(templates map[string]string) TemplateSelection {
+	selection := make(TemplateSelection)
+	for key, id := range templates {
+		alertType, channel, ok := parseTemplateKey(key)
+		if !ok {
+			continue
+		}
+		if selection[channel] == nil {
+			selection[channel] = make(map[AlertType]string)
+		}
+		selection[channel][alertType] = id
+	}
+	return selection
+}
+
+// parseTemplateKey splits an alerts.templates key into alert type and channel
+func parseTemplateKey(key string) (AlertType, ChannelType, bool) {
+	i := strings.LastIndex(key, "_")
+	if i <= 0 {
+		return "", "", false
+	}
+
+	alertType, channel := AlertType(key[:i]), ChannelType(key[i+1:])
+	for _, known := range alertTypes {
+		if alertType == known {
+			return alertType, channel, true
+		}
+	}
+	return "", "", false
+}
+
+// ValidateTemplates checks that every alerts.templates entry names a known
+// alert type and a built-in template for the same channel. Problems are
+// returned as *config.FieldError, like config.Validate.
+func ValidateTemplates(alertConfig config.AlertConfig) error {
+	tm := NewTemplateManager()
+
+	keys := make([]string, 0, len(alertConfig.Templates))
+	for key := range alertConfig.Templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		id := alertConfig.Templates[key]
+		if id == "" {
+			continue // Reported by config.Validate
+		}
+		path := "alerts.templates." + key
+
+		alertType, channel, ok := parseTemplateKey(key)
+		if !ok {
+			errs = append(errs, &config.FieldError{Path: path, Message: fmt.Sprintf("unknown alert type in %q", key)})
+			continue
+		}
+
+		tmpl, exists := tm.GetTemplate(id)
+		switch {
+		case !exists:
+			errs = append(errs, &config.FieldError{Path: path, Message: fmt.Sprintf("unknown template %q", id)})
+		case tmpl.Channel != channel:
+			errs = append(errs, &config.FieldError{Path: path, Message: fmt.Sprintf("template %q is for %s, not %s", id, tmpl.Channel, channel)})
+		case tmpl.AlertType != alertType:
+			errs = append(errs, &config.FieldError{Path: path, Message: fmt.Sprintf("template %q renders %s alerts, not %s", id, tmpl.AlertType, alertType)})
+		}
+	}
+	return errors.Join(errs...)
+}
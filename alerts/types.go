@@ -1,6 +1,7 @@
 package alerts
 
 import (
+	"context"
 	"fmt"
 	"site-monitor/monitor"
 	"time"
@@ -14,8 +15,17 @@ const (
 	AlertTypeSiteUp       AlertType = "site_up"
 	AlertTypeSlowResponse AlertType = "slow_response"
 	AlertTypeLowUptime    AlertType = "low_uptime"
+	AlertTypeChange       AlertType = "change_detected"
+	AlertTypeDegraded     AlertType = "site_degraded"
+	AlertTypeSSLExpiry    AlertType = "ssl_expiry"
 )
 
+// alertTypes lists every alert type, e.g. to resolve alerts.templates keys
+var alertTypes = []AlertType{
+	AlertTypeSiteDown, AlertTypeSiteUp, AlertTypeSlowResponse, AlertTypeLowUptime,
+	AlertTypeChange, AlertTypeDegraded, AlertTypeSSLExpiry,
+}
+
 // AlertSeverity represents the severity level of an alert
 type AlertSeverity string
 
@@ -44,12 +54,13 @@ type Alert struct {
 	ConsecutiveFails int           `json:"consecutive_fails,omitempty"`
 	UptimePercent    float64       `json:"uptime_percent,omitempty"`
 	ErrorMessage     string        `json:"error_message,omitempty"`
+	CertificateDays  int           `json:"certificate_days,omitempty"` // Days left on the certificate (ssl_expiry alerts)
 }
 
 // AlertChannel defines the interface for sending alerts
 type AlertChannel interface {
-	// Send sends an alert through this channel
-	Send(alert Alert) error
+	// Send sends an alert through this channel, aborting if ctx is cancelled
+	Send(ctx context.Context, alert Alert) error
 
 	// Test verifies the channel configuration
 	Test() error
@@ -62,11 +73,13 @@ type AlertChannel interface {
 type AlertState struct {
 	SiteName         string    `json:"site_name"`
 	IsDown           bool      `json:"is_down"`
+	IsDegraded       bool      `json:"is_degraded"` // Last result had warning severity and was alerted on
 	ConsecutiveFails int       `json:"consecutive_fails"`
 	LastFailTime     time.Time `json:"last_fail_time,omitempty"`
 	LastSuccessTime  time.Time `json:"last_success_time,omitempty"`
 	LastAlertTime    time.Time `json:"last_alert_time,omitempty"`
-	ActiveAlerts     []string  `json:"active_alerts"` // Alert IDs
+	ActiveAlerts     []string  `json:"active_alerts"`             // Alert IDs
+	SSLWarnedDays    int       `json:"ssl_warned_days,omitempty"` // Lowest ssl_expiry_warning_days threshold alerted on
 }
 
 // String returns a formatted string representation of the alert
@@ -80,11 +93,23 @@ func (a Alert) String() string {
 		return fmt.Sprintf("⚠️ SLOW RESPONSE: %s is responding slowly (%v)", a.SiteName, a.ResponseTime)
 	case AlertTypeLowUptime:
 		return fmt.Sprintf("📉 LOW UPTIME: %s uptime is %.1f%%", a.SiteName, a.UptimePercent)
+	case AlertTypeChange:
+		return fmt.Sprintf("🔀 CHANGE DETECTED: %s", a.Details)
+	case AlertTypeDegraded:
+		return fmt.Sprintf("⚠️ SITE DEGRADED: %s reports a warning", a.SiteName)
+	case AlertTypeSSLExpiry:
+		return fmt.Sprintf("🔐 SSL EXPIRY: %s certificate expires in %d days", a.SiteName, a.CertificateDays)
 	default:
 		return fmt.Sprintf("🔔 ALERT: %s - %s", a.SiteName, a.Message)
 	}
 }
 
+// bypassesCooldown reports one-off alerts, which are sent regardless of the
+// cooldown and therefore do not start it either
+func (a Alert) bypassesCooldown() bool {
+	return a.Type == AlertTypeChange || a.Type == AlertTypeSSLExpiry
+}
+
 // IsRecoveryAlert returns true if this is a recovery/resolution alert
 func (a Alert) IsRecoveryAlert() bool {
 	return a.Type == AlertTypeSiteUp
@@ -2,6 +2,7 @@ package alerts
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,8 +14,10 @@ import (
 
 // WebhookChannel implements AlertChannel for webhook notifications
 type WebhookChannel struct {
-	config config.WebhookConfig
-	client *http.Client
+	config      config.WebhookConfig
+	client      *http.Client
+	templates   *TemplateManager
+	templateIDs map[AlertType]string // Alert types whose payload is rendered from a JSON template
 }
 
 // NewWebhookChannel creates a new webhook alert channel
@@ -34,13 +37,33 @@ func NewWebhookChannel(cfg config.WebhookConfig) *WebhookChannel {
 	}
 }
 
+// SetTemplates renders the payload of the given alert types with templates from tm
+func (w *WebhookChannel) SetTemplates(tm *TemplateManager, ids map[AlertType]string) {
+	w.templates = tm
+	w.templateIDs = ids
+}
+
+// TemplateChannel returns the template channel matching the payload format
+func (w *WebhookChannel) TemplateChannel() ChannelType {
+	switch w.config.Format {
+	case "slack":
+		return ChannelSlack
+	case "discord":
+		return ChannelDiscord
+	case "teams":
+		return ChannelTeams
+	default:
+		return ChannelWebhook
+	}
+}
+
 // Name returns the channel name
 func (w *WebhookChannel) Name() string {
 	return fmt.Sprintf("Webhook (%s)", w.config.Format)
 }
 
-// Send sends an alert via webhook
-func (w *WebhookChannel) Send(alert Alert) error {
+// Send sends an alert via webhook, retrying with backoff until ctx is cancelled
+func (w *WebhookChannel) Send(ctx context.Context, alert Alert) error {
 	payload, err := w.generatePayload(alert)
 	if err != nil {
 		return fmt.Errorf("failed to generate webhook payload: %w", err)
@@ -54,13 +77,17 @@ func (w *WebhookChannel) Send(alert Alert) error {
 
 	var lastError error
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if err := w.sendRequest(payload); err != nil {
+		if err := w.sendRequest(ctx, payload); err != nil {
 			lastError = err
 			if attempt < maxRetries {
 				// Wait before retry (exponential backoff)
 				waitTime := time.Duration(attempt*attempt) * time.Second
-				time.Sleep(waitTime)
-				continue
+				select {
+				case <-time.After(waitTime):
+					continue
+				case <-ctx.Done():
+					return fmt.Errorf("webhook retry aborted after %d attempts: %w", attempt, ctx.Err())
+				}
 			}
 		} else {
 			return nil // Success
@@ -92,11 +119,16 @@ func (w *WebhookChannel) Test() error {
 		Timestamp: time.Now(),
 	}
 
-	return w.Send(testAlert)
+	return w.Send(context.Background(), testAlert)
 }
 
 // generatePayload creates the webhook payload based on the configured format
 func (w *WebhookChannel) generatePayload(alert Alert) ([]byte, error) {
+	if id, ok := w.templateIDs[alert.Type]; ok && w.templates != nil {
+		_, body, err := w.templates.RenderTemplate(id, alert)
+		return []byte(body), err
+	}
+
 	switch w.config.Format {
 	case "slack":
 		return w.generateSlackPayload(alert)
@@ -214,8 +246,8 @@ func (w *WebhookChannel) generateGenericPayload(alert Alert) ([]byte, error) {
 }
 
 // sendRequest sends the HTTP request to the webhook URL
-func (w *WebhookChannel) sendRequest(payload []byte) error {
-	req, err := http.NewRequest("POST", w.config.URL, bytes.NewBuffer(payload))
+func (w *WebhookChannel) sendRequest(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", w.config.URL, bytes.NewBuffer(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -7,32 +7,50 @@ import (
 	"site-monitor/storage"
 )
 
+// DefaultConfigPath is the configuration file used when --config is not given
+const DefaultConfigPath = "config.json"
+
 // CLIApp represents the main CLI application
 type CLIApp struct {
-	storage storage.Storage
-	config  *config.Config
-	dbPath  string
+	storage    storage.Storage
+	config     *config.Config
+	configPath string
+	dbPath     string // Database file, or the redacted DSN with the postgres driver
+	dbDriver   string
 }
 
 // NewCLIApp creates a new CLI application instance
 func NewCLIApp() (*CLIApp, error) {
 	return &CLIApp{
-		dbPath: "site-monitor.db",
+		configPath: DefaultConfigPath,
+		dbPath:     config.DefaultDatabasePath,
+		dbDriver:   config.StorageSQLite,
 	}, nil
 }
 
-// InitStorage initializes the storage connection
+// SetConfigPath sets the configuration file loaded by LoadConfig
+func (app *CLIApp) SetConfigPath(path string) {
+	app.configPath = path
+}
+
+// ConfigPath returns the configuration file used by the app
+func (app *CLIApp) ConfigPath() string {
+	return app.configPath
+}
+
+// InitStorage initializes the storage connection, migrating the schema
 func (app *CLIApp) InitStorage() error {
 	if app.storage != nil {
 		return nil // Already initialized
 	}
 
-	db, err := storage.NewSQLiteStorage(app.dbPath)
+	db, err := app.openStorage()
 	if err != nil {
-		return fmt.Errorf("failed to initialize storage: %w", err)
+		return err
 	}
 
 	if err := db.Init(); err != nil {
+		db.Close()
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 
@@ -40,9 +58,35 @@ func (app *CLIApp) InitStorage() error {
 	return nil
 }
 
+// openStorage opens the configured database without touching its schema
+func (app *CLIApp) openStorage() (storage.Storage, error) {
+	// The database is taken from the storage section when a configuration
+	// file exists; commands run without one use the default SQLite database
+	if app.config == nil {
+		if _, err := os.Stat(app.configPath); err == nil {
+			if err := app.LoadConfig(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var storageConfig config.StorageConfig
+	if app.config != nil && app.config.Storage != nil {
+		storageConfig = *app.config.Storage
+	}
+	app.dbPath = storageConfig.Location()
+	app.dbDriver = storageConfig.GetDriver()
+
+	db, err := storage.Open(storageConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return db, nil
+}
+
 // LoadConfig loads the configuration file
 func (app *CLIApp) LoadConfig() error {
-	cfg, err := config.Load("config.json")
+	cfg, err := config.Load(app.configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
@@ -58,8 +102,12 @@ func (app *CLIApp) Close() error {
 	return nil
 }
 
-// CheckDatabaseExists verifies if the database file exists
+// CheckDatabaseExists verifies if the database file exists. A PostgreSQL
+// database is assumed to, connecting reports otherwise.
 func (app *CLIApp) CheckDatabaseExists() bool {
+	if app.dbDriver == config.StoragePostgres {
+		return true
+	}
 	_, err := os.Stat(app.dbPath)
 	return !os.IsNotExist(err)
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"site-monitor/alerts"
+	"site-monitor/config"
+	"site-monitor/monitor"
+)
+
+// ConfigValidateOptions contains options for the config validate command
+type ConfigValidateOptions struct {
+	Path string
+}
+
+// ValidateConfig loads a configuration file and reports every problem found,
+// including check-type specific options, without starting any check.
+// It returns an error when the configuration is invalid.
+func (app *CLIApp) ValidateConfig(opts ConfigValidateOptions) error {
+	cfg, err := config.Load(opts.Path)
+	if err != nil {
+		printProblems(opts.Path, err)
+		return fmt.Errorf("invalid configuration: %s", opts.Path)
+	}
+
+	problems := cfg.Validate()
+	if cfg.Alerts != nil {
+		problems = errors.Join(problems, alerts.ValidateTemplates(*cfg.Alerts))
+	}
+
+	// Build the checkers of sites that passed the generic checks, so options
+	// specific to a check type are validated too
+	invalid := make(map[string]bool)
+	for _, problem := range flattenErrors(problems) {
+		var fieldErr *config.FieldError
+		if errors.As(problem, &fieldErr) {
+			invalid[sitePath(fieldErr.Path)] = true
+		}
+	}
+	for i, site := range cfg.Sites {
+		path := fmt.Sprintf("sites[%d]", i)
+		if invalid[path] {
+			continue
+		}
+		if _, err := monitor.NewFromSite(site); err != nil {
+			problems = errors.Join(problems, &config.FieldError{Path: path, Message: err.Error()})
+		}
+	}
+
+	if problems != nil {
+		printProblems(opts.Path, problems)
+		return fmt.Errorf("invalid configuration: %s", opts.Path)
+	}
+
+	fmt.Printf("✅ %s is valid (%d sites)\n", opts.Path, len(cfg.Sites))
+	return nil
+}
+
+// printProblems lists each problem of a joined error on its own line
+func printProblems(path string, err error) {
+	problems := flattenErrors(err)
+	fmt.Printf("❌ %s has %d problem(s):\n", path, len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  • %v\n", problem)
+	}
+}
+
+// flattenErrors expands errors created by errors.Join
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(e)...)
+	}
+	return errs
+}
+
+// sitePath returns the "sites[N]" prefix of a JSON path, if any
+func sitePath(path string) string {
+	for i, c := range path {
+		if c == ']' {
+			return path[:i+1]
+		}
+	}
+	return ""
+}
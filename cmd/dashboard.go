@@ -1,17 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"site-monitor/web"
 )
 
 // DashboardOptions contains options for the dashboard command
 type DashboardOptions struct {
-	Port int
+	Port int // Zero uses dashboard.port from the configuration
 }
 
-// ShowDashboard starts the web dashboard server
-func (app *CLIApp) ShowDashboard(opts DashboardOptions) error {
+// ShowDashboard starts the web dashboard server and blocks until ctx is cancelled
+func (app *CLIApp) ShowDashboard(ctx context.Context, opts DashboardOptions) error {
 	// Initialize storage
 	if err := app.InitStorage(); err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
@@ -33,11 +34,21 @@ func (app *CLIApp) ShowDashboard(opts DashboardOptions) error {
 	}
 
 	// Create dashboard instance
-	dashboard := web.NewDashboard(app.storage, app.config, opts.Port)
+	dashboard, err := web.NewDashboard(app.storage, app.config, opts.Port)
+	if err != nil {
+		return fmt.Errorf("failed to create dashboard: %w", err)
+	}
+	port := dashboard.Port()
+
+	drainTimeout, err := app.config.GetDrainTimeout()
+	if err != nil {
+		return fmt.Errorf("invalid drain_timeout: %w", err)
+	}
+	dashboard.SetShutdownTimeout(drainTimeout)
 
 	// Print startup information
 	fmt.Printf("🌐 Starting Site Monitor Dashboard\n")
-	fmt.Printf("📊 Port: %d\n", opts.Port)
+	fmt.Printf("📊 Port: %d\n", port)
 	fmt.Printf("💾 Database: %s\n", app.dbPath)
 	fmt.Printf("📋 Sites configured: %d\n", len(app.config.Sites))
 
@@ -52,12 +63,14 @@ func (app *CLIApp) ShowDashboard(opts DashboardOptions) error {
 		fmt.Printf("🚨 Alert channels: %d\n", alertChannels)
 	}
 
-	fmt.Printf("\n🚀 Dashboard available at: http://localhost:%d\n", opts.Port)
-	fmt.Printf("📱 WebSocket endpoint: ws://localhost:%d/ws\n", opts.Port)
+	fmt.Printf("\n🚀 Dashboard available at: http://localhost:%d\n", port)
+	if app.config.Dashboard.GetFeatures().RealTimeUpdates {
+		fmt.Printf("📱 WebSocket endpoint: ws://localhost:%d/ws\n", port)
+	}
 	fmt.Printf("\n💡 Press Ctrl+C to stop the dashboard\n\n")
 
-	// Start the dashboard server (blocking)
-	if err := dashboard.Start(); err != nil {
+	// Start the dashboard server (blocking until ctx is cancelled)
+	if err := dashboard.Start(ctx); err != nil {
 		return fmt.Errorf("dashboard server error: %w", err)
 	}
 
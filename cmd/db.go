@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"site-monitor/storage"
+)
+
+// openMigrator opens the database for the db commands
+func (app *CLIApp) openMigrator() (storage.Storage, storage.Migrator, error) {
+	db, err := app.openStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrator, ok := db.(storage.Migrator)
+	if !ok {
+		db.Close()
+		return nil, nil, fmt.Errorf("storage does not support migrations")
+	}
+	return db, migrator, nil
+}
+
+// MigrateDatabase applies the pending schema migrations
+func (app *CLIApp) MigrateDatabase() error {
+	db, migrator, err := app.openMigrator()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := migrator.Migrate()
+	for _, migration := range applied {
+		fmt.Printf("✅ Applied %03d %s\n", migration.Version, migration.Description)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Printf("✅ %s is up to date\n", app.dbPath)
+	}
+	return nil
+}
+
+// ShowDatabaseStatus prints the schema version and every migration's state
+// without applying any
+func (app *CLIApp) ShowDatabaseStatus() error {
+	db, migrator, err := app.openMigrator()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if !app.CheckDatabaseExists() {
+		app.ShowDatabaseNotFoundError()
+		return nil
+	}
+
+	status, err := migrator.SchemaStatus()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🗄️  Database: %s\n", app.dbPath)
+	fmt.Printf("   Schema version: %d (latest: %d)\n", status.Current, status.Latest)
+	fmt.Println()
+
+	for _, migration := range status.Migrations {
+		if migration.AppliedAt.IsZero() {
+			fmt.Printf("   ⏳ %03d %-45s pending\n", migration.Version, migration.Description)
+			continue
+		}
+		fmt.Printf("   ✅ %03d %-45s applied %s\n", migration.Version, migration.Description,
+			migration.AppliedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	switch pending := len(status.Pending()); {
+	case status.Current > status.Latest:
+		fmt.Printf("\n❌ The database was migrated by a newer version of site-monitor (version %d)\n", status.Current)
+	case pending > 0:
+		fmt.Printf("\n⚠️ %d pending migration(s); run 'site-monitor db migrate' or start the monitor\n", pending)
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"site-monitor/alerts"
+	"site-monitor/config"
 	"site-monitor/metrics"
 	"site-monitor/reports"
 	"site-monitor/ssl"
@@ -47,20 +49,38 @@ func (app *EnhancedCLIApp) InitEnhancedFeatures() error {
 	}
 
 	// Initialize SSL checker
-	app.sslChecker = ssl.NewSSLChecker(10 * time.Second)
+	sslConfig := config.SSLConfig{Enabled: true}
+	if app.config.SSL != nil {
+		sslConfig = *app.config.SSL
+	}
+	sslChecker, err := ssl.NewFromConfig(sslConfig)
+	if err != nil {
+		return err
+	}
+	app.sslChecker = sslChecker
 
 	// Initialize metrics calculator
 	app.metricsCalc = metrics.NewAdvancedMetricsCalculator(app.storage)
+	if app.config.Metrics != nil {
+		if err := app.metricsCalc.SetConfig(*app.config.Metrics); err != nil {
+			return fmt.Errorf("invalid metrics configuration: %w", err)
+		}
+	}
 
 	// Initialize report scheduler
 	if app.config.Alerts != nil {
 		app.reportScheduler = reports.NewReportScheduler(app.storage, app.config.Alerts.Email)
+		if err := app.reportScheduler.Configure(app.config); err != nil {
+			return err
+		}
 
 		// Initialize alert manager with templates
 		app.alertManager = alerts.NewManager(*app.config.Alerts, app.storage)
 
-		// Set up default report schedules
-		app.setupDefaultReports()
+		// Without configured schedules, set up the default ones
+		if app.config.Reports == nil {
+			app.setupDefaultReports()
+		}
 	}
 
 	return nil
@@ -76,8 +96,11 @@ func (app *EnhancedCLIApp) ShowSSLStatus() error {
 	fmt.Println(strings.Repeat("━", 70))
 
 	for _, site := range app.config.Sites {
-		sslCheck := app.sslChecker.CheckSSL(site.URL)
-		app.printSSLStatus(sslCheck)
+		if !strings.HasPrefix(site.URL, "https://") {
+			continue
+		}
+		sslCheck := app.sslChecker.CheckSite(site)
+		app.printSSLStatus(sslCheck, app.sslWarnDays(site))
 		fmt.Println()
 	}
 
@@ -114,7 +137,7 @@ func (app *EnhancedCLIApp) SendTestReport() error {
 	}
 
 	if app.reportScheduler == nil {
-		return fmt.Errorf("email reporting not configured - check your config.json alerts.email section")
+		return fmt.Errorf("email reporting not configured - check the alerts.email section of your configuration")
 	}
 
 	fmt.Println("📧 Sending test email report...")
@@ -215,13 +238,26 @@ func (app *EnhancedCLIApp) TestAlertTemplate(templateID string) error {
 	return nil
 }
 
+// sslWarnDays returns how many days before expiry a site's certificate is flagged
+func (app *EnhancedCLIApp) sslWarnDays(site config.Site) int {
+	if site.SSLWarnDays > 0 {
+		return site.SSLWarnDays
+	}
+	if app.config.SSL != nil {
+		return app.config.SSL.GetWarningDays()
+	}
+	return config.DefaultSSLWarnDays
+}
+
 // printSSLStatus prints SSL certificate status for a site
-func (app *EnhancedCLIApp) printSSLStatus(check ssl.SSLCheck) {
+func (app *EnhancedCLIApp) printSSLStatus(check ssl.SSLCheck, warnDays int) {
 	// Status icon and basic info
 	statusIcon := "✅"
 	if check.Error != "" {
 		statusIcon = "❌"
-	} else if check.IsExpiringSoon(30) {
+	} else if check.Revoked || check.ChainError != "" {
+		statusIcon = "🔴"
+	} else if check.IsExpiringSoon(warnDays) {
 		statusIcon = "⚠️"
 	} else if check.IsExpired() {
 		statusIcon = "🔴"
@@ -242,8 +278,17 @@ func (app *EnhancedCLIApp) printSSLStatus(check ssl.SSLCheck) {
 		check.GetExpiryStatus())
 	fmt.Printf("   ⚡ Response Time: %v\n", check.ResponseTime.Round(time.Millisecond))
 
+	if check.ChainError != "" {
+		fmt.Printf("   🔴 Chain: %s\n", check.ChainError)
+	}
+	if check.Revoked {
+		fmt.Printf("   🔴 Certificate has been revoked\n")
+	} else if check.RevocationError != "" {
+		fmt.Printf("   ⚠️  Revocation check failed: %s\n", check.RevocationError)
+	}
+
 	// Warning for soon-to-expire certificates
-	if check.IsExpiringSoon(30) {
+	if check.IsExpiringSoon(warnDays) {
 		fmt.Printf("   ⚠️  WARNING: Certificate expires in %d days!\n", check.DaysUntilExpiry)
 	}
 
@@ -323,6 +368,14 @@ func (app *EnhancedCLIApp) printAdvancedMetrics(m *metrics.AdvancedMetrics) {
 	fmt.Printf("      • P95: %v\n", m.P95.Round(time.Millisecond))
 	fmt.Printf("      • P99: %v\n", m.P99.Round(time.Millisecond))
 	fmt.Printf("      • Std Dev: %v\n", m.ResponseTimeStdDev.Round(time.Millisecond))
+	if tp := m.TimingPercentiles; tp.TTFB.P95 > 0 {
+		fmt.Printf("      • P95 Phases: dns %v, connect %v, tls %v, ttfb %v, transfer %v\n",
+			tp.DNS.P95.Round(time.Millisecond),
+			tp.Connect.P95.Round(time.Millisecond),
+			tp.TLS.P95.Round(time.Millisecond),
+			tp.TTFB.P95.Round(time.Millisecond),
+			tp.Transfer.P95.Round(time.Millisecond))
+	}
 
 	// Reliability metrics
 	if m.MTTR > 0 {
@@ -404,6 +457,7 @@ func (app *EnhancedCLIApp) setupDefaultReports() {
 			reports.SectionRecommendations,
 		},
 		Enabled: false, // Disabled by default, user can enable
+		Hour:    config.DefaultReportHour,
 	}
 
 	// Daily operational report
@@ -419,6 +473,7 @@ func (app *EnhancedCLIApp) setupDefaultReports() {
 			reports.SectionAlertsSummary,
 		},
 		Enabled: false, // Disabled by default
+		Hour:    config.DefaultReportHour,
 	}
 
 	app.reportScheduler.AddSchedule(weeklyReport)
@@ -460,6 +515,14 @@ func (app *EnhancedCLIApp) getTrendIcon(trend metrics.TrendDirection) string {
 	}
 }
 
+// enabledString describes a feature toggle
+func enabledString(enabled bool) string {
+	if enabled {
+		return "Enabled"
+	}
+	return "Disabled"
+}
+
 // formatDurationString formats a duration for display
 func (app *EnhancedCLIApp) formatDurationString(d time.Duration) string {
 	if d < time.Minute {
@@ -489,8 +552,8 @@ func (app *EnhancedCLIApp) formatDurationString(d time.Duration) string {
 	return fmt.Sprintf("%dd%dh", days, hours)
 }
 
-// StartEnhancedMonitor starts monitoring with enhanced features
-func (app *EnhancedCLIApp) StartEnhancedMonitor() error {
+// StartEnhancedMonitor starts monitoring with enhanced features and blocks until ctx is cancelled
+func (app *EnhancedCLIApp) StartEnhancedMonitor(ctx context.Context) error {
 	if err := app.InitEnhancedFeatures(); err != nil {
 		return err
 	}
@@ -501,13 +564,13 @@ func (app *EnhancedCLIApp) StartEnhancedMonitor() error {
 
 	// Start report scheduler if configured
 	if app.reportScheduler != nil {
-		app.reportScheduler.Start()
+		app.reportScheduler.Start(ctx)
 		fmt.Printf("📧 Email reports: Enabled\n")
 	}
 
 	// Enhanced feature summary
-	fmt.Printf("🔐 SSL monitoring: Enabled\n")
-	fmt.Printf("📊 Advanced metrics: Enabled\n")
+	fmt.Printf("🔐 SSL monitoring: %s\n", enabledString(app.config.SSL == nil || app.config.SSL.Enabled))
+	fmt.Printf("📊 Advanced metrics: %s\n", enabledString(app.config.Metrics == nil || app.config.Metrics.Advanced.Enabled))
 	fmt.Printf("🎨 Alert templates: %d templates loaded\n", len(app.templateManager.ListTemplates(nil)))
 
 	fmt.Println()
@@ -523,7 +586,22 @@ func (app *EnhancedCLIApp) StartEnhancedMonitor() error {
 	fmt.Println("Starting monitoring loop...")
 
 	// For now, just indicate enhanced monitoring is active
-	select {} // Block forever (in real implementation, this would be the monitoring loop)
+	<-ctx.Done()
+
+	if app.reportScheduler != nil {
+		drainTimeout, err := app.config.GetDrainTimeout()
+		if err != nil {
+			drainTimeout = config.DefaultDrainTimeout
+		}
+
+		select {
+		case <-app.reportScheduler.Done():
+		case <-time.After(drainTimeout):
+			fmt.Println("⚠️ Timed out waiting for report scheduler to stop")
+		}
+	}
+
+	return nil
 }
 
 // CLI Command Handlers
@@ -554,9 +632,22 @@ func (app *EnhancedCLIApp) HandleReportCommand(args []string) error {
 		return app.SetupReportSchedule("Custom Report", reports.ScheduleWeekly,
 			app.config.Alerts.Email.Recipients)
 	case "list":
+		if err := app.InitEnhancedFeatures(); err != nil {
+			return err
+		}
+		if app.reportScheduler == nil {
+			return fmt.Errorf("email reporting not configured")
+		}
+
 		fmt.Println("📋 Scheduled Reports:")
-		fmt.Println("• Weekly Executive Summary (disabled)")
-		fmt.Println("• Daily Operations Report (disabled)")
+		for _, schedule := range app.reportScheduler.Schedules() {
+			status := "enabled"
+			if !schedule.Enabled {
+				status = "disabled"
+			}
+			fmt.Printf("• %s (%s, %s) - next %s\n", schedule.Name, schedule.Schedule, status,
+				schedule.NextDue.Format("2006-01-02 15:04"))
+		}
 		return nil
 	default:
 		return fmt.Errorf("unknown report subcommand: %s", args[0])
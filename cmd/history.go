@@ -146,12 +146,30 @@ func (app *CLIApp) printHistoryEntry(entry storage.HistoryEntry, showSiteName bo
 		entry.Status,
 		entry.Duration.Round(time.Millisecond))
 
+	// Retries (if any)
+	if entry.Attempts > 1 {
+		fmt.Printf(" (%d attempts)", entry.Attempts)
+	}
+
 	// Error message (if any)
 	if entry.Error != "" {
 		fmt.Printf(" - %s", entry.Error)
 	}
 
 	fmt.Println()
+
+	// Transaction steps, up to the one that failed
+	for i, step := range entry.Steps {
+		stepIcon := "✅"
+		if !step.Success {
+			stepIcon = "❌"
+		}
+		fmt.Printf("      %d. %s %s - %d - %v", i+1, stepIcon, step.Name, step.Status, step.Duration.Round(time.Millisecond))
+		if step.Error != "" {
+			fmt.Printf(" - %s", step.Error)
+		}
+		fmt.Println()
+	}
 }
 
 // showHistorySummary shows a summary of the history entries
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"site-monitor/config"
+	"site-monitor/monitor"
 	"site-monitor/storage"
 	"strings"
 	"time"
@@ -112,12 +114,34 @@ func (app *CLIApp) printSiteStats(stats storage.Stats) {
 	fmt.Printf("   📈 Uptime: %.1f%% (%d/%d checks)\n",
 		stats.SuccessRate, stats.SuccessfulChecks, stats.TotalChecks)
 
+	// Per address family breakdown for sites with an ip_version
+	for _, family := range []string{config.IPv4, config.IPv6} {
+		if familyStats, ok := stats.Families[family]; ok {
+			fmt.Printf("   🌐 IP%s: %.1f%% (%d/%d checks), %v avg\n",
+				family, familyStats.SuccessRate, familyStats.SuccessfulChecks, familyStats.TotalChecks,
+				familyStats.AvgResponseTime.Round(time.Millisecond))
+		}
+	}
+
 	// Response time stats
 	if stats.SuccessfulChecks > 0 {
 		fmt.Printf("   ⚡ Response: %v avg (min: %v, max: %v)\n",
 			stats.AvgResponseTime.Round(time.Millisecond),
 			stats.MinResponseTime.Round(time.Millisecond),
 			stats.MaxResponseTime.Round(time.Millisecond))
+		fmt.Printf("   📐 Percentiles: p50 %v, p95 %v, p99 %v\n",
+			stats.P50ResponseTime.Round(time.Millisecond),
+			stats.P95ResponseTime.Round(time.Millisecond),
+			stats.P99ResponseTime.Round(time.Millisecond))
+
+		if t := stats.AvgTimings; t != (monitor.Timings{}) {
+			fmt.Printf("   🔬 Phases: dns %v, connect %v, tls %v, ttfb %v, transfer %v\n",
+				t.DNS.Round(time.Millisecond),
+				t.Connect.Round(time.Millisecond),
+				t.TLS.Round(time.Millisecond),
+				t.TTFB.Round(time.Millisecond),
+				t.Transfer.Round(time.Millisecond))
+		}
 	}
 
 	// Last check timing
@@ -1,23 +1,184 @@
 package config
 
 import (
-	"encoding/json"
-	"os"
+	"strings"
 	"time"
 )
 
 // Config represents the main configuration structure
 type Config struct {
-	Sites  []Site       `json:"sites"`
-	Alerts *AlertConfig `json:"alerts,omitempty"`
+	Sites        []Site           `json:"sites"`
+	Alerts       *AlertConfig     `json:"alerts,omitempty"`
+	Scheduler    *SchedulerConfig `json:"scheduler,omitempty"`
+	DrainTimeout string           `json:"drain_timeout,omitempty"` // Max time to flush pending work on shutdown (e.g., "30s")
+
+	Reports   *ReportsConfig   `json:"reports,omitempty"`
+	Metrics   *MetricsConfig   `json:"metrics,omitempty"`
+	SSL       *SSLConfig       `json:"ssl,omitempty"`
+	Storage   *StorageConfig   `json:"storage,omitempty"`
+	Dashboard *DashboardConfig `json:"dashboard,omitempty"`
+	Logging   *LoggingConfig   `json:"logging,omitempty"`
+	API       *APIConfig       `json:"api,omitempty"`
 }
 
+// DefaultDrainTimeout is used when no drain_timeout is configured
+const DefaultDrainTimeout = 30 * time.Second
+
 // Site represents a single website to monitor
 type Site struct {
-	Name     string `json:"name"`     // Display name for the site
-	URL      string `json:"url"`      // URL to monitor
-	Interval string `json:"interval"` // How often to check (e.g., "30s", "5m")
-	Timeout  string `json:"timeout"`  // HTTP request timeout
+	Name     string `json:"name"`           // Display name for the site
+	Type     string `json:"type,omitempty"` // Check type (default: "http")
+	URL      string `json:"url"`            // URL to monitor
+	Interval string `json:"interval"`       // How often to check (e.g., "30s", "5m")
+	Timeout  string `json:"timeout"`        // Check timeout (default: 10s)
+
+	// Failure handling
+	Retries    int    `json:"retries,omitempty"`     // Extra attempts within a check before it fails (default: 0)
+	RetryDelay string `json:"retry_delay,omitempty"` // Wait between attempts (default: 1s)
+	Confirm    bool   `json:"confirm,omitempty"`     // Re-check immediately before a failure is recorded
+
+	// HTTP request options
+	Method              string            `json:"method,omitempty"`                // HTTP method (default: GET)
+	Headers             map[string]string `json:"headers,omitempty"`               // Extra request headers
+	Body                string            `json:"body,omitempty"`                  // Request body
+	Auth                *AuthConfig       `json:"auth,omitempty"`                  // Basic or bearer authentication
+	FollowRedirects     *bool             `json:"follow_redirects,omitempty"`      // Follow redirects (default: true)
+	MaxRedirects        int               `json:"max_redirects,omitempty"`         // Max redirect hops when following (default: 10)
+	ExpectedStatusCodes []int             `json:"expected_status_codes,omitempty"` // Accepted status codes (default: 200-399)
+
+	// Certificate expiry (https URLs only)
+	SSLCheck    bool `json:"ssl_check,omitempty"`     // Report a warning when the certificate is about to expire
+	SSLWarnDays int  `json:"ssl_warn_days,omitempty"` // Days before expiry that trigger the warning (default: 30)
+
+	// Response assertions, evaluated in order once the status code is accepted
+	// (for websocket checks, against the first message received)
+	Assertions []AssertionConfig `json:"assertions,omitempty"`
+
+	// TCP options (the URL is host:port or tcp://host:port)
+	Send        string `json:"send,omitempty"`         // Payload written after connecting (also a websocket text message)
+	Expect      string `json:"expect,omitempty"`       // Expected response prefix
+	ExpectRegex string `json:"expect_regex,omitempty"` // Expected response pattern
+
+	// DNS options (the URL is the name to resolve)
+	Resolver        string   `json:"resolver,omitempty"`         // DNS server host:port (default: system resolver)
+	RecordType      string   `json:"record_type,omitempty"`      // A, AAAA, CNAME, MX, TXT (default: A)
+	ExpectedRecords []string `json:"expected_records,omitempty"` // Values that must all be present in the answer
+
+	// Heartbeat options (jobs ping /ping/<token> on the dashboard server)
+	Token  string `json:"token,omitempty"`  // Ping URL token (default: derived from the name)
+	Period string `json:"period,omitempty"` // Expected time between pings (default: interval)
+	Grace  string `json:"grace,omitempty"`  // Extra time allowed before a missing ping fails (default: 0)
+
+	// Exec options (Nagios-compatible plugins)
+	Command string   `json:"command,omitempty"` // Plugin executable
+	Args    []string `json:"args,omitempty"`    // Plugin arguments
+
+	// gRPC options (the URL is host:port, grpc://host:port, or grpcs://host:port for TLS)
+	Service string `json:"service,omitempty"` // Health service name (default: overall server health)
+
+	// Mail server options for smtp, imap and pop3 (the URL is host:port, e.g.
+	// smtp://host:587, or smtps://, imaps://, pop3s:// for implicit TLS); TLS applies here too
+	StartTLS     bool     `json:"starttls,omitempty"`     // Upgrade the connection with STARTTLS (STLS for POP3)
+	Capabilities []string `json:"capabilities,omitempty"` // Capabilities the server must advertise (e.g., "STARTTLS", "AUTH PLAIN")
+
+	// Connection options for http, transaction, websocket, tcp, grpc and mail checks
+	TLS   *TLSConfig   `json:"tls,omitempty"`   // Client TLS settings (for grpc checks, setting them enables TLS)
+	Proxy *ProxyConfig `json:"proxy,omitempty"` // Egress proxy the connections go through
+
+	// Address family and pinning for the same check types
+	IPVersion string   `json:"ip_version,omitempty"` // v4, v6, or both for parallel probes (default: either)
+	ResolveTo []string `json:"resolve_to,omitempty"` // Addresses to connect to instead of the DNS answer; Host and SNI keep the URL host
+
+	// Transaction options: ordered HTTP steps sharing cookies and extracted
+	// variables. Site headers, auth, timeout and redirect options apply to every step.
+	Steps []StepConfig `json:"steps,omitempty"`
+}
+
+// StepConfig represents one HTTP request of a transaction. The URL, headers
+// and body may reference variables extracted by earlier steps as {{name}}.
+type StepConfig struct {
+	Name                string            `json:"name"`                            // Step name shown in results
+	URL                 string            `json:"url"`                             // URL to request
+	Method              string            `json:"method,omitempty"`                // HTTP method (default: GET)
+	Headers             map[string]string `json:"headers,omitempty"`               // Extra request headers, added to the site headers
+	Body                string            `json:"body,omitempty"`                  // Request body
+	ExpectedStatusCodes []int             `json:"expected_status_codes,omitempty"` // Accepted status codes (default: 200-399)
+	Assertions          []AssertionConfig `json:"assertions,omitempty"`            // Response assertions for this step
+	Extract             []ExtractConfig   `json:"extract,omitempty"`               // Variables captured from the response
+}
+
+// ExtractConfig captures a value from a step response into a variable
+type ExtractConfig struct {
+	Var   string `json:"var"`             // Variable name, referenced as {{var}} in later steps
+	Type  string `json:"type"`            // json_path, regex, header, cookie
+	Path  string `json:"path,omitempty"`  // JSONPath for json_path (e.g., "$.token")
+	Value string `json:"value,omitempty"` // Regex pattern (first capture group is kept), header name or cookie name
+}
+
+// AssertionConfig represents a check on the response body
+type AssertionConfig struct {
+	Type     string `json:"type"`               // contains, not_contains, regex, json_path, size
+	Value    string `json:"value,omitempty"`    // Keyword, regex pattern or expected JSON value
+	Path     string `json:"path,omitempty"`     // JSONPath for json_path (e.g., "$.status", "$.items[0].id")
+	Operator string `json:"operator,omitempty"` // For json_path: eq, ne, gt, gte, lt, lte (default: eq)
+	Min      int64  `json:"min,omitempty"`      // For size: minimum body size in bytes
+	Max      int64  `json:"max,omitempty"`      // For size: maximum body size in bytes (0 = no limit)
+}
+
+// AuthConfig represents HTTP authentication for a site
+type AuthConfig struct {
+	Type     string `json:"type"`               // basic, bearer
+	Username string `json:"username,omitempty"` // For basic auth
+	Password string `json:"password,omitempty"` // For basic auth
+	Token    string `json:"token,omitempty"`    // For bearer auth
+}
+
+// TLSConfig represents client-side TLS settings for a site
+type TLSConfig struct {
+	CAFile             string `json:"ca_file,omitempty"`              // PEM bundle used instead of the system roots
+	CertFile           string `json:"cert_file,omitempty"`            // Client certificate for mTLS
+	KeyFile            string `json:"key_file,omitempty"`             // Client key for mTLS
+	ServerName         string `json:"server_name,omitempty"`          // Name to verify instead of the host
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // Skip certificate verification
+	MinVersion         string `json:"min_version,omitempty"`          // Minimum TLS version: 1.0, 1.1, 1.2, 1.3 (default: 1.2)
+}
+
+// ProxyConfig represents an egress proxy for a site
+type ProxyConfig struct {
+	URL      string `json:"url"`                // http://, https://, socks5:// or socks5h:// (remote DNS) proxy URL
+	Username string `json:"username,omitempty"` // Proxy user, overriding any in the URL
+	Password string `json:"password,omitempty"` // Proxy password
+}
+
+// DefaultTimeout is the check timeout used when none is configured
+const DefaultTimeout = 10 * time.Second
+
+// DefaultRetryDelay is the wait between check attempts when none is configured
+const DefaultRetryDelay = time.Second
+
+// DefaultMaxRedirects is the redirect hop limit used when none is configured
+const DefaultMaxRedirects = 10
+
+// Site ip_version values
+const (
+	IPv4   = "v4"
+	IPv6   = "v6"
+	IPBoth = "both"
+)
+
+// SchedulerConfig represents the check scheduler configuration
+type SchedulerConfig struct {
+	Workers   int    `json:"workers"`    // Maximum concurrent checks (default: 10)
+	QueueSize int    `json:"queue_size"` // Maximum due checks waiting for a worker (default: 100)
+	MaxJitter string `json:"max_jitter"` // Upper bound of the random delay added to each check (default: 10% of interval)
+}
+
+// GetMaxJitter parses and returns the maximum jitter, zero if unset
+func (sc SchedulerConfig) GetMaxJitter() (time.Duration, error) {
+	if sc.MaxJitter == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(sc.MaxJitter)
 }
 
 // AlertConfig represents the alert configuration
@@ -25,6 +186,10 @@ type AlertConfig struct {
 	Email      EmailConfig     `json:"email"`
 	Webhook    WebhookConfig   `json:"webhook"`
 	Thresholds ThresholdConfig `json:"thresholds"`
+
+	// Templates selects the template rendering each alert type on a channel,
+	// keyed by <alert type>_<channel> (e.g. "site_down_email": "default-site-down-email")
+	Templates map[string]string `json:"templates,omitempty"`
 }
 
 // EmailConfig represents email alert configuration
@@ -64,25 +229,18 @@ type ThresholdConfig struct {
 
 	// Cooldown to prevent spam
 	AlertCooldown string `json:"alert_cooldown"` // e.g., "5m"
-}
 
-// Load reads and parses configuration from JSON file
-func Load(filename string) (*Config, error) {
-	// Open the configuration file
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+	// Days before certificate expiry at which an ssl_expiry alert is sent,
+	// once per threshold (sites with ssl_check only)
+	SSLExpiryWarningDays []int `json:"ssl_expiry_warning_days,omitempty"`
+}
 
-	// Decode JSON into Config struct
-	var config Config
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&config); err != nil {
-		return nil, err
+// GetDrainTimeout returns the shutdown drain timeout, defaulting to DefaultDrainTimeout
+func (c *Config) GetDrainTimeout() (time.Duration, error) {
+	if c.DrainTimeout == "" {
+		return DefaultDrainTimeout, nil
 	}
-
-	return &config, nil
+	return time.ParseDuration(c.DrainTimeout)
 }
 
 // GetInterval converts string interval to time.Duration
@@ -90,11 +248,82 @@ func (s *Site) GetInterval() (time.Duration, error) {
 	return time.ParseDuration(s.Interval)
 }
 
-// GetTimeout converts string timeout to time.Duration
+// GetTimeout converts string timeout to time.Duration, defaulting to DefaultTimeout
 func (s *Site) GetTimeout() (time.Duration, error) {
+	if s.Timeout == "" {
+		return DefaultTimeout, nil
+	}
 	return time.ParseDuration(s.Timeout)
 }
 
+// GetRetryDelay returns the wait between check attempts, defaulting to DefaultRetryDelay
+func (s *Site) GetRetryDelay() (time.Duration, error) {
+	if s.RetryDelay == "" {
+		return DefaultRetryDelay, nil
+	}
+	return time.ParseDuration(s.RetryDelay)
+}
+
+// GetHeartbeatToken returns the ping URL token, defaulting to a slug of the name
+func (s *Site) GetHeartbeatToken() string {
+	if s.Token != "" {
+		return s.Token
+	}
+
+	var slug strings.Builder
+	for _, r := range strings.ToLower(s.Name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			slug.WriteRune(r)
+		case slug.Len() > 0 && !strings.HasSuffix(slug.String(), "-"):
+			slug.WriteRune('-')
+		}
+	}
+	return strings.TrimSuffix(slug.String(), "-")
+}
+
+// GetPeriod returns the expected heartbeat period, defaulting to the interval
+func (s *Site) GetPeriod() (time.Duration, error) {
+	if s.Period == "" {
+		return s.GetInterval()
+	}
+	return time.ParseDuration(s.Period)
+}
+
+// GetGrace returns the heartbeat grace window, zero if unset
+func (s *Site) GetGrace() (time.Duration, error) {
+	if s.Grace == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s.Grace)
+}
+
+// ShouldFollowRedirects reports whether redirects should be followed (default: true)
+func (s *Site) ShouldFollowRedirects() bool {
+	return s.FollowRedirects == nil || *s.FollowRedirects
+}
+
+// GetMaxRedirects returns the redirect hop limit, defaulting to DefaultMaxRedirects
+func (s *Site) GetMaxRedirects() int {
+	if s.MaxRedirects <= 0 {
+		return DefaultMaxRedirects
+	}
+	return s.MaxRedirects
+}
+
+// GetIPVersions returns the address families a site is probed over: nil when
+// ip_version is unset, both families for "both"
+func (s *Site) GetIPVersions() []string {
+	switch s.IPVersion {
+	case "":
+		return nil
+	case IPBoth:
+		return []string{IPv4, IPv6}
+	default:
+		return []string{s.IPVersion}
+	}
+}
+
 // Helper methods for ThresholdConfig
 
 // GetResponseTimeThreshold parses and returns the response time threshold
@@ -0,0 +1,46 @@
+package config
+
+import "reflect"
+
+// SiteChanges lists the sites that differ between two configurations
+type SiteChanges struct {
+	Added   []Site // Sites only in the new configuration
+	Removed []Site // Sites only in the old configuration
+	Changed []Site // Sites in both whose settings differ (new version)
+}
+
+// Empty reports whether no site was added, removed or changed
+func (c SiteChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// DiffSites compares two site lists by name
+func DiffSites(old, new []Site) SiteChanges {
+	var changes SiteChanges
+
+	previous := make(map[string]Site, len(old))
+	for _, site := range old {
+		previous[site.Name] = site
+	}
+
+	current := make(map[string]bool, len(new))
+	for _, site := range new {
+		current[site.Name] = true
+
+		before, exists := previous[site.Name]
+		switch {
+		case !exists:
+			changes.Added = append(changes.Added, site)
+		case !reflect.DeepEqual(before, site):
+			changes.Changed = append(changes.Changed, site)
+		}
+	}
+
+	for _, site := range old {
+		if !current[site.Name] {
+			changes.Removed = append(changes.Removed, site)
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads and parses a configuration file. The format is chosen by
+// extension: .yaml/.yml for YAML, .toml for TOML, anything else is JSON.
+//
+// Whatever the format, string values may reference environment variables as
+// ${NAME} or ${NAME:-default}, with $${ escaping a literal ${; any other $ is
+// kept as is. Any string option can instead be read from a file by suffixing
+// its key with _file (e.g. "password_file": "/run/secrets/smtp"); relative
+// paths are resolved against the configuration file's directory. Keys that do
+// not match a configuration field are rejected, so typos are not silently
+// ignored.
+func Load(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := parse(data, filepath.Ext(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &resolver{dir: filepath.Dir(filename)}
+	document = r.resolve(document, reflect.TypeOf(Config{}), "")
+	if err := errors.Join(r.problems...); err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(encoded, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// parse decodes a configuration document into the generic values produced by
+// encoding/json, so every format is resolved and decoded the same way
+func parse(data []byte, ext string) (interface{}, error) {
+	var document interface{}
+
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		var table map[string]interface{}
+		if err := toml.Unmarshal(data, &table); err != nil {
+			return nil, err
+		}
+		document = table
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return nil, describeSyntaxError(data, err)
+		}
+		return document, nil
+	}
+
+	// Normalize YAML and TOML values (e.g. []map[string]interface{}) through JSON
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// describeSyntaxError adds the line and column to JSON syntax errors
+func describeSyntaxError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	before := data[:syntaxErr.Offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n') - 1 // Offset is just past the bad byte
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+// envPattern matches ${NAME}, ${NAME:-default} and the $${ escape
+var envPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// resolver walks a decoded document alongside the Go type it will be decoded
+// into, expanding environment variables, reading _file secrets and
+// recording unknown fields
+type resolver struct {
+	dir      string
+	problems problems
+}
+
+func (r *resolver) resolve(value interface{}, t reflect.Type, path string) interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case string:
+		return r.resolveString(v, t, path)
+
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return value // Type mismatches are reported by the decoder
+		}
+		for i, item := range v {
+			v[i] = r.resolve(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			r.resolveObject(v, t, path)
+		case reflect.Map:
+			for _, key := range sortedKeys(v) {
+				v[key] = r.resolve(v[key], t.Elem(), joinPath(path, key))
+			}
+		}
+	}
+
+	return value
+}
+
+// resolveObject resolves the fields of a JSON object decoded into struct t
+func (r *resolver) resolveObject(object map[string]interface{}, t reflect.Type, path string) {
+	fields := jsonFields(t)
+
+	for _, key := range sortedKeys(object) {
+		if field, known := fields[strings.ToLower(key)]; known {
+			object[key] = r.resolve(object[key], field.Type, joinPath(path, key))
+			continue
+		}
+
+		// name_file reads the value of a string option name from a file
+		base, isFile := strings.CutSuffix(key, "_file")
+		field, known := fields[strings.ToLower(base)]
+		if !isFile || !known || field.Type.Kind() != reflect.String {
+			r.problems.add(joinPath(path, key), "unknown field")
+			continue
+		}
+		if _, set := object[base]; set {
+			r.problems.add(joinPath(path, key), "cannot be combined with %s", base)
+			continue
+		}
+
+		filename, ok := object[key].(string)
+		if !ok {
+			r.problems.add(joinPath(path, key), "must be a file path")
+			continue
+		}
+		delete(object, key)
+
+		secret, err := r.readFile(r.expand(filename, joinPath(path, key)))
+		if err != nil {
+			r.problems.add(joinPath(path, key), "%v", err)
+			continue
+		}
+		object[base] = secret
+	}
+}
+
+// resolveString expands environment variables in a string value. Values
+// built from variables are converted when the field is a number or a boolean,
+// so "${SMTP_PORT}" can configure an int.
+func (r *resolver) resolveString(value string, t reflect.Type, path string) interface{} {
+	expanded := r.expand(value, path)
+	if expanded == value {
+		return value
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(expanded, 64); err != nil {
+			r.problems.add(path, "expected a number, got %q", expanded)
+			return value
+		}
+		return json.Number(expanded)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(expanded)
+		if err != nil {
+			r.problems.add(path, "expected a boolean, got %q", expanded)
+			return value
+		}
+		return b
+	}
+	return expanded
+}
+
+// expand replaces environment variable references in value
+func (r *resolver) expand(value, path string) string {
+	return envPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		groups := envPattern.FindStringSubmatch(match)
+		name, hasDefault, fallback := groups[1], groups[2] != "", groups[3]
+
+		env, set := os.LookupEnv(name)
+		switch {
+		case hasDefault && env == "":
+			return fallback
+		case !set:
+			r.problems.add(path, "environment variable %s is not set", name)
+		}
+		return env
+	})
+}
+
+// readFile reads a secret, dropping the trailing newline most tools write
+func (r *resolver) readFile(filename string) (string, error) {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(r.dir, filename)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// jsonFields maps the lowercased JSON names of a struct's fields to the
+// fields, since encoding/json matches keys case-insensitively
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field
+	}
+	return fields
+}
+
+// sortedKeys returns the keys of a JSON object or map option in a stable order
+func sortedKeys[V any](object map[string]V) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a configuration file into a temporary directory
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Formats(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{"sites": [{"name": "a", "url": "https://example.com", "interval": "1m", "expected_status_codes": [200, 204]}],
+			"alerts": {"email": {"smtp_port": 587}}}`,
+		"config.yaml": `
+sites:
+  - name: a
+    url: https://example.com
+    interval: 1m
+    expected_status_codes: [200, 204]
+alerts:
+  email:
+    smtp_port: 587
+`,
+		"config.toml": `
+[[sites]]
+name = "a"
+url = "https://example.com"
+interval = "1m"
+expected_status_codes = [200, 204]
+
+[alerts.email]
+smtp_port = 587
+`,
+	}
+
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, name, data))
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if len(cfg.Sites) != 1 || cfg.Sites[0].Interval != "1m" || len(cfg.Sites[0].ExpectedStatusCodes) != 2 {
+				t.Errorf("Unexpected sites: %+v", cfg.Sites)
+			}
+			if cfg.Alerts == nil || cfg.Alerts.Email.SMTPPort != 587 {
+				t.Errorf("Unexpected alerts: %+v", cfg.Alerts)
+			}
+		})
+	}
+}
+
+func TestLoad_Interpolation(t *testing.T) {
+	t.Setenv("MONITOR_TOKEN", "s3cr3t")
+	t.Setenv("MONITOR_PORT", "2525")
+	t.Setenv("MONITOR_EMPTY", "")
+
+	path := writeConfig(t, "config.yaml", `
+sites:
+  - name: a
+    url: ${MONITOR_URL:-https://example.com}/health
+    interval: 1m
+    headers:
+      Authorization: Bearer ${MONITOR_TOKEN}
+      X-Price: $5 $${MONITOR_EMPTY} ${MONITOR_EMPTY:-fallback}
+alerts:
+  email:
+    enabled: ${MONITOR_EMAIL:-false}
+    smtp_port: ${MONITOR_PORT}
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	site := cfg.Sites[0]
+	if site.URL != "https://example.com/health" {
+		t.Errorf("Expected the default URL, got %q", site.URL)
+	}
+	if site.Headers["Authorization"] != "Bearer s3cr3t" {
+		t.Errorf("Expected the token to be expanded, got %q", site.Headers["Authorization"])
+	}
+	if site.Headers["X-Price"] != "$5 ${MONITOR_EMPTY} fallback" {
+		t.Errorf("Expected $${ to escape and empty values to use the default, got %q", site.Headers["X-Price"])
+	}
+	if cfg.Alerts.Email.SMTPPort != 2525 || cfg.Alerts.Email.Enabled {
+		t.Errorf("Expected variables to be converted to numbers and booleans, got %+v", cfg.Alerts.Email)
+	}
+}
+
+func TestLoad_LiteralDollars(t *testing.T) {
+	// Values without a ${ reference are left exactly as written
+	path := writeConfig(t, "config.json", `{
+		"sites": [{"name": "a", "url": "https://example.com/$$HOME", "interval": "1m",
+			"auth": {"type": "basic", "username": "u", "password": "pa$$word$"},
+			"headers": {"X-Literal": "$${MONITOR_MISSING}", "X-Cost": "$$$5"}}]
+	}`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	site := cfg.Sites[0]
+	if site.Auth.Password != "pa$$word$" || site.URL != "https://example.com/$$HOME" || site.Headers["X-Cost"] != "$$$5" {
+		t.Errorf("Expected literal $ to be kept, got password %q, URL %q and %q",
+			site.Auth.Password, site.URL, site.Headers["X-Cost"])
+	}
+	if site.Headers["X-Literal"] != "${MONITOR_MISSING}" {
+		t.Errorf("Expected $${ to escape a reference, got %q", site.Headers["X-Literal"])
+	}
+}
+
+func TestLoad_InterpolationErrors(t *testing.T) {
+	t.Setenv("MONITOR_PORT", "smtp")
+
+	path := writeConfig(t, "config.json", `{
+		"sites": [{"name": "a", "url": "https://${MONITOR_MISSING}", "interval": "1m"}],
+		"alerts": {"email": {"smtp_port": "${MONITOR_PORT}"}}
+	}`)
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("Expected interpolation errors")
+	}
+	paths := problemPaths(t, err)
+	expected := []string{"alerts.email.smtp_port", "sites[0].url"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v (%v)", expected, paths, err)
+	}
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "smtp_password"), []byte("hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token := filepath.Join(dir, "token")
+	if err := os.WriteFile(token, []byte("abc123"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MONITOR_SECRETS", dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	data := `
+sites:
+  - name: a
+    url: https://example.com
+    interval: 1m
+    auth:
+      type: bearer
+      token_file: ${MONITOR_SECRETS}/token
+    tls:
+      ca_file: ca.pem
+alerts:
+  email:
+    password_file: smtp_password
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Sites[0].Auth.Token != "abc123" {
+		t.Errorf("Expected the token to be read from its file, got %q", cfg.Sites[0].Auth.Token)
+	}
+	if cfg.Alerts.Email.Password != "hunter2" {
+		t.Errorf("Expected a relative secret path and the trailing newline to be trimmed, got %q", cfg.Alerts.Email.Password)
+	}
+	if cfg.Sites[0].TLS.CAFile != "ca.pem" {
+		t.Errorf("Expected real _file options to be left alone, got %q", cfg.Sites[0].TLS.CAFile)
+	}
+}
+
+func TestLoad_SecretFileErrors(t *testing.T) {
+	path := writeConfig(t, "config.json", `{
+		"sites": [{"name": "a", "url": "https://example.com", "interval": "1m", "interval_file": "interval"}],
+		"alerts": {"email": {"password": "x", "password_file": "secret", "username_file": "missing", "smtp_port_file": "port"}}
+	}`)
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("Expected secret file errors")
+	}
+	paths := problemPaths(t, err)
+	expected := []string{
+		"alerts.email.password_file",  // Combined with password
+		"alerts.email.smtp_port_file", // Not a string option
+		"alerts.email.username_file",  // File does not exist
+		"sites[0].interval_file",      // File does not exist
+	}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v (%v)", expected, paths, err)
+	}
+}
+
+func TestLoad_RejectsUnknownFields(t *testing.T) {
+	path := writeConfig(t, "config.json", `{
+		"sites": [{"name": "a", "URL": "https://example.com", "interval": "1m", "intervall": "2m",
+			"headers": {"X-Custom": "1"}, "auth": {"type": "basic", "user": "me"}}],
+		"alert": {}
+	}`)
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("Expected unknown fields to be rejected")
+	}
+
+	paths := problemPaths(t, err)
+	expected := []string{"alert", "sites[0].auth.user", "sites[0].intervall"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+}
+
+func TestLoad_SyntaxErrorPosition(t *testing.T) {
+	path := writeConfig(t, "config.json", "{\n  \"sites\": [\n    {\"name\": \"a\",}\n  ]\n}")
+
+	_, err := Load(path)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3, column 18:") {
+		t.Errorf("Expected the error position, got %v", err)
+	}
+}
@@ -0,0 +1,380 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for the optional sections
+const (
+	DefaultDatabasePath      = "site-monitor.db"
+	DefaultDashboardPort     = 8080
+	DefaultRefreshInterval   = 30 * time.Second
+	DefaultSSLCheckInterval  = 24 * time.Hour
+	DefaultSSLTimeout        = 10 * time.Second
+	DefaultSSLWarnDays       = 30
+	DefaultReportHour        = 9
+	DefaultRequestsPerMinute = 60
+)
+
+// ReportsConfig represents scheduled reports
+type ReportsConfig struct {
+	Email ReportEmailConfig `json:"email"`
+}
+
+// ReportEmailConfig represents email reports, sent through the alerts.email SMTP settings
+type ReportEmailConfig struct {
+	Enabled   bool                   `json:"enabled"`
+	Schedules []ReportScheduleConfig `json:"schedules"`
+}
+
+// ReportScheduleConfig represents one periodic report
+type ReportScheduleConfig struct {
+	Name             string   `json:"name"`
+	Schedule         string   `json:"schedule"`                     // daily, weekly, monthly
+	DayOfWeek        int      `json:"day_of_week,omitempty"`        // Weekly: 0 = Sunday ... 6 = Saturday
+	DayOfMonth       int      `json:"day_of_month,omitempty"`       // Monthly: 1-31, clamped to the month length (default: 1)
+	Hour             *int     `json:"hour,omitempty"`               // Hour of day the report is sent (default: 9)
+	Sites            []string `json:"sites,omitempty"`              // Sites to include (default: all)
+	Recipients       []string `json:"recipients,omitempty"`         // Default: alerts.email.recipients
+	Format           string   `json:"format,omitempty"`             // html, pdf, csv (default: html)
+	Sections         []string `json:"sections,omitempty"`           // Report sections to include
+	Template         string   `json:"template,omitempty"`           // Report template name
+	IncludeCSVExport bool     `json:"include_csv_export,omitempty"` // Attach the metrics as CSV
+}
+
+// GetHour returns the hour of day the report is sent, defaulting to DefaultReportHour
+func (rc ReportScheduleConfig) GetHour() int {
+	if rc.Hour == nil {
+		return DefaultReportHour
+	}
+	return *rc.Hour
+}
+
+// MetricsConfig represents the advanced metrics settings
+type MetricsConfig struct {
+	Advanced    AdvancedMetricsConfig    `json:"advanced"`
+	Performance PerformanceMetricsConfig `json:"performance"`
+}
+
+// AdvancedMetricsConfig represents percentile, trend and SLA calculations
+type AdvancedMetricsConfig struct {
+	Enabled         bool              `json:"enabled"`
+	Percentiles     []float64         `json:"percentiles,omitempty"`    // e.g. [50, 95, 99.9]
+	CalculateTrends bool              `json:"calculate_trends"`         // Compute response time and uptime trends
+	RetentionDays   int               `json:"retention_days,omitempty"` // Oldest history analysed (0 = no limit)
+	SLATargets      map[string]string `json:"sla_targets,omitempty"`    // Uptime percent -> SLA name, e.g. "99.9": "Enterprise SLA"
+}
+
+// GetSLATargets parses the SLA targets into SLA name -> uptime percent
+func (ac AdvancedMetricsConfig) GetSLATargets() (map[string]float64, error) {
+	targets := make(map[string]float64, len(ac.SLATargets))
+	for percent, name := range ac.SLATargets {
+		target, err := strconv.ParseFloat(percent, 64)
+		if err != nil || target <= 0 || target > 100 {
+			return nil, fmt.Errorf("invalid SLA target %q: must be a percentage", percent)
+		}
+		if name == "" {
+			name = percent + "%"
+		}
+		targets[name] = target
+	}
+	return targets, nil
+}
+
+// PerformanceMetricsConfig represents response time and error analysis
+type PerformanceMetricsConfig struct {
+	ResponseTimeBuckets []int               `json:"response_time_buckets,omitempty"` // Histogram upper bounds in milliseconds
+	ErrorClassification map[string][]string `json:"error_classification,omitempty"`  // Category -> substrings matched against errors
+}
+
+// SSLConfig represents certificate checking
+type SSLConfig struct {
+	Enabled           bool   `json:"enabled"`
+	CheckInterval     string `json:"check_interval,omitempty"`     // How often certificate reports are refreshed (default: 24h)
+	WarningThresholds []int  `json:"warning_thresholds,omitempty"` // Days before expiry that are reported (default: [30])
+	VerifyChain       bool   `json:"verify_chain"`                 // Verify the chain against the system roots
+	CheckRevocation   bool   `json:"check_revocation"`             // Look the certificate up in its issuer's CRL
+	Timeout           string `json:"timeout,omitempty"`            // Connection timeout (default: 10s)
+}
+
+// GetCheckInterval returns how often certificates are checked, defaulting to DefaultSSLCheckInterval
+func (sc SSLConfig) GetCheckInterval() (time.Duration, error) {
+	if sc.CheckInterval == "" {
+		return DefaultSSLCheckInterval, nil
+	}
+	return ParseDuration(sc.CheckInterval)
+}
+
+// GetTimeout returns the connection timeout, defaulting to DefaultSSLTimeout
+func (sc SSLConfig) GetTimeout() (time.Duration, error) {
+	if sc.Timeout == "" {
+		return DefaultSSLTimeout, nil
+	}
+	return time.ParseDuration(sc.Timeout)
+}
+
+// GetWarningDays returns the largest warning threshold, defaulting to DefaultSSLWarnDays
+func (sc SSLConfig) GetWarningDays() int {
+	days := 0
+	for _, threshold := range sc.WarningThresholds {
+		days = max(days, threshold)
+	}
+	if days == 0 {
+		return DefaultSSLWarnDays
+	}
+	return days
+}
+
+// Storage drivers
+const (
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+)
+
+// StorageConfig represents the database settings
+type StorageConfig struct {
+	Driver    string          `json:"driver,omitempty"` // sqlite or postgres (default: sqlite)
+	SQLite    SQLiteConfig    `json:"sqlite"`
+	Postgres  PostgresConfig  `json:"postgres"`
+	Retention RetentionConfig `json:"retention"`
+}
+
+// GetDriver returns the storage driver, defaulting to StorageSQLite
+func (sc StorageConfig) GetDriver() string {
+	if sc.Driver == "" {
+		return StorageSQLite
+	}
+	return sc.Driver
+}
+
+// Location describes the configured database for messages, without the
+// PostgreSQL password
+func (sc StorageConfig) Location() string {
+	if sc.GetDriver() == StoragePostgres {
+		return sc.Postgres.Redacted()
+	}
+	return sc.SQLite.GetPath()
+}
+
+// SQLiteConfig represents the SQLite database
+type SQLiteConfig struct {
+	Path           string `json:"path,omitempty"`            // Database file (default: site-monitor.db)
+	WALMode        *bool  `json:"wal_mode,omitempty"`        // Write-ahead logging (default: true)
+	VacuumInterval string `json:"vacuum_interval,omitempty"` // How often the database is compacted, e.g. "7d" (default: never)
+}
+
+// GetPath returns the database file, defaulting to DefaultDatabasePath
+func (sc SQLiteConfig) GetPath() string {
+	if sc.Path == "" {
+		return DefaultDatabasePath
+	}
+	return sc.Path
+}
+
+// UseWAL reports whether write-ahead logging is enabled (default: true)
+func (sc SQLiteConfig) UseWAL() bool {
+	return sc.WALMode == nil || *sc.WALMode
+}
+
+// GetVacuumInterval returns how often the database is compacted, zero if never
+func (sc SQLiteConfig) GetVacuumInterval() (time.Duration, error) {
+	if sc.VacuumInterval == "" {
+		return 0, nil
+	}
+	return ParseDuration(sc.VacuumInterval)
+}
+
+// PostgresConfig represents a PostgreSQL database, shared by several
+// monitor instances and the dashboard
+type PostgresConfig struct {
+	DSN          string `json:"dsn,omitempty"`            // e.g. "postgres://monitor:secret@db:5432/site_monitor?sslmode=require"
+	MaxOpenConns int    `json:"max_open_conns,omitempty"` // Connection pool size (default: 10)
+}
+
+// GetMaxOpenConns returns the connection pool size, defaulting to 10
+func (pc PostgresConfig) GetMaxOpenConns() int {
+	if pc.MaxOpenConns <= 0 {
+		return 10
+	}
+	return pc.MaxOpenConns
+}
+
+// dsnPassword matches the password of a key=value DSN
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Redacted returns the DSN with its password masked
+func (pc PostgresConfig) Redacted() string {
+	if u, err := url.Parse(pc.DSN); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(pc.DSN, "${1}xxxxx")
+}
+
+// RetentionConfig represents how long data is kept, in days (0 = forever)
+type RetentionConfig struct {
+	RawDataDays        int `json:"raw_data_days,omitempty"`
+	AggregatedDataDays int `json:"aggregated_data_days,omitempty"`
+	AlertHistoryDays   int `json:"alert_history_days,omitempty"`
+}
+
+// DashboardConfig represents the web dashboard
+type DashboardConfig struct {
+	Enabled         bool               `json:"enabled"`                    // Serve the dashboard from the monitor process
+	Port            int                `json:"port,omitempty"`             // Default: 8080
+	RefreshInterval int                `json:"refresh_interval,omitempty"` // Seconds between browser refreshes (default: 30)
+	Features        *DashboardFeatures `json:"features,omitempty"`         // Default: everything enabled
+}
+
+// DashboardFeatures toggles optional dashboard features
+type DashboardFeatures struct {
+	RealTimeUpdates bool `json:"real_time_updates"` // Push updates over a WebSocket
+	SSLMonitoring   bool `json:"ssl_monitoring"`    // Certificate status at /api/ssl
+	AdvancedMetrics bool `json:"advanced_metrics"`  // Percentiles and SLAs at /api/metrics
+	AlertManagement bool `json:"alert_management"`  // Alert channel status at /api/alerts
+}
+
+// GetPort returns the dashboard port, defaulting to DefaultDashboardPort
+func (dc *DashboardConfig) GetPort() int {
+	if dc == nil || dc.Port == 0 {
+		return DefaultDashboardPort
+	}
+	return dc.Port
+}
+
+// GetRefreshInterval returns the browser refresh interval, defaulting to DefaultRefreshInterval
+func (dc *DashboardConfig) GetRefreshInterval() time.Duration {
+	if dc == nil || dc.RefreshInterval == 0 {
+		return DefaultRefreshInterval
+	}
+	return time.Duration(dc.RefreshInterval) * time.Second
+}
+
+// GetFeatures returns the enabled features, all of them when none are configured
+func (dc *DashboardConfig) GetFeatures() DashboardFeatures {
+	if dc == nil || dc.Features == nil {
+		return DashboardFeatures{true, true, true, true}
+	}
+	return *dc.Features
+}
+
+// LoggingConfig represents log output
+type LoggingConfig struct {
+	Level  string        `json:"level,omitempty"`  // debug, info, warn, error (default: info)
+	Format string        `json:"format,omitempty"` // text, json (default: text)
+	Output string        `json:"output,omitempty"` // stdout, stderr (default: stderr)
+	File   LogFileConfig `json:"file"`
+}
+
+// LogFileConfig represents a rotated log file, written in addition to Output
+type LogFileConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Path     string `json:"path,omitempty"`
+	MaxSize  string `json:"max_size,omitempty"` // Rotate past this size, e.g. "100MB" (default: never)
+	MaxAge   string `json:"max_age,omitempty"`  // Delete rotated files older than this, e.g. "7d" (default: never)
+	Compress bool   `json:"compress"`           // Gzip rotated files
+}
+
+// GetMaxSize returns the rotation size in bytes, zero if unset
+func (fc LogFileConfig) GetMaxSize() (int64, error) {
+	if fc.MaxSize == "" {
+		return 0, nil
+	}
+	return ParseSize(fc.MaxSize)
+}
+
+// GetMaxAge returns how long rotated files are kept, zero if forever
+func (fc LogFileConfig) GetMaxAge() (time.Duration, error) {
+	if fc.MaxAge == "" {
+		return 0, nil
+	}
+	return ParseDuration(fc.MaxAge)
+}
+
+// APIConfig represents the JSON API served under /api
+type APIConfig struct {
+	Enabled      bool            `json:"enabled"`
+	Port         int             `json:"port,omitempty"` // Separate listener for the API (default: the dashboard port)
+	CORS         CORSConfig      `json:"cors"`
+	RateLimiting RateLimitConfig `json:"rate_limiting"`
+}
+
+// CORSConfig represents cross-origin access to the API
+type CORSConfig struct {
+	Enabled bool     `json:"enabled"`
+	Origins []string `json:"origins,omitempty"` // Allowed origins, "*" for any
+}
+
+// RateLimitConfig represents per-client API rate limiting
+type RateLimitConfig struct {
+	Enabled           bool `json:"enabled"`
+	RequestsPerMinute int  `json:"requests_per_minute,omitempty"` // Default: 60
+}
+
+// GetRequestsPerMinute returns the per-client limit, defaulting to DefaultRequestsPerMinute
+func (rc RateLimitConfig) GetRequestsPerMinute() int {
+	if rc.RequestsPerMinute <= 0 {
+		return DefaultRequestsPerMinute
+	}
+	return rc.RequestsPerMinute
+}
+
+// GetDatabasePath returns the SQLite database file, defaulting to DefaultDatabasePath
+func (c *Config) GetDatabasePath() string {
+	if c == nil || c.Storage == nil {
+		return DefaultDatabasePath
+	}
+	return c.Storage.SQLite.GetPath()
+}
+
+// GetSSLWarnDays returns the days before expiry at which the certificate
+// check reports a warning, defaulting to DefaultSSLWarnDays
+func (s *Site) GetSSLWarnDays() int {
+	if s.SSLWarnDays <= 0 {
+		return DefaultSSLWarnDays
+	}
+	return s.SSLWarnDays
+}
+
+// ParseDuration parses a duration like time.ParseDuration, also accepting
+// whole days such as "7d"
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days: %s", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// sizeUnits are the suffixes understood by ParseSize, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a byte size such as "100MB", "512KB" or "1GB"
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid size %q", s)
+			}
+			return n * unit.bytes, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid size %q (use B, KB, MB or GB)", s)
+}
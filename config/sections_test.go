@@ -0,0 +1,173 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_EnhancedExample(t *testing.T) {
+	cfg, err := Load("enhanced.json")
+	if err != nil {
+		t.Fatalf("Failed to load enhanced.json: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected enhanced.json to be valid, got %v", err)
+	}
+
+	if !cfg.Sites[1].SSLCheck || cfg.Sites[1].GetSSLWarnDays() != 14 {
+		t.Errorf("Unexpected site certificate settings: %+v", cfg.Sites[1])
+	}
+	if got := cfg.Alerts.Thresholds.SSLExpiryWarningDays; len(got) != 4 || got[0] != 30 {
+		t.Errorf("Unexpected ssl_expiry_warning_days %v", got)
+	}
+
+	schedules := cfg.Reports.Email.Schedules
+	if len(schedules) != 3 || schedules[0].DayOfWeek != 1 || schedules[1].GetHour() != 8 || !schedules[2].IncludeCSVExport {
+		t.Errorf("Unexpected report schedules: %+v", schedules)
+	}
+
+	targets, err := cfg.Metrics.Advanced.GetSLATargets()
+	if err != nil || targets["Enterprise SLA"] != 99.9 || len(targets) != 3 {
+		t.Errorf("Unexpected SLA targets %v (%v)", targets, err)
+	}
+
+	if interval, _ := cfg.SSL.GetCheckInterval(); interval != 24*time.Hour {
+		t.Errorf("Expected a 24h SSL check interval, got %v", interval)
+	}
+	if vacuum, _ := cfg.Storage.SQLite.GetVacuumInterval(); vacuum != 7*24*time.Hour {
+		t.Errorf("Expected a 7d vacuum interval, got %v", vacuum)
+	}
+	if size, _ := cfg.Logging.File.GetMaxSize(); size != 100<<20 {
+		t.Errorf("Expected a 100MB log size, got %d", size)
+	}
+	if cfg.Dashboard.GetRefreshInterval() != 30*time.Second || !cfg.Dashboard.GetFeatures().SSLMonitoring {
+		t.Errorf("Unexpected dashboard settings: %+v", cfg.Dashboard)
+	}
+	if cfg.API.Port != 8081 || cfg.API.RateLimiting.GetRequestsPerMinute() != 100 {
+		t.Errorf("Unexpected API settings: %+v", cfg.API)
+	}
+}
+
+func TestConfig_SectionDefaults(t *testing.T) {
+	var cfg Config
+
+	if cfg.GetDatabasePath() != DefaultDatabasePath {
+		t.Errorf("Expected the default database path, got %s", cfg.GetDatabasePath())
+	}
+	if cfg.Dashboard.GetPort() != DefaultDashboardPort || cfg.Dashboard.GetFeatures() != (DashboardFeatures{true, true, true, true}) {
+		t.Errorf("Expected every dashboard feature on the default port")
+	}
+
+	sqlite := SQLiteConfig{}
+	if !sqlite.UseWAL() {
+		t.Errorf("Expected WAL mode by default")
+	}
+	if days := (SSLConfig{WarningThresholds: []int{7, 21, 14}}).GetWarningDays(); days != 21 {
+		t.Errorf("Expected the largest warning threshold, got %d", days)
+	}
+}
+
+func TestConfig_ValidateSections(t *testing.T) {
+	hour := 24
+	cfg := &Config{
+		Sites: []Site{
+			{Name: "plain", URL: "http://example.com", Interval: "1m", SSLCheck: true, SSLWarnDays: -1},
+		},
+		Alerts: validAlerts(),
+		Reports: &ReportsConfig{Email: ReportEmailConfig{Enabled: true, Schedules: []ReportScheduleConfig{
+			{Name: "r", Schedule: "hourly", DayOfWeek: 7, Hour: &hour, Format: "docx", Sections: []string{"overview", "gossip"}, Sites: []string{"missing"}},
+		}}},
+		Metrics: &MetricsConfig{
+			Advanced:    AdvancedMetricsConfig{Percentiles: []float64{50, 100}, SLATargets: map[string]string{"high": "Gold"}},
+			Performance: PerformanceMetricsConfig{ResponseTimeBuckets: []int{100, 50}},
+		},
+		SSL:       &SSLConfig{CheckInterval: "weekly", WarningThresholds: []int{0}},
+		Storage:   &StorageConfig{SQLite: SQLiteConfig{VacuumInterval: "0d"}, Retention: RetentionConfig{RawDataDays: -1}},
+		Dashboard: &DashboardConfig{Port: 70000},
+		Logging:   &LoggingConfig{Level: "verbose", File: LogFileConfig{Enabled: true, MaxSize: "lots"}},
+		API:       &APIConfig{Enabled: true, CORS: CORSConfig{Enabled: true}},
+	}
+	cfg.Alerts.Templates = map[string]string{"site_down_pager": "x"}
+	cfg.Alerts.Thresholds.SSLExpiryWarningDays = []int{-3}
+
+	expected := []string{
+		"sites[0].ssl_check",
+		"sites[0].ssl_warn_days",
+		"alerts.thresholds.ssl_expiry_warning_days[0]",
+		"alerts.templates.site_down_pager",
+		"reports.email.enabled",
+		"reports.email.schedules[0].schedule",
+		"reports.email.schedules[0].format",
+		"reports.email.schedules[0].day_of_week",
+		"reports.email.schedules[0].hour",
+		"reports.email.schedules[0].sections[1]",
+		"reports.email.schedules[0].sites[0]",
+		"reports.email.schedules[0].recipients",
+		"metrics.advanced.percentiles[1]",
+		"metrics.advanced.sla_targets.high",
+		"metrics.performance.response_time_buckets[1]",
+		"ssl.check_interval",
+		"ssl.warning_thresholds[0]",
+		"storage.sqlite.vacuum_interval",
+		"storage.retention.raw_data_days",
+		"dashboard.port",
+		"logging.level",
+		"logging.file.path",
+		"logging.file.max_size",
+		"api.cors.origins",
+	}
+	paths := problemPaths(t, cfg.Validate())
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected problems:\n%s\nexpected:\n%s", strings.Join(paths, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestStorageConfig_Postgres(t *testing.T) {
+	sc := StorageConfig{Driver: StoragePostgres}
+	if paths := problemPaths(t, (&Config{Storage: &sc}).Validate()); len(paths) != 1 || paths[0] != "storage.postgres.dsn" {
+		t.Errorf("Expected the missing DSN to be reported, got %v", paths)
+	}
+	if paths := problemPaths(t, (&Config{Storage: &StorageConfig{Driver: "mysql"}}).Validate()); len(paths) != 1 || paths[0] != "storage.driver" {
+		t.Errorf("Expected the unknown driver to be reported, got %v", paths)
+	}
+
+	redacted := map[string]string{
+		"postgres://monitor:s3cret@db:5432/site_monitor": "postgres://monitor:xxxxx@db:5432/site_monitor",
+		"host=db user=monitor password=s3cret":           "host=db user=monitor password=xxxxx",
+		"host=db password='with space' dbname=monitor":   "host=db password=xxxxx dbname=monitor",
+	}
+	for dsn, expected := range redacted {
+		sc.Postgres.DSN = dsn
+		if got := sc.Location(); got != expected {
+			t.Errorf("Location() = %q, expected %q", got, expected)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{"100MB": 100 << 20, "512kb": 512 << 10, "1GB": 1 << 30, "10 B": 10}
+	for input, expected := range tests {
+		if got, err := ParseSize(input); err != nil || got != expected {
+			t.Errorf("ParseSize(%q) = %d, %v; expected %d", input, got, err, expected)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "10TB", "-1MB"} {
+		if _, err := ParseSize(input); err == nil {
+			t.Errorf("Expected ParseSize(%q) to fail", input)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	if d, err := ParseDuration("7d"); err != nil || d != 7*24*time.Hour {
+		t.Errorf("Expected 7 days, got %v (%v)", d, err)
+	}
+	if d, err := ParseDuration("90m"); err != nil || d != 90*time.Minute {
+		t.Errorf("Expected 90 minutes, got %v (%v)", d, err)
+	}
+	if _, err := ParseDuration("xd"); err == nil {
+		t.Errorf("Expected an invalid day count to fail")
+	}
+}
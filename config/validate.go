@@ -0,0 +1,518 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError is a problem with a single configuration value, located by its
+// JSON path (e.g. "sites[2].interval")
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// problems collects field errors while validating
+type problems []error
+
+func (p *problems) add(path, format string, args ...interface{}) {
+	*p = append(*p, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// duration records a problem if value is not a valid duration. Empty values
+// are accepted unless required; non-positive values are rejected if positive.
+func (p *problems) duration(path, value string, required, positive bool) {
+	if value == "" {
+		if required {
+			p.add(path, "is required")
+		}
+		return
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		p.add(path, "invalid duration %q (use a unit, e.g. \"30s\" or \"5m\")", value)
+		return
+	}
+	if positive && d <= 0 {
+		p.add(path, "must be positive, got %q", value)
+	}
+}
+
+// longDuration is like duration but also accepts whole days (e.g. "7d");
+// values must be positive
+func (p *problems) longDuration(path, value string) {
+	if value == "" {
+		return
+	}
+
+	d, err := ParseDuration(value)
+	if err != nil {
+		p.add(path, "invalid duration %q (use a unit, e.g. \"12h\" or \"7d\")", value)
+		return
+	}
+	if d <= 0 {
+		p.add(path, "must be positive, got %q", value)
+	}
+}
+
+// port records a problem if a configured port is out of range (0 means unset)
+func (p *problems) port(path string, port int) {
+	if port < 0 || port > 65535 {
+		p.add(path, "must be between 1 and 65535, got %d", port)
+	}
+}
+
+// oneOf records a problem if a configured value is not in values (empty means unset)
+func (p *problems) oneOf(path, value string, values []string) {
+	if value != "" && !contains(values, value) {
+		p.add(path, "must be one of %s, got %q", strings.Join(values, ", "), value)
+	}
+}
+
+// webhookFormats are the payload formats understood by the webhook channel
+var webhookFormats = []string{"slack", "discord", "teams", "generic"}
+
+// templateChannels are the channels alert templates can be selected for
+var templateChannels = []string{"email", "slack", "discord", "teams", "webhook"}
+
+// Values understood by the optional sections
+var (
+	reportSchedules = []string{"daily", "weekly", "monthly"}
+	reportFormats   = []string{"html", "pdf", "csv"}
+	reportSections  = []string{"overview", "detailed_metrics", "ssl_certificates", "alerts_summary",
+		"performance_trends", "sla_compliance", "error_analysis", "recommendations"}
+	storageDrivers = []string{StorageSQLite, StoragePostgres}
+	logLevels      = []string{"debug", "info", "warn", "error"}
+	logFormats     = []string{"text", "json"}
+	logOutputs     = []string{"stdout", "stderr"}
+	tlsVersions    = []string{"1.0", "1.1", "1.2", "1.3"}
+	proxySchemes   = []string{"http", "https", "socks5", "socks5h"}
+	ipVersions     = []string{IPv4, IPv6, IPBoth}
+)
+
+// Validate checks the whole configuration and returns every problem found,
+// each one a *FieldError. Check-type specific options are validated when the
+// site's checker is built.
+func (c *Config) Validate() error {
+	var p problems
+
+	names := make(map[string]int, len(c.Sites))
+	tokens := make(map[string]int)
+	for i, site := range c.Sites {
+		path := fmt.Sprintf("sites[%d]", i)
+		site.validate(path, &p)
+		if site.Type == "heartbeat" {
+			site.validateToken(path, i, tokens, &p)
+			// Pings are received by the dashboard server
+			if c.Dashboard == nil || !c.Dashboard.Enabled {
+				p.add(path+".type", "heartbeat checks need dashboard.enabled to serve /ping/%s", site.GetHeartbeatToken())
+			}
+		}
+
+		if site.Name == "" {
+			continue
+		}
+		if first, exists := names[site.Name]; exists {
+			p.add(path+".name", "duplicate site name %q (also used by sites[%d])", site.Name, first)
+			continue
+		}
+		names[site.Name] = i
+	}
+
+	if c.Alerts != nil {
+		c.Alerts.validate(&p)
+	}
+
+	if c.Scheduler != nil {
+		if c.Scheduler.Workers < 0 {
+			p.add("scheduler.workers", "must not be negative, got %d", c.Scheduler.Workers)
+		}
+		if c.Scheduler.QueueSize < 0 {
+			p.add("scheduler.queue_size", "must not be negative, got %d", c.Scheduler.QueueSize)
+		}
+		p.duration("scheduler.max_jitter", c.Scheduler.MaxJitter, false, false)
+	}
+
+	p.duration("drain_timeout", c.DrainTimeout, false, true)
+
+	if c.Reports != nil {
+		c.validateReports(names, &p)
+	}
+	if c.Metrics != nil {
+		c.Metrics.validate(&p)
+	}
+	if c.SSL != nil {
+		c.SSL.validate(&p)
+	}
+	if c.Storage != nil {
+		c.Storage.validate(&p)
+	}
+	if c.Dashboard != nil {
+		p.port("dashboard.port", c.Dashboard.Port)
+		if c.Dashboard.RefreshInterval < 0 {
+			p.add("dashboard.refresh_interval", "must not be negative, got %d", c.Dashboard.RefreshInterval)
+		}
+	}
+	if c.Logging != nil {
+		c.Logging.validate(&p)
+	}
+	if c.API != nil {
+		c.API.validate(&p)
+	}
+
+	return errors.Join(p...)
+}
+
+// validate checks the options shared by every check type
+func (s *Site) validate(path string, p *problems) {
+	if s.Name == "" {
+		p.add(path+".name", "is required")
+	}
+
+	p.duration(path+".interval", s.Interval, true, true)
+	p.duration(path+".timeout", s.Timeout, false, true)
+	p.duration(path+".retry_delay", s.RetryDelay, false, false)
+	p.duration(path+".period", s.Period, false, true)
+	p.duration(path+".grace", s.Grace, false, false)
+
+	if s.Retries < 0 {
+		p.add(path+".retries", "must not be negative, got %d", s.Retries)
+	}
+	if s.MaxRedirects < 0 {
+		p.add(path+".max_redirects", "must not be negative, got %d", s.MaxRedirects)
+	}
+	for i, code := range s.ExpectedStatusCodes {
+		if code < 100 || code > 599 {
+			p.add(fmt.Sprintf("%s.expected_status_codes[%d]", path, i), "must be an HTTP status code, got %d", code)
+		}
+	}
+
+	if s.Type == "" || s.Type == "http" {
+		if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			p.add(path+".url", "must be an absolute http or https URL, got %q", s.URL)
+		} else if s.SSLCheck && u.Scheme != "https" {
+			p.add(path+".ssl_check", "requires an https URL, got %q", s.URL)
+		}
+	} else if s.SSLCheck {
+		p.add(path+".ssl_check", "is only supported by http checks")
+	}
+	if s.SSLWarnDays < 0 {
+		p.add(path+".ssl_warn_days", "must not be negative, got %d", s.SSLWarnDays)
+	}
+
+	if s.Auth != nil {
+		switch s.Auth.Type {
+		case "basic", "bearer":
+		default:
+			p.add(path+".auth.type", "must be basic or bearer, got %q", s.Auth.Type)
+		}
+	}
+
+	if s.TLS != nil {
+		p.oneOf(path+".tls.min_version", s.TLS.MinVersion, tlsVersions)
+		if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+			p.add(path+".tls", "cert_file and key_file must be set together")
+		}
+	}
+
+	if s.Proxy != nil {
+		switch s.Type {
+		case "dns", "exec", "heartbeat":
+			p.add(path+".proxy", "is not supported by %s checks", s.Type)
+		}
+		if u, err := url.Parse(s.Proxy.URL); err != nil || !contains(proxySchemes, u.Scheme) || u.Host == "" {
+			p.add(path+".proxy.url", "must be an http, https, socks5 or socks5h URL, got %q", s.Proxy.URL)
+		}
+		if s.Proxy.Password != "" && s.Proxy.Username == "" {
+			p.add(path+".proxy.username", "is required with a password")
+		}
+	}
+
+	s.validateAddressing(path, p)
+}
+
+// validateToken checks that a heartbeat site has a ping token of its own,
+// recording it in tokens
+func (s *Site) validateToken(path string, index int, tokens map[string]int, p *problems) {
+	// Tokens derived from the name are reported on the name
+	field := path + ".token"
+	if s.Token == "" {
+		field = path + ".name"
+	}
+
+	token := s.GetHeartbeatToken()
+	if token == "" {
+		if s.Name != "" {
+			p.add(path+".token", "is required when the name has no letters or digits")
+		}
+		return
+	}
+	if first, exists := tokens[token]; exists {
+		p.add(field, "duplicate heartbeat token %q (also used by sites[%d])", token, first)
+		return
+	}
+	tokens[token] = index
+}
+
+// validateAddressing checks ip_version and resolve_to, which need an address
+// for every probed family
+func (s *Site) validateAddressing(path string, p *problems) {
+	if s.IPVersion == "" && len(s.ResolveTo) == 0 {
+		return
+	}
+	switch s.Type {
+	case "dns", "exec", "heartbeat":
+		if s.IPVersion != "" {
+			p.add(path+".ip_version", "is not supported by %s checks", s.Type)
+		}
+		if len(s.ResolveTo) > 0 {
+			p.add(path+".resolve_to", "is not supported by %s checks", s.Type)
+		}
+		return
+	}
+
+	p.oneOf(path+".ip_version", s.IPVersion, ipVersions)
+
+	families := make(map[string]bool)
+	for i, address := range s.ResolveTo {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			p.add(fmt.Sprintf("%s.resolve_to[%d]", path, i), "must be an IP address, got %q", address)
+			continue
+		}
+		if ip.To4() != nil {
+			families[IPv4] = true
+		} else {
+			families[IPv6] = true
+		}
+	}
+	if len(s.ResolveTo) == 0 || !contains(ipVersions, s.IPVersion) {
+		return
+	}
+	for _, family := range s.GetIPVersions() {
+		if !families[family] {
+			p.add(path+".resolve_to", "has no IP%s address for ip_version %q", family, s.IPVersion)
+		}
+	}
+}
+
+// Validate checks the alert configuration and returns every problem found
+func (ac *AlertConfig) Validate() error {
+	var p problems
+	ac.validate(&p)
+	return errors.Join(p...)
+}
+
+func (ac *AlertConfig) validate(p *problems) {
+	if ac.Email.Enabled {
+		// The email channel dials smtp_server as is
+		if _, _, err := net.SplitHostPort(ac.Email.SMTPServer); err != nil {
+			p.add("alerts.email.smtp_server", "must be in host:port format, got %q", ac.Email.SMTPServer)
+		}
+		if ac.Email.From == "" {
+			p.add("alerts.email.from", "is required")
+		}
+		if len(ac.Email.Recipients) == 0 {
+			p.add("alerts.email.recipients", "must not be empty")
+		}
+	}
+
+	if ac.Webhook.Enabled {
+		if u, err := url.Parse(ac.Webhook.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			p.add("alerts.webhook.url", "must be an absolute URL, got %q", ac.Webhook.URL)
+		}
+		if ac.Webhook.Format != "" && !contains(webhookFormats, ac.Webhook.Format) {
+			p.add("alerts.webhook.format", "must be one of %s, got %q", strings.Join(webhookFormats, ", "), ac.Webhook.Format)
+		}
+		p.duration("alerts.webhook.timeout", ac.Webhook.Timeout, false, true)
+		if ac.Webhook.RetryCount < 0 {
+			p.add("alerts.webhook.retry_count", "must not be negative, got %d", ac.Webhook.RetryCount)
+		}
+	}
+
+	tc := ac.Thresholds
+	if tc.ConsecutiveFailures < 1 {
+		p.add("alerts.thresholds.consecutive_failures", "must be at least 1, got %d", tc.ConsecutiveFailures)
+	}
+	if tc.UptimeThreshold < 0 || tc.UptimeThreshold > 100 {
+		p.add("alerts.thresholds.uptime_threshold", "must be between 0 and 100, got %v", tc.UptimeThreshold)
+	}
+
+	p.duration("alerts.thresholds.response_time_threshold", tc.ResponseTimeThreshold, true, true)
+	p.duration("alerts.thresholds.uptime_window", tc.UptimeWindow, true, true)
+	p.duration("alerts.thresholds.performance_window", tc.PerformanceWindow, true, true)
+	p.duration("alerts.thresholds.alert_cooldown", tc.AlertCooldown, true, false)
+	for i, days := range tc.SSLExpiryWarningDays {
+		if days <= 0 {
+			p.add(fmt.Sprintf("alerts.thresholds.ssl_expiry_warning_days[%d]", i), "must be positive, got %d", days)
+		}
+	}
+
+	// Template IDs are checked against the available templates by the alerts package
+	for _, key := range sortedKeys(ac.Templates) {
+		path := "alerts.templates." + key
+		i := strings.LastIndex(key, "_")
+		if i <= 0 || !contains(templateChannels, key[i+1:]) {
+			p.add(path, "key must be <alert type>_<channel> with channel one of %s", strings.Join(templateChannels, ", "))
+		}
+		if ac.Templates[key] == "" {
+			p.add(path, "template ID is required")
+		}
+	}
+}
+
+// validateReports checks the report schedules; sites are the configured site
+// names, which schedules may refer to
+func (c *Config) validateReports(sites map[string]int, p *problems) {
+	if c.Reports.Email.Enabled && (c.Alerts == nil || !c.Alerts.Email.Enabled) {
+		p.add("reports.email.enabled", "requires alerts.email to be enabled, whose SMTP settings are used to send reports")
+	}
+
+	names := make(map[string]int)
+	for i, schedule := range c.Reports.Email.Schedules {
+		path := fmt.Sprintf("reports.email.schedules[%d]", i)
+
+		if schedule.Name == "" {
+			p.add(path+".name", "is required")
+		} else if first, exists := names[schedule.Name]; exists {
+			p.add(path+".name", "duplicate schedule name %q (also used by schedules[%d])", schedule.Name, first)
+		} else {
+			names[schedule.Name] = i
+		}
+
+		if schedule.Schedule == "" {
+			p.add(path+".schedule", "is required")
+		}
+		p.oneOf(path+".schedule", schedule.Schedule, reportSchedules)
+		p.oneOf(path+".format", schedule.Format, reportFormats)
+
+		if schedule.DayOfWeek < 0 || schedule.DayOfWeek > 6 {
+			p.add(path+".day_of_week", "must be between 0 (Sunday) and 6 (Saturday), got %d", schedule.DayOfWeek)
+		}
+		if schedule.DayOfMonth < 0 || schedule.DayOfMonth > 31 {
+			p.add(path+".day_of_month", "must be between 1 and 31, got %d", schedule.DayOfMonth)
+		}
+		if hour := schedule.GetHour(); hour < 0 || hour > 23 {
+			p.add(path+".hour", "must be between 0 and 23, got %d", hour)
+		}
+
+		for j, section := range schedule.Sections {
+			p.oneOf(fmt.Sprintf("%s.sections[%d]", path, j), section, reportSections)
+		}
+		for j, site := range schedule.Sites {
+			if _, exists := sites[site]; !exists {
+				p.add(fmt.Sprintf("%s.sites[%d]", path, j), "unknown site %q", site)
+			}
+		}
+		if c.Reports.Email.Enabled && len(schedule.Recipients) == 0 && (c.Alerts == nil || len(c.Alerts.Email.Recipients) == 0) {
+			p.add(path+".recipients", "must not be empty when alerts.email.recipients is")
+		}
+	}
+}
+
+func (mc *MetricsConfig) validate(p *problems) {
+	for i, percentile := range mc.Advanced.Percentiles {
+		if percentile <= 0 || percentile >= 100 {
+			p.add(fmt.Sprintf("metrics.advanced.percentiles[%d]", i), "must be between 0 and 100 (exclusive), got %v", percentile)
+		}
+	}
+	if mc.Advanced.RetentionDays < 0 {
+		p.add("metrics.advanced.retention_days", "must not be negative, got %d", mc.Advanced.RetentionDays)
+	}
+	for _, percent := range sortedKeys(mc.Advanced.SLATargets) {
+		if target, err := strconv.ParseFloat(percent, 64); err != nil || target <= 0 || target > 100 {
+			p.add("metrics.advanced.sla_targets."+percent, "key must be an uptime percentage, e.g. \"99.9\"")
+		}
+	}
+
+	for i, bucket := range mc.Performance.ResponseTimeBuckets {
+		path := fmt.Sprintf("metrics.performance.response_time_buckets[%d]", i)
+		if bucket <= 0 {
+			p.add(path, "must be positive, got %d", bucket)
+		} else if i > 0 && bucket <= mc.Performance.ResponseTimeBuckets[i-1] {
+			p.add(path, "buckets must be in increasing order")
+		}
+	}
+	for _, category := range sortedKeys(mc.Performance.ErrorClassification) {
+		if len(mc.Performance.ErrorClassification[category]) == 0 {
+			p.add("metrics.performance.error_classification."+category, "must list at least one pattern")
+		}
+	}
+}
+
+func (sc *SSLConfig) validate(p *problems) {
+	p.longDuration("ssl.check_interval", sc.CheckInterval)
+	p.duration("ssl.timeout", sc.Timeout, false, true)
+	for i, days := range sc.WarningThresholds {
+		if days <= 0 {
+			p.add(fmt.Sprintf("ssl.warning_thresholds[%d]", i), "must be positive, got %d", days)
+		}
+	}
+}
+
+func (sc *StorageConfig) validate(p *problems) {
+	p.oneOf("storage.driver", sc.Driver, storageDrivers)
+	p.longDuration("storage.sqlite.vacuum_interval", sc.SQLite.VacuumInterval)
+	if sc.GetDriver() == StoragePostgres && sc.Postgres.DSN == "" {
+		p.add("storage.postgres.dsn", "is required with the postgres driver")
+	}
+	if sc.Postgres.MaxOpenConns < 0 {
+		p.add("storage.postgres.max_open_conns", "must not be negative, got %d", sc.Postgres.MaxOpenConns)
+	}
+
+	if sc.Retention.RawDataDays < 0 {
+		p.add("storage.retention.raw_data_days", "must not be negative, got %d", sc.Retention.RawDataDays)
+	}
+	if sc.Retention.AggregatedDataDays < 0 {
+		p.add("storage.retention.aggregated_data_days", "must not be negative, got %d", sc.Retention.AggregatedDataDays)
+	}
+	if sc.Retention.AlertHistoryDays < 0 {
+		p.add("storage.retention.alert_history_days", "must not be negative, got %d", sc.Retention.AlertHistoryDays)
+	}
+}
+
+func (lc *LoggingConfig) validate(p *problems) {
+	p.oneOf("logging.level", lc.Level, logLevels)
+	p.oneOf("logging.format", lc.Format, logFormats)
+	p.oneOf("logging.output", lc.Output, logOutputs)
+
+	if !lc.File.Enabled {
+		return
+	}
+	if lc.File.Path == "" {
+		p.add("logging.file.path", "is required when the log file is enabled")
+	}
+	if _, err := lc.File.GetMaxSize(); err != nil {
+		p.add("logging.file.max_size", "%v", err)
+	}
+	p.longDuration("logging.file.max_age", lc.File.MaxAge)
+}
+
+func (ac *APIConfig) validate(p *problems) {
+	p.port("api.port", ac.Port)
+	if ac.RateLimiting.RequestsPerMinute < 0 {
+		p.add("api.rate_limiting.requests_per_minute", "must not be negative, got %d", ac.RateLimiting.RequestsPerMinute)
+	}
+	if ac.CORS.Enabled && len(ac.CORS.Origins) == 0 {
+		p.add("api.cors.origins", "must not be empty when CORS is enabled")
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// problemPaths returns the JSON path of every problem in err
+func problemPaths(t *testing.T, err error) []string {
+	t.Helper()
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("Expected a joined error, got %v", err)
+	}
+
+	var paths []string
+	for _, e := range joined.Unwrap() {
+		var fieldErr *FieldError
+		if !errors.As(e, &fieldErr) {
+			t.Fatalf("Expected a *FieldError, got %T: %v", e, e)
+		}
+		paths = append(paths, fieldErr.Path)
+	}
+	return paths
+}
+
+func validAlerts() *AlertConfig {
+	return &AlertConfig{Thresholds: ThresholdConfig{
+		ConsecutiveFailures:   3,
+		ResponseTimeThreshold: "5s",
+		UptimeThreshold:       95,
+		UptimeWindow:          "24h",
+		PerformanceWindow:     "1h",
+		AlertCooldown:         "5m",
+	}}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := &Config{
+		Sites: []Site{
+			{Name: "ok", URL: "https://example.com", Interval: "30s", Timeout: "5s"},
+			{Name: "dns", Type: "dns", URL: "example.com", Interval: "1m"},
+		},
+		Alerts: validAlerts(),
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected a valid config, got %v", err)
+	}
+
+	cfg.Sites = append(cfg.Sites,
+		Site{Name: "ok", URL: "example.com", Interval: "30", Timeout: "-1s", Retries: -1, ExpectedStatusCodes: []int{200, 42}},
+		Site{URL: "https://example.com", Auth: &AuthConfig{Type: "digest"}},
+	)
+	cfg.Alerts.Email = EmailConfig{Enabled: true, SMTPServer: "smtp.example.com", From: "monitor@example.com", Recipients: []string{"ops@example.com"}}
+	cfg.Alerts.Webhook = WebhookConfig{Enabled: true, URL: "/hook", Format: "mattermost", Timeout: "soon"}
+	cfg.Alerts.Thresholds.UptimeThreshold = 101
+	cfg.Scheduler = &SchedulerConfig{Workers: -1, MaxJitter: "lots"}
+	cfg.DrainTimeout = "0s"
+
+	expected := []string{
+		"sites[2].interval",
+		"sites[2].timeout",
+		"sites[2].retries",
+		"sites[2].expected_status_codes[1]",
+		"sites[2].url",
+		"sites[2].name",
+		"sites[3].name",
+		"sites[3].interval",
+		"sites[3].auth.type",
+		"alerts.email.smtp_server",
+		"alerts.webhook.url",
+		"alerts.webhook.format",
+		"alerts.webhook.timeout",
+		"alerts.thresholds.uptime_threshold",
+		"scheduler.workers",
+		"scheduler.max_jitter",
+		"drain_timeout",
+	}
+	paths := problemPaths(t, cfg.Validate())
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected problems:\n%s\nexpected:\n%s", strings.Join(paths, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestConfig_ValidateHeartbeatTokens(t *testing.T) {
+	cfg := &Config{Sites: []Site{
+		{Name: "API Job", Type: "heartbeat", Interval: "1h"},
+		{Name: "api-job", Type: "heartbeat", Interval: "1h"},
+		{Name: "backup", Type: "heartbeat", Interval: "1h", Token: "api-job"},
+		{Name: "api job", URL: "https://example.com", Interval: "1m"},
+		{Name: "💓", Type: "heartbeat", Interval: "1h"},
+	}, Dashboard: &DashboardConfig{Enabled: true}}
+
+	expected := []string{"sites[1].name", "sites[2].token", "sites[4].token"}
+	paths := problemPaths(t, cfg.Validate())
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected problems:\n%s\nexpected:\n%s", strings.Join(paths, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestConfig_ValidateHeartbeatNeedsDashboard(t *testing.T) {
+	cfg := &Config{Sites: []Site{
+		{Name: "ok", URL: "https://example.com", Interval: "1m"},
+		{Name: "backup", Type: "heartbeat", Interval: "1h"},
+	}}
+
+	paths := problemPaths(t, cfg.Validate())
+	if len(paths) != 1 || paths[0] != "sites[1].type" {
+		t.Errorf("Expected the heartbeat to need the dashboard, got %v", paths)
+	}
+
+	cfg.Dashboard = &DashboardConfig{Enabled: true}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a valid config with the dashboard enabled, got %v", err)
+	}
+}
+
+func TestSite_ValidateConnection(t *testing.T) {
+	valid := []Site{
+		{Name: "a", URL: "https://internal.example", Interval: "1m",
+			TLS:   &TLSConfig{CertFile: "client.pem", KeyFile: "client-key.pem", MinVersion: "1.3"},
+			Proxy: &ProxyConfig{URL: "http://proxy.example:3128", Username: "monitor", Password: "secret"}},
+		{Name: "b", Type: "tcp", URL: "db.internal:5432", Interval: "1m", Proxy: &ProxyConfig{URL: "socks5h://127.0.0.1:1080"}},
+	}
+	if err := (&Config{Sites: valid}).Validate(); err != nil {
+		t.Fatalf("Expected a valid config, got %v", err)
+	}
+
+	invalid := []Site{
+		{Name: "c", URL: "https://internal.example", Interval: "1m",
+			TLS:   &TLSConfig{CertFile: "client.pem", MinVersion: "1.4"},
+			Proxy: &ProxyConfig{URL: "ftp://proxy.example", Password: "secret"}},
+		{Name: "d", Type: "dns", URL: "example.com", Interval: "1m", Proxy: &ProxyConfig{URL: "socks5://proxy"}},
+	}
+	expected := []string{
+		"sites[0].tls.min_version",
+		"sites[0].tls",
+		"sites[0].proxy.url",
+		"sites[0].proxy.username",
+		"sites[1].proxy",
+	}
+	paths := problemPaths(t, (&Config{Sites: invalid}).Validate())
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected problems:\n%s\nexpected:\n%s", strings.Join(paths, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestSite_ValidateAddressing(t *testing.T) {
+	valid := []Site{
+		{Name: "a", URL: "https://shop.example.com", Interval: "1m", IPVersion: IPBoth},
+		{Name: "b", URL: "https://shop.example.com", Interval: "1m", IPVersion: IPBoth, ResolveTo: []string{"192.0.2.10", "2001:db8::10"}},
+		{Name: "c", Type: "tcp", URL: "db.internal:5432", Interval: "1m", ResolveTo: []string{"10.0.0.5"}},
+	}
+	if err := (&Config{Sites: valid}).Validate(); err != nil {
+		t.Fatalf("Expected a valid config, got %v", err)
+	}
+
+	invalid := []Site{
+		{Name: "d", URL: "https://shop.example.com", Interval: "1m", IPVersion: "v5", ResolveTo: []string{"backend-1"}},
+		{Name: "e", URL: "https://shop.example.com", Interval: "1m", IPVersion: IPv6, ResolveTo: []string{"192.0.2.10"}},
+		{Name: "f", Type: "dns", URL: "example.com", Interval: "1m", IPVersion: IPv4},
+	}
+	expected := []string{
+		"sites[0].ip_version",
+		"sites[0].resolve_to[0]",
+		"sites[1].resolve_to",
+		"sites[2].ip_version",
+	}
+	paths := problemPaths(t, (&Config{Sites: invalid}).Validate())
+	if strings.Join(paths, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected problems:\n%s\nexpected:\n%s", strings.Join(paths, "\n"), strings.Join(expected, "\n"))
+	}
+}
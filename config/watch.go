@@ -0,0 +1,48 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Watch polls a file every interval and sends on the returned channel when
+// its size or modification time changes. Changes that occur while a previous
+// notification is pending are coalesced. The channel is closed when ctx is
+// cancelled.
+func Watch(ctx context.Context, filename string, interval time.Duration) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+
+	go func() {
+		defer close(changes)
+
+		last, _ := os.Stat(filename)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(filename)
+			if err != nil {
+				// The file may be mid-replace by an editor; try again next tick
+				continue
+			}
+			if last != nil && info.Size() == last.Size() && info.ModTime().Equal(last.ModTime()) {
+				continue
+			}
+			last = info
+
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes
+}
@@ -50,6 +50,11 @@ func (f *CSVFormatter) Format(data *ExportData, writer io.Writer) error {
 		"success",
 		"status_code",
 		"response_time_ms",
+		"dns_ms",
+		"connect_ms",
+		"tls_ms",
+		"ttfb_ms",
+		"transfer_ms",
 		"error",
 	}
 	if err := csvWriter.Write(header); err != nil {
@@ -64,7 +69,12 @@ func (f *CSVFormatter) Format(data *ExportData, writer io.Writer) error {
 			entry.URL,
 			strconv.FormatBool(entry.Success),
 			strconv.Itoa(entry.Status),
-			fmt.Sprintf("%.2f", float64(entry.Duration.Nanoseconds())/1000000), // Convert to ms
+			formatMilliseconds(entry.Duration),
+			formatMilliseconds(entry.Timings.DNS),
+			formatMilliseconds(entry.Timings.Connect),
+			formatMilliseconds(entry.Timings.TLS),
+			formatMilliseconds(entry.Timings.TTFB),
+			formatMilliseconds(entry.Timings.Transfer),
 			entry.Error,
 		}
 
@@ -144,6 +154,11 @@ func GetFormatter(format ExportFormat) (Formatter, error) {
 	}
 }
 
+// formatMilliseconds formats a duration as fractional milliseconds for CSV
+func formatMilliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.2f", float64(d.Nanoseconds())/1000000)
+}
+
 // Template helper functions
 func formatDuration(d time.Duration) string {
 	if d < time.Second {
@@ -3,6 +3,7 @@ package export
 import (
 	"bytes"
 	"encoding/json"
+	"site-monitor/monitor"
 	"site-monitor/storage"
 	"strings"
 	"testing"
@@ -51,6 +52,13 @@ func createTestExportData() *ExportData {
 				Duration:  100 * time.Millisecond,
 				Success:   true,
 				Timestamp: now.Add(-30 * time.Minute),
+				Timings: monitor.Timings{
+					DNS:      5 * time.Millisecond,
+					Connect:  10 * time.Millisecond,
+					TLS:      25 * time.Millisecond,
+					TTFB:     55 * time.Millisecond,
+					Transfer: 5 * time.Millisecond,
+				},
 			},
 			{
 				ID:        2,
@@ -123,7 +131,7 @@ func TestCSVFormatter(t *testing.T) {
 		t.Fatal("CSV output is empty")
 	}
 
-	headerExpected := "timestamp,site_name,url,success,status_code,response_time_ms,error"
+	headerExpected := "timestamp,site_name,url,success,status_code,response_time_ms,dns_ms,connect_ms,tls_ms,ttfb_ms,transfer_ms,error"
 	if lines[0] != headerExpected {
 		t.Errorf("Expected header: %s\nGot: %s", headerExpected, lines[0])
 	}
@@ -140,7 +148,8 @@ func TestCSVFormatter(t *testing.T) {
 		"https://test1.com",
 		"true",
 		"200",
-		"100.00", // response time in ms
+		"100.00",                             // response time in ms
+		"100.00,5.00,10.00,25.00,55.00,5.00", // response time and phases in ms
 	}
 
 	for _, expected := range expectedValues {
@@ -75,6 +75,11 @@ type CSVRecord struct {
 	Success      string `csv:"success"`
 	StatusCode   string `csv:"status_code"`
 	ResponseTime string `csv:"response_time_ms"`
+	DNS          string `csv:"dns_ms"`
+	Connect      string `csv:"connect_ms"`
+	TLS          string `csv:"tls_ms"`
+	TTFB         string `csv:"ttfb_ms"`
+	Transfer     string `csv:"transfer_ms"`
 	Error        string `csv:"error,omitempty"`
 }
 
@@ -0,0 +1,149 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"site-monitor/storage"
+	"time"
+)
+
+// Type is the check type of push monitors
+const Type = "heartbeat"
+
+func init() {
+	// The registry has no ping store to hand out, so the registered checker
+	// only validates the site; the daemon builds its checkers with
+	// NewCheckerFromSite
+	monitor.Register(Type, func(site config.Site) (monitor.Checker, error) {
+		return NewCheckerFromSite(site, nil)
+	})
+}
+
+// Checker evaluates the pings pushed by a job. Each scheduled run turns the
+// latest pings into a Result: a missing ping after the period plus the grace
+// window, a failure ping or a run that never finished all fail the check.
+type Checker struct {
+	Name    string
+	Token   string
+	Period  time.Duration            // Expected time between successful pings
+	Grace   time.Duration            // Extra time allowed before a late ping fails
+	store   storage.HeartbeatStorage // Where the dashboard records the pings
+	created time.Time                // Baseline used until the first ping arrives
+	now     func() time.Time
+}
+
+// NewChecker creates a heartbeat checker for the named site, reading its
+// pings from store
+func NewChecker(name, token string, period, grace time.Duration, store storage.HeartbeatStorage) *Checker {
+	return &Checker{
+		Name:    name,
+		Token:   token,
+		Period:  period,
+		Grace:   grace,
+		store:   store,
+		created: time.Now(),
+		now:     time.Now,
+	}
+}
+
+// NewCheckerFromSite builds a heartbeat checker from a site configuration,
+// reading its pings from store
+func NewCheckerFromSite(site config.Site, store storage.HeartbeatStorage) (*Checker, error) {
+	period, err := site.GetPeriod()
+	if err != nil {
+		return nil, fmt.Errorf("invalid period: %w", err)
+	}
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be positive, got %v", period)
+	}
+
+	grace, err := site.GetGrace()
+	if err != nil {
+		return nil, fmt.Errorf("invalid grace: %w", err)
+	}
+
+	token := site.GetHeartbeatToken()
+	if token == "" {
+		return nil, fmt.Errorf("heartbeat needs a token or a name to derive one from")
+	}
+
+	return NewChecker(site.Name, token, period, grace, store), nil
+}
+
+// Check turns the latest pings into a result
+func (c *Checker) Check(ctx context.Context) monitor.Result {
+	now := c.now()
+	result := monitor.Result{
+		URL:       "/ping/" + c.Token,
+		Timestamp: now,
+	}
+
+	if c.store == nil {
+		result.Error = "heartbeat storage is not configured"
+		return result
+	}
+
+	pings, err := c.store.GetLatestHeartbeatPings(c.Name, 2)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	deadline := c.Period + c.Grace
+	if len(pings) == 0 {
+		if waited := now.Sub(c.created); waited > deadline {
+			result.Error = fmt.Sprintf("no ping received in %v (period %v, grace %v)", waited.Round(time.Second), c.Period, c.Grace)
+			return result
+		}
+		result.Success = true
+		return result
+	}
+
+	last := pings[0]
+	age := now.Sub(last.Timestamp)
+
+	switch last.Kind {
+	case storage.HeartbeatStart:
+		// A run is in progress: it must finish within the grace window,
+		// or within the period when no grace is configured
+		maxRun := c.Grace
+		if maxRun <= 0 {
+			maxRun = c.Period
+		}
+		if age > maxRun {
+			result.Error = fmt.Sprintf("run started %v ago and has not finished", age.Round(time.Second))
+			return result
+		}
+		result.Success = true
+		return result
+
+	case storage.HeartbeatFail:
+		result.Duration = runDuration(pings)
+		result.Status = last.ExitCode
+		if last.ExitCode != 0 {
+			result.Error = fmt.Sprintf("job reported failure (exit code %d)", last.ExitCode)
+		} else {
+			result.Error = "job reported failure"
+		}
+		return result
+	}
+
+	result.Duration = runDuration(pings)
+	if age > deadline {
+		result.Error = fmt.Sprintf("last ping %v ago (period %v, grace %v)", age.Round(time.Second), c.Period, c.Grace)
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// runDuration returns how long the last run took when it was preceded by a start ping
+func runDuration(pings []storage.HeartbeatPing) time.Duration {
+	if len(pings) < 2 || pings[1].Kind != storage.HeartbeatStart {
+		return 0
+	}
+	return pings[0].Timestamp.Sub(pings[1].Timestamp)
+}
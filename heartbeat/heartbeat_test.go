@@ -0,0 +1,113 @@
+package heartbeat
+
+import (
+	"context"
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"site-monitor/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryStore keeps pings in memory, newest last
+type memoryStore struct {
+	pings []storage.HeartbeatPing
+}
+
+func (m *memoryStore) SaveHeartbeatPing(ping storage.HeartbeatPing) error {
+	m.pings = append(m.pings, ping)
+	return nil
+}
+
+func (m *memoryStore) GetLatestHeartbeatPings(siteName string, limit int) ([]storage.HeartbeatPing, error) {
+	var latest []storage.HeartbeatPing
+	for i := len(m.pings) - 1; i >= 0 && len(latest) < limit; i-- {
+		if m.pings[i].SiteName == siteName {
+			latest = append(latest, m.pings[i])
+		}
+	}
+	return latest, nil
+}
+
+func TestChecker(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ping := func(kind string, exitCode int, ago time.Duration) storage.HeartbeatPing {
+		return storage.HeartbeatPing{SiteName: "backup", Kind: kind, ExitCode: exitCode, Timestamp: base.Add(-ago)}
+	}
+
+	tests := []struct {
+		name    string
+		pings   []storage.HeartbeatPing // Oldest first
+		created time.Duration           // How long ago the checker started
+		success bool
+		errText string
+	}{
+		{"no ping yet within period", nil, 30 * time.Minute, true, ""},
+		{"no ping after period and grace", nil, 2 * time.Hour, false, "no ping received"},
+		{"recent success", []storage.HeartbeatPing{ping(storage.HeartbeatSuccess, 0, 10*time.Minute)}, 0, true, ""},
+		{"late but within grace", []storage.HeartbeatPing{ping(storage.HeartbeatSuccess, 0, 65*time.Minute)}, 0, true, ""},
+		{"missed", []storage.HeartbeatPing{ping(storage.HeartbeatSuccess, 0, 80*time.Minute)}, 0, false, "last ping"},
+		{"failure", []storage.HeartbeatPing{ping(storage.HeartbeatFail, 0, time.Minute)}, 0, false, "job reported failure"},
+		{"exit code", []storage.HeartbeatPing{ping(storage.HeartbeatFail, 3, time.Minute)}, 0, false, "exit code 3"},
+		{"running", []storage.HeartbeatPing{ping(storage.HeartbeatStart, 0, 5*time.Minute)}, 0, true, ""},
+		{"stuck run", []storage.HeartbeatPing{ping(storage.HeartbeatStart, 0, 20*time.Minute)}, 0, false, "has not finished"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker("backup", "backup", time.Hour, 15*time.Minute, &memoryStore{pings: tt.pings})
+			c.created = base.Add(-tt.created)
+			c.now = func() time.Time { return base }
+
+			result := c.Check(context.Background())
+			if result.Success != tt.success {
+				t.Errorf("Expected success=%v, got %+v", tt.success, result)
+			}
+			if !strings.Contains(result.Error, tt.errText) {
+				t.Errorf("Expected error containing %q, got %q", tt.errText, result.Error)
+			}
+		})
+	}
+}
+
+func TestChecker_RunDuration(t *testing.T) {
+	now := time.Now()
+	store := &memoryStore{pings: []storage.HeartbeatPing{
+		{SiteName: "etl", Kind: storage.HeartbeatStart, Timestamp: now.Add(-3 * time.Minute)},
+		{SiteName: "etl", Kind: storage.HeartbeatSuccess, Timestamp: now.Add(-time.Minute)},
+	}}
+
+	result := NewChecker("etl", "etl", time.Hour, 0, store).Check(context.Background())
+	if !result.Success || result.Duration != 2*time.Minute {
+		t.Errorf("Expected successful 2m run, got %+v", result)
+	}
+}
+
+func TestNewFromSite(t *testing.T) {
+	site := config.Site{
+		Name:     "Nightly Backup",
+		Type:     Type,
+		Interval: "1m",
+		Period:   "24h",
+		Grace:    "30m",
+	}
+
+	// The registered checker has no store to read pings from
+	m, err := monitor.NewFromSite(site)
+	if err != nil {
+		t.Fatalf("NewFromSite failed: %v", err)
+	}
+	if result := m.Check(context.Background()); result.Success {
+		t.Errorf("Expected a checker without a store to fail, got %+v", result)
+	}
+
+	checker, err := NewCheckerFromSite(site, &memoryStore{})
+	if err != nil {
+		t.Fatalf("NewCheckerFromSite failed: %v", err)
+	}
+	result := checker.Check(context.Background())
+	if !result.Success || result.URL != "/ping/nightly-backup" {
+		t.Errorf("Expected a passing check on the ping URL derived from the name, got %+v", result)
+	}
+}
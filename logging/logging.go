@@ -0,0 +1,153 @@
+// Package logging routes the standard logger according to the logging
+// section of the configuration.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"site-monitor/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level orders log messages by importance
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the level name used in the configuration
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel parses a configured level name, defaulting to info
+func ParseLevel(name string) Level {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug
+	case "warn":
+		return LevelWarn
+	case "error":
+		return LevelError
+	default:
+		return LevelInfo
+	}
+}
+
+// levelOf infers a message's level from the emoji prefixes used throughout
+// the code base: ❌ for errors and ⚠️ for warnings
+func levelOf(message string) Level {
+	switch {
+	case strings.HasPrefix(message, "❌"):
+		return LevelError
+	case strings.HasPrefix(message, "⚠️"):
+		return LevelWarn
+	case strings.HasPrefix(message, "🔍"):
+		return LevelDebug
+	default:
+		return LevelInfo
+	}
+}
+
+// Writer formats each line written by the standard logger as text or JSON
+// and drops lines below the configured level
+type Writer struct {
+	mu       sync.Mutex
+	out      io.Writer
+	json     bool
+	minLevel Level
+	now      func() time.Time
+}
+
+// NewWriter creates a writer that formats log lines onto out
+func NewWriter(out io.Writer, format string, minLevel Level) *Writer {
+	return &Writer{
+		out:      out,
+		json:     format == "json",
+		minLevel: minLevel,
+		now:      time.Now,
+	}
+}
+
+// Write formats one log line; the standard logger calls it once per message
+func (w *Writer) Write(p []byte) (int, error) {
+	message := strings.TrimRight(string(p), "\n")
+	level := levelOf(message)
+	if level < w.minLevel {
+		return len(p), nil
+	}
+
+	timestamp := w.now().Format(time.RFC3339)
+
+	var line []byte
+	if w.json {
+		encoded, err := json.Marshal(struct {
+			Time    string `json:"time"`
+			Level   string `json:"level"`
+			Message string `json:"msg"`
+		}{timestamp, level.String(), message})
+		if err != nil {
+			return 0, err
+		}
+		line = append(encoded, '\n')
+	} else {
+		line = []byte(fmt.Sprintf("%s %-5s %s\n", timestamp, strings.ToUpper(level.String()), message))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.out.Write(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Setup redirects the standard logger according to cfg. It returns the log
+// file, or nil when logging.file is disabled; Close is safe to call on either.
+func Setup(cfg config.LoggingConfig) (*RotatingFile, error) {
+	var out io.Writer = os.Stderr
+	if cfg.Output == "stdout" {
+		out = os.Stdout
+	}
+
+	var file *RotatingFile
+	if cfg.File.Enabled {
+		maxSize, err := cfg.File.GetMaxSize()
+		if err != nil {
+			return nil, fmt.Errorf("invalid logging.file.max_size: %w", err)
+		}
+		maxAge, err := cfg.File.GetMaxAge()
+		if err != nil {
+			return nil, fmt.Errorf("invalid logging.file.max_age: %w", err)
+		}
+
+		file, err = OpenRotatingFile(cfg.File.Path, maxSize, maxAge, cfg.File.Compress)
+		if err != nil {
+			return nil, err
+		}
+		out = io.MultiWriter(out, file)
+	}
+
+	log.SetFlags(0) // The writer adds its own timestamp
+	log.SetOutput(NewWriter(out, cfg.Format, ParseLevel(cfg.Level)))
+
+	return file, nil
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter_FormatsAndFilters(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, "json", LevelWarn)
+	w.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	w.Write([]byte("📍 Starting example\n"))
+	w.Write([]byte("⚠️ Timed out waiting for checks\n"))
+	w.Write([]byte("❌ failed to purge results\n"))
+
+	expected := `{"time":"2024-01-02T03:04:05Z","level":"warn","msg":"⚠️ Timed out waiting for checks"}
+{"time":"2024-01-02T03:04:05Z","level":"error","msg":"❌ failed to purge results"}
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	w = NewWriter(&buf, "text", LevelInfo)
+	w.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	w.Write([]byte("📍 Starting example\n"))
+	if buf.String() != "2024-01-02T03:04:05Z INFO  📍 Starting example\n" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "monitor.log")
+
+	// A leftover rotated file that has expired
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	expired := path + "." + time.Now().Add(-48*time.Hour).Format(rotatedTimeFormat) + ".gz"
+	os.WriteFile(expired, []byte("old"), 0o644)
+
+	rf, err := OpenRotatingFile(path, 10, 24*time.Hour, true)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile failed: %v", err)
+	}
+	defer rf.Close()
+
+	rf.Write([]byte("12345678\n"))
+	rf.Write([]byte("abcdefgh\n")) // Exceeds 10 bytes, rotates first
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "abcdefgh\n" {
+		t.Errorf("Expected only the latest line in the current file, got %q", current)
+	}
+
+	matches, _ := filepath.Glob(path + ".*")
+	if len(matches) != 1 || !strings.HasSuffix(matches[0], ".gz") || matches[0] == expired {
+		t.Errorf("Expected one compressed rotated file and the expired one removed, got %v", matches)
+	}
+}
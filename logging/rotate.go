@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is appended to the log file name when it is rotated
+const rotatedTimeFormat = "20060102-150405"
+
+// RotatingFile is a log file that is renamed once it grows past maxSize.
+// Rotated files are optionally gzipped and deleted once older than maxAge.
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64         // Zero never rotates
+	maxAge   time.Duration // Zero keeps rotated files forever
+	compress bool
+	file     *os.File
+	size     int64
+}
+
+// OpenRotatingFile opens (or creates) the log file at path for appending
+func OpenRotatingFile(path string, maxSize int64, maxAge time.Duration, compress bool) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxAge:   maxAge,
+		compress: compress,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// Write appends to the file, rotating it first if the write would exceed maxSize
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close closes the current log file
+func (rf *RotatingFile) Close() error {
+	if rf == nil {
+		return nil
+	}
+
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.file.Close()
+}
+
+// open opens the log file and records its current size
+func (rf *RotatingFile) open() error {
+	if dir := filepath.Dir(rf.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	return nil
+}
+
+// rotate renames the current file aside, starts a new one and cleans up
+// old rotated files
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	rotated := rf.path + "." + time.Now().Format(rotatedTimeFormat)
+	if err := os.Rename(rf.path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	if rf.compress {
+		if err := compressFile(rotated); err != nil {
+			fmt.Fprintf(rf.file, "⚠️ Failed to compress %s: %v\n", rotated, err)
+		}
+	}
+	rf.removeExpired()
+	return nil
+}
+
+// removeExpired deletes rotated files older than maxAge
+func (rf *RotatingFile) removeExpired() {
+	if rf.maxAge <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(rf.path + ".*")
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-rf.maxAge)
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(match, rf.path+"."), ".gz")
+		rotatedAt, err := time.ParseInLocation(rotatedTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue // Not one of ours
+		}
+		if rotatedAt.Before(cutoff) {
+			os.Remove(match)
+		}
+	}
+}
+
+// compressFile gzips path into path.gz and removes the original
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -1,18 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"site-monitor/alerts"
 	"site-monitor/cmd"
 	"site-monitor/config"
+	"site-monitor/heartbeat"
+	"site-monitor/logging"
 	"site-monitor/monitor"
+	"site-monitor/pipeline"
+	"site-monitor/reload"
+	"site-monitor/reports"
+	"site-monitor/scheduler"
 	"site-monitor/storage"
+	"site-monitor/web"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 )
@@ -22,6 +31,7 @@ func main() {
 	var (
 		showHelp    = flag.Bool("help", false, "Show help message")
 		showVersion = flag.Bool("version", false, "Show version information")
+		configFile  = flag.String("config", cmd.DefaultConfigPath, "Configuration file (JSON, YAML or TOML)")
 	)
 
 	// Parse command line arguments
@@ -42,22 +52,23 @@ func main() {
 
 	if len(args) == 0 {
 		// Default behavior: run the monitor
-		runMonitor()
+		runMonitor(*configFile)
 		return
 	}
 
-	// Handle subcommands
+	// Handle subcommands; --config is accepted after any of them
 	command := args[0]
-	commandArgs := args[1:]
+	configPath, commandArgs := extractConfigFlag(args[1:], *configFile)
 
 	app, err := cmd.NewCLIApp()
 	if err != nil {
 		log.Fatal("Failed to initialize CLI app:", err)
 	}
+	app.SetConfigPath(configPath)
 
 	switch command {
 	case "run", "monitor":
-		runMonitor()
+		runMonitor(configPath)
 	case "stats":
 		runStatsCommand(app, commandArgs)
 	case "history":
@@ -68,6 +79,10 @@ func main() {
 		runDashboardCommand(app, commandArgs)
 	case "export":
 		runExportCommand(app, commandArgs)
+	case "config":
+		runConfigCommand(app, commandArgs)
+	case "db":
+		runDBCommand(app, commandArgs)
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		fmt.Println()
@@ -90,6 +105,13 @@ func showUsage() {
 	fmt.Println("  status [options]        Show current status")
 	fmt.Println("  dashboard [options]     Start web dashboard")
 	fmt.Println("  export [options]        Export monitoring data")
+	fmt.Println("  config validate [file]  Check a configuration file")
+	fmt.Println("  db migrate              Apply pending database migrations")
+	fmt.Println("  db status               Show the database schema version")
+	fmt.Println()
+	fmt.Println("GLOBAL OPTIONS:")
+	fmt.Println("  --config <file>         Configuration file, .json, .yaml/.yml or .toml")
+	fmt.Println("                          (default: config.json; accepted by every command)")
 	fmt.Println()
 	fmt.Println("STATS OPTIONS:")
 	fmt.Println("  --site <name>           Show stats for specific site")
@@ -105,7 +127,7 @@ func showUsage() {
 	fmt.Println("  --interval <duration>   Refresh interval (default: 30s)")
 	fmt.Println()
 	fmt.Println("DASHBOARD OPTIONS:")
-	fmt.Println("  --port <number>         Web server port (default: 8080)")
+	fmt.Println("  --port <number>         Web server port (default: dashboard.port, or 8080)")
 	fmt.Println()
 	fmt.Println("EXPORT OPTIONS:")
 	fmt.Println("  --format <format>       Export format (json, csv, html)")
@@ -126,6 +148,9 @@ func showUsage() {
 	fmt.Println("  site-monitor export --format json --output data.json")
 	fmt.Println("  site-monitor export --format csv --site \"My Site\" --since 7d")
 	fmt.Println("  site-monitor export --format html --stats")
+	fmt.Println("  site-monitor config validate")
+	fmt.Println("  site-monitor db status")
+	fmt.Println("  site-monitor run --config /etc/site-monitor/config.yaml")
 }
 
 // runStatsCommand handles the stats subcommand
@@ -153,7 +178,7 @@ func runStatsCommand(app *cmd.CLIApp, args []string) {
 	since := 24 * time.Hour // Default to 24 hours
 	if sinceStr != "" {
 		var err error
-		since, err = parseDuration(sinceStr)
+		since, err = config.ParseDuration(sinceStr)
 		if err != nil {
 			log.Fatalf("Invalid duration '%s': %v", sinceStr, err)
 		}
@@ -200,7 +225,7 @@ func runHistoryCommand(app *cmd.CLIApp, args []string) {
 	since := 24 * time.Hour // Default to 24 hours
 	if sinceStr != "" {
 		var err error
-		since, err = parseDuration(sinceStr)
+		since, err = config.ParseDuration(sinceStr)
 		if err != nil {
 			log.Fatalf("Invalid duration '%s': %v", sinceStr, err)
 		}
@@ -249,7 +274,7 @@ func runStatusCommand(app *cmd.CLIApp, args []string) {
 	interval := 30 * time.Second // Default to 30 seconds
 	if intervalStr != "" {
 		var err error
-		interval, err = parseDuration(intervalStr)
+		interval, err = config.ParseDuration(intervalStr)
 		if err != nil {
 			log.Fatalf("Invalid interval '%s': %v", intervalStr, err)
 		}
@@ -280,8 +305,8 @@ func runDashboardCommand(app *cmd.CLIApp, args []string) {
 		}
 	}
 
-	// Parse port
-	port := 8080 // Default port
+	// Parse port (0 uses dashboard.port from the configuration)
+	port := 0
 	if portStr != "" {
 		var err error
 		port, err = strconv.Atoi(portStr)
@@ -294,7 +319,10 @@ func runDashboardCommand(app *cmd.CLIApp, args []string) {
 		Port: port,
 	}
 
-	if err := app.ShowDashboard(opts); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := app.ShowDashboard(ctx, opts); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -366,7 +394,7 @@ func runExportCommand(app *cmd.CLIApp, args []string) {
 	since := 24 * time.Hour // Default to 24 hours
 	if sinceStr != "" {
 		var err error
-		since, err = parseDuration(sinceStr)
+		since, err = config.ParseDuration(sinceStr)
 		if err != nil {
 			log.Fatalf("Invalid duration '%s': %v", sinceStr, err)
 		}
@@ -412,6 +440,46 @@ func runExportCommand(app *cmd.CLIApp, args []string) {
 	}
 }
 
+// runConfigCommand handles the config subcommand
+func runConfigCommand(app *cmd.CLIApp, args []string) {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Println("Usage: site-monitor config validate [file]")
+		os.Exit(1)
+	}
+
+	opts := cmd.ConfigValidateOptions{Path: app.ConfigPath()}
+	if len(args) > 1 {
+		opts.Path = args[1]
+	}
+
+	if err := app.ValidateConfig(opts); err != nil {
+		os.Exit(1)
+	}
+}
+
+// runDBCommand handles the db subcommand
+func runDBCommand(app *cmd.CLIApp, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: site-monitor db migrate|status")
+		os.Exit(1)
+	}
+
+	var err error
+	switch args[0] {
+	case "migrate":
+		err = app.MigrateDatabase()
+	case "status":
+		err = app.ShowDatabaseStatus()
+	default:
+		fmt.Println("Usage: site-monitor db migrate|status")
+		os.Exit(1)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 // showExportHelp displays help for the export command
 func showExportHelp() {
 	fmt.Println("Site Monitor - Export Command Help")
@@ -442,15 +510,36 @@ func showExportHelp() {
 }
 
 // runMonitor runs the original monitoring daemon
-func runMonitor() {
+func runMonitor(configPath string) {
 	// Load configuration from file
-	cfg, err := config.Load("config.json")
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		log.Fatal("Failed to load configuration:", err)
 	}
 
+	if err := errors.Join(cfg.Validate(), validateTemplates(cfg)); err != nil {
+		log.Fatalf("Invalid configuration (run 'site-monitor config validate' for details):\n%v", err)
+	}
+
+	if cfg.Logging != nil {
+		logFile, err := logging.Setup(*cfg.Logging)
+		if err != nil {
+			log.Fatal("Failed to set up logging:", err)
+		}
+		defer logFile.Close()
+	}
+
+	drainTimeout, err := cfg.GetDrainTimeout()
+	if err != nil {
+		log.Fatal("Invalid drain_timeout:", err)
+	}
+
 	// Initialize storage
-	db, err := storage.NewSQLiteStorage("site-monitor.db")
+	var storageConfig config.StorageConfig
+	if cfg.Storage != nil {
+		storageConfig = *cfg.Storage
+	}
+	db, err := storage.Open(storageConfig)
 	if err != nil {
 		log.Fatal("Failed to initialize storage:", err)
 	}
@@ -461,72 +550,206 @@ func runMonitor() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
-	fmt.Printf("🚀 Starting monitoring for %d sites\n", len(cfg.Sites))
-	fmt.Printf("💾 Database initialized: site-monitor.db\n")
+	// Build the result pipeline: every check result is fanned out to storage,
+	// the alert manager and any other subscriber registered here
+	results := pipeline.New(100)
+	if err := results.Subscribe("console", func(ctx context.Context, result monitor.Result) error {
+		fmt.Println(result)
+		return nil
+	}); err != nil {
+		log.Fatal("Failed to subscribe console:", err)
+	}
+	if err := results.Subscribe("storage", func(ctx context.Context, result monitor.Result) error {
+		return db.SaveResult(result)
+	}); err != nil {
+		log.Fatal("Failed to subscribe storage:", err)
+	}
 
-	var wg sync.WaitGroup
+	checks := scheduler.New(results, schedulerOptions(cfg))
 
-	// Channel to handle graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// The reloader owns the alert manager so alerting can be reconfigured
+	// without a restart
+	reloader := reload.New(cfg, checks, db)
+	if err := results.Subscribe("alerts", reloader.ProcessResult); err != nil {
+		log.Fatal("Failed to subscribe alert manager:", err)
+	}
 
-	// Start monitoring each site in a separate goroutine
-	for _, site := range cfg.Sites {
-		wg.Add(1)
+	results.Start()
 
-		go func(s config.Site) {
-			defer wg.Done()
+	fmt.Printf("🚀 Starting monitoring for %d sites\n", len(cfg.Sites))
+	fmt.Printf("💾 Database initialized: %s\n", storageConfig.Location())
 
-			interval, err := s.GetInterval()
-			if err != nil {
-				log.Printf("Invalid interval for %s: %v", s.Name, err)
-				return
-			}
+	// Register every site with the central scheduler
+	for _, s := range cfg.Sites {
+		m, err := reload.NewMonitor(s, db)
+		if err != nil {
+			log.Printf("Invalid configuration for %s: %v", s.Name, err)
+			continue
+		}
 
-			timeout, err := s.GetTimeout()
-			if err != nil {
-				log.Printf("Invalid timeout for %s: %v", s.Name, err)
-				return
-			}
+		if err := checks.Add(m); err != nil {
+			log.Printf("Failed to schedule %s: %v", s.Name, err)
+			continue
+		}
 
-			m := monitor.New(s.URL, interval)
-			m.SetName(s.Name)
-			m.SetTimeout(timeout)
-			m.SetStorage(db) // Attach storage to monitor
+		if s.Type == heartbeat.Type {
+			fmt.Printf("💓 Starting %s - expecting pings on /ping/%s\n",
+				s.Name, s.GetHeartbeatToken())
+			continue
+		}
 
-			fmt.Printf("📍 Starting %s (%s) - checking every %s\n",
-				s.Name, s.URL, s.Interval)
+		if s.Type == "exec" {
+			fmt.Printf("🔌 Starting %s (%s) - running every %s\n",
+				s.Name, s.Command, s.Interval)
+			continue
+		}
 
-			if err := m.Start(); err != nil {
-				log.Printf("Error monitoring %s: %v", s.Name, err)
-			}
-		}(site)
+		if s.Type == "transaction" {
+			fmt.Printf("🔗 Starting %s (%d steps) - checking every %s\n",
+				s.Name, len(s.Steps), s.Interval)
+			continue
+		}
+
+		fmt.Printf("📍 Starting %s (%s) - checking every %s\n",
+			s.Name, s.URL, s.Interval)
 	}
 
-	// Handle graceful shutdown
+	// Cancelled on SIGINT/SIGTERM to trigger a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Reload the configuration on SIGHUP or when it changes on disk
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+	go reloader.Run(ctx, configPath, hangups)
+	fmt.Printf("🔁 Watching %s for changes (or send SIGHUP to reload)\n", configPath)
+
+	schedulerDone := make(chan struct{})
 	go func() {
-		<-sigChan
-		fmt.Println("\n🛑 Received shutdown signal, stopping monitors...")
-		// Note: In a real implementation, we would send a stop signal to monitors
-		// For now, the program will exit and goroutines will be terminated
-		os.Exit(0)
+		defer close(schedulerDone)
+		if err := checks.Start(ctx); err != nil {
+			log.Printf("Scheduler error: %v", err)
+		}
 	}()
 
-	wg.Wait()
-}
+	// Rollups, retention and vacuuming run in the background
+	if maintainer, ok := db.(storage.Maintainer); ok {
+		go maintainer.Maintain(ctx, storageConfig)
+	}
+	if days := storageConfig.Retention.AlertHistoryDays; days != 0 {
+		log.Printf("⚠️ storage.retention.alert_history_days (%d) is ignored: alert history is not stored", days)
+	}
+
+	// Background services that are drained on shutdown
+	var services []<-chan struct{}
 
-// parseDuration parses duration strings like "1h", "30m", "24h", "7d"
-func parseDuration(s string) (time.Duration, error) {
-	// Handle days (Go's time.ParseDuration doesn't support 'd')
-	if strings.HasSuffix(s, "d") {
-		daysStr := strings.TrimSuffix(s, "d")
-		days, err := strconv.Atoi(daysStr)
+	if cfg.Reports != nil && cfg.Reports.Email.Enabled {
+		reportScheduler := reports.NewReportScheduler(db, cfg.Alerts.Email)
+		if err := reportScheduler.Configure(cfg); err != nil {
+			log.Fatal("Failed to configure reports:", err)
+		}
+		reportScheduler.Start(ctx)
+		services = append(services, reportScheduler.Done())
+		fmt.Printf("📧 Email reports: %d schedules\n", len(reportScheduler.Schedules()))
+	}
+
+	if cfg.Dashboard != nil && cfg.Dashboard.Enabled {
+		dashboard, err := web.NewDashboard(db, cfg, 0)
 		if err != nil {
-			return 0, fmt.Errorf("invalid number of days: %s", daysStr)
+			log.Fatal("Failed to create dashboard:", err)
+		}
+		dashboard.SetShutdownTimeout(drainTimeout)
+		reloader.SetDashboard(dashboard)
+		dashboard.SetScheduler(checks)
+
+		dashboardDone := make(chan struct{})
+		go func() {
+			defer close(dashboardDone)
+			if err := dashboard.Start(ctx); err != nil {
+				log.Printf("❌ Dashboard server error: %v", err)
+			}
+		}()
+		services = append(services, dashboardDone)
+	}
+
+	<-ctx.Done()
+	fmt.Println("\n🛑 Received shutdown signal, stopping monitors...")
+
+	// Give in-flight checks and queued results (storage writes, alerts)
+	// up to drainTimeout to complete before exiting
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	select {
+	case <-schedulerDone:
+	case <-drainCtx.Done():
+		log.Printf("⚠️ Timed out waiting for in-flight checks to complete")
+	}
+
+	if err := results.Shutdown(drainCtx); err != nil {
+		log.Printf("⚠️ Some results were not flushed: %v", err)
+	}
+
+	for _, done := range services {
+		select {
+		case <-done:
+		case <-drainCtx.Done():
+			log.Printf("⚠️ Timed out waiting for background services to stop")
+		}
+	}
+
+	fmt.Println(checks.Metrics())
+	fmt.Println("✅ Monitoring stopped")
+}
+
+// validateTemplates checks the alerts.templates selection against the
+// templates the alert manager knows about
+func validateTemplates(cfg *config.Config) error {
+	if cfg.Alerts == nil {
+		return nil
+	}
+	return alerts.ValidateTemplates(*cfg.Alerts)
+}
+
+// schedulerOptions builds scheduler options from the configuration
+func schedulerOptions(cfg *config.Config) scheduler.Options {
+	var opts scheduler.Options
+	if cfg.Scheduler == nil {
+		return opts
+	}
+
+	opts.Workers = cfg.Scheduler.Workers
+	opts.QueueSize = cfg.Scheduler.QueueSize
+
+	maxJitter, err := cfg.Scheduler.GetMaxJitter()
+	if err != nil {
+		log.Fatal("Invalid scheduler.max_jitter:", err)
+	}
+	opts.MaxJitter = maxJitter
+
+	return opts
+}
+
+// extractConfigFlag removes "--config <file>" or "--config=<file>" from a
+// subcommand's arguments and returns the configuration path to use
+func extractConfigFlag(args []string, defaultPath string) (string, []string) {
+	path := defaultPath
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--config" || args[i] == "-config":
+			if i+1 < len(args) {
+				path = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(args[i], "--config="):
+			path = strings.TrimPrefix(args[i], "--config=")
+		default:
+			rest = append(rest, args[i])
 		}
-		return time.Duration(days) * 24 * time.Hour, nil
 	}
 
-	// Use standard time.ParseDuration for other units
-	return time.ParseDuration(s)
+	return path, rest
 }
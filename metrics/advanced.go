@@ -1,14 +1,20 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"site-monitor/config"
 	"site-monitor/storage"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// ErrDisabled is returned when metrics.advanced.enabled is false
+var ErrDisabled = errors.New("advanced metrics are disabled in the configuration")
+
 // AdvancedMetrics represents advanced statistical metrics
 type AdvancedMetrics struct {
 	SiteName string `json:"site_name"`
@@ -21,6 +27,15 @@ type AdvancedMetrics struct {
 	P99  time.Duration `json:"p99_response_time"`
 	P999 time.Duration `json:"p999_response_time"`
 
+	// Percentiles holds the configured percentiles (metrics.advanced.percentiles), keyed like "p99.9"
+	Percentiles map[string]time.Duration `json:"percentiles,omitempty"`
+
+	// ResponseTimeHistogram counts successful checks per configured response time bucket
+	ResponseTimeHistogram []HistogramBucket `json:"response_time_histogram,omitempty"`
+
+	// Phase Timing Percentiles (DNS, connect, TLS, TTFB, transfer)
+	TimingPercentiles TimingPercentiles `json:"timing_percentiles"`
+
 	// Reliability Metrics
 	MTTR time.Duration `json:"mttr"` // Mean Time To Recovery
 	MTBF time.Duration `json:"mtbf"` // Mean Time Between Failures
@@ -55,6 +70,30 @@ type AdvancedMetrics struct {
 	AnalysisTimestamp time.Time `json:"analysis_timestamp"`
 }
 
+// TimingPercentiles represents percentiles for each request phase
+type TimingPercentiles struct {
+	DNS      PhasePercentiles `json:"dns"`
+	Connect  PhasePercentiles `json:"connect"`
+	TLS      PhasePercentiles `json:"tls"`
+	TTFB     PhasePercentiles `json:"ttfb"`
+	Transfer PhasePercentiles `json:"transfer"`
+}
+
+// PhasePercentiles represents the percentiles of a single request phase
+type PhasePercentiles struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+}
+
+// HistogramBucket counts the checks that took at most UpperBoundMs and more
+// than the previous bucket's bound; the last bucket (UpperBoundMs 0) is unbounded
+type HistogramBucket struct {
+	UpperBoundMs int   `json:"le_ms,omitempty"`
+	Count        int64 `json:"count"`
+}
+
 // ErrorStatistics represents error analysis for a specific error type
 type ErrorStatistics struct {
 	Count      int64     `json:"count"`
@@ -112,18 +151,58 @@ type DayStats struct {
 
 // AdvancedMetricsCalculator calculates advanced metrics
 type AdvancedMetricsCalculator struct {
-	storage storage.Storage
+	storage    storage.Storage
+	config     *config.MetricsConfig // nil uses the built-in defaults
+	slaTargets map[string]float64    // SLA name -> uptime percent
+}
+
+// defaultSLATargets are reported when no sla_targets are configured
+var defaultSLATargets = map[string]float64{
+	"99.9% (8.77h downtime/month)":    99.9,
+	"99.95% (4.38h downtime/month)":   99.95,
+	"99.99% (52.6min downtime/month)": 99.99,
+	"99.5% (3.65d downtime/month)":    99.5,
+	"95% (36.5h downtime/month)":      95.0,
 }
 
 // NewAdvancedMetricsCalculator creates a new calculator
 func NewAdvancedMetricsCalculator(storage storage.Storage) *AdvancedMetricsCalculator {
 	return &AdvancedMetricsCalculator{
-		storage: storage,
+		storage:    storage,
+		slaTargets: defaultSLATargets,
 	}
 }
 
+// SetConfig applies the metrics section of the configuration: percentiles,
+// trends, retention, SLA targets, response time buckets and error categories
+func (calc *AdvancedMetricsCalculator) SetConfig(cfg config.MetricsConfig) error {
+	targets, err := cfg.Advanced.GetSLATargets()
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		targets = defaultSLATargets
+	}
+
+	calc.config = &cfg
+	calc.slaTargets = targets
+	return nil
+}
+
 // CalculateAdvancedMetrics calculates comprehensive metrics for a site
 func (calc *AdvancedMetricsCalculator) CalculateAdvancedMetrics(siteName string, since time.Time, period string) (*AdvancedMetrics, error) {
+	if calc.config != nil && !calc.config.Advanced.Enabled {
+		return nil, ErrDisabled
+	}
+
+	// History older than the retention window is not analysed
+	if calc.config != nil && calc.config.Advanced.RetentionDays > 0 {
+		oldest := time.Now().AddDate(0, 0, -calc.config.Advanced.RetentionDays)
+		if since.Before(oldest) {
+			since = oldest
+		}
+	}
+
 	// Get raw historical data
 	history, err := calc.storage.GetHistory(siteName, since)
 	if err != nil {
@@ -146,6 +225,7 @@ func (calc *AdvancedMetricsCalculator) CalculateAdvancedMetrics(siteName string,
 	// Calculate basic counts
 	var successfulChecks, failedChecks int64
 	var responseTimes []time.Duration
+	var dnsTimes, connectTimes, tlsTimes, ttfbTimes, transferTimes []time.Duration
 	var errorCounts map[string]int64 = make(map[string]int64)
 	var downtimeEvents []DowntimeEvent
 
@@ -153,6 +233,11 @@ func (calc *AdvancedMetricsCalculator) CalculateAdvancedMetrics(siteName string,
 		if entry.Success {
 			successfulChecks++
 			responseTimes = append(responseTimes, entry.Duration)
+			dnsTimes = append(dnsTimes, entry.Timings.DNS)
+			connectTimes = append(connectTimes, entry.Timings.Connect)
+			tlsTimes = append(tlsTimes, entry.Timings.TLS)
+			ttfbTimes = append(ttfbTimes, entry.Timings.TTFB)
+			transferTimes = append(transferTimes, entry.Timings.Transfer)
 		} else {
 			failedChecks++
 			// Count errors by message
@@ -189,8 +274,27 @@ func (calc *AdvancedMetricsCalculator) CalculateAdvancedMetrics(siteName string,
 		metrics.P99 = calc.percentile(responseTimes, 99)
 		metrics.P999 = calc.percentile(responseTimes, 99.9)
 
+		if calc.config != nil && len(calc.config.Advanced.Percentiles) > 0 {
+			metrics.Percentiles = make(map[string]time.Duration)
+			for _, p := range calc.config.Advanced.Percentiles {
+				metrics.Percentiles["p"+strconv.FormatFloat(p, 'f', -1, 64)] = calc.percentile(responseTimes, p)
+			}
+		}
+		if calc.config != nil && len(calc.config.Performance.ResponseTimeBuckets) > 0 {
+			metrics.ResponseTimeHistogram = histogram(responseTimes, calc.config.Performance.ResponseTimeBuckets)
+		}
+
 		// Calculate standard deviation
 		metrics.ResponseTimeStdDev = calc.standardDeviation(responseTimes)
+
+		// Calculate phase timing percentiles
+		metrics.TimingPercentiles = TimingPercentiles{
+			DNS:      calc.phasePercentiles(dnsTimes),
+			Connect:  calc.phasePercentiles(connectTimes),
+			TLS:      calc.phasePercentiles(tlsTimes),
+			TTFB:     calc.phasePercentiles(ttfbTimes),
+			Transfer: calc.phasePercentiles(transferTimes),
+		}
 	}
 
 	// Calculate MTTR and MTBF
@@ -201,8 +305,12 @@ func (calc *AdvancedMetricsCalculator) CalculateAdvancedMetrics(siteName string,
 	}
 
 	// Calculate trends
-	metrics.ResponseTimeTrend = calc.calculateResponseTimeTrend(history)
-	metrics.UptimeTrend = calc.calculateUptimeTrend(history)
+	metrics.ResponseTimeTrend = TrendUnknown
+	metrics.UptimeTrend = TrendUnknown
+	if calc.config == nil || calc.config.Advanced.CalculateTrends {
+		metrics.ResponseTimeTrend = calc.calculateResponseTimeTrend(history)
+		metrics.UptimeTrend = calc.calculateUptimeTrend(history)
+	}
 
 	// Calculate error breakdown
 	metrics.ErrorBreakdown = calc.calculateErrorBreakdown(errorCounts, history, failedChecks)
@@ -241,6 +349,37 @@ func (calc *AdvancedMetricsCalculator) percentile(sortedDurations []time.Duratio
 	return time.Duration(lowerVal + weight*(upperVal-lowerVal))
 }
 
+// phasePercentiles calculates the percentiles of a request phase
+// histogram counts sorted response times per bucket, bounds in milliseconds
+func histogram(sortedDurations []time.Duration, bounds []int) []HistogramBucket {
+	buckets := make([]HistogramBucket, len(bounds)+1)
+	for i, bound := range bounds {
+		buckets[i].UpperBoundMs = bound
+	}
+
+	i := 0
+	for _, d := range sortedDurations {
+		for i < len(bounds) && d > time.Duration(bounds[i])*time.Millisecond {
+			i++
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+func (calc *AdvancedMetricsCalculator) phasePercentiles(durations []time.Duration) PhasePercentiles {
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	return PhasePercentiles{
+		P50: calc.percentile(durations, 50),
+		P90: calc.percentile(durations, 90),
+		P95: calc.percentile(durations, 95),
+		P99: calc.percentile(durations, 99),
+	}
+}
+
 // standardDeviation calculates standard deviation of response times
 func (calc *AdvancedMetricsCalculator) standardDeviation(durations []time.Duration) time.Duration {
 	if len(durations) == 0 {
@@ -479,6 +618,26 @@ func (calc *AdvancedMetricsCalculator) calculateErrorBreakdown(errorCounts map[s
 func (calc *AdvancedMetricsCalculator) classifyErrorPattern(errorMsg string) string {
 	errorMsg = strings.ToLower(errorMsg)
 
+	// Configured categories replace the built-in ones; they are tried in
+	// name order so the classification is stable
+	if calc.config != nil && len(calc.config.Performance.ErrorClassification) > 0 {
+		classification := calc.config.Performance.ErrorClassification
+		categories := make([]string, 0, len(classification))
+		for category := range classification {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		for _, category := range categories {
+			for _, pattern := range classification[category] {
+				if strings.Contains(errorMsg, strings.ToLower(pattern)) {
+					return category
+				}
+			}
+		}
+		return "Other"
+	}
+
 	if strings.Contains(errorMsg, "timeout") || strings.Contains(errorMsg, "deadline") {
 		return "Timeout"
 	} else if strings.Contains(errorMsg, "connection") || strings.Contains(errorMsg, "network") {
@@ -500,17 +659,9 @@ func (calc *AdvancedMetricsCalculator) classifyErrorPattern(errorMsg string) str
 
 // calculateSLACompliance calculates compliance against standard SLA targets
 func (calc *AdvancedMetricsCalculator) calculateSLACompliance(actualUptime float64) map[string]SLAResult {
-	slaTargets := map[string]float64{
-		"99.9% (8.77h downtime/month)":    99.9,
-		"99.95% (4.38h downtime/month)":   99.95,
-		"99.99% (52.6min downtime/month)": 99.99,
-		"99.5% (3.65d downtime/month)":    99.5,
-		"95% (36.5h downtime/month)":      95.0,
-	}
-
 	compliance := make(map[string]SLAResult)
 
-	for name, target := range slaTargets {
+	for name, target := range calc.slaTargets {
 		violation := time.Duration(0)
 		if actualUptime < target {
 			// Rough estimation of violation time based on a 30-day month
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"errors"
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"site-monitor/storage"
+	"testing"
+	"time"
+)
+
+// historyStorage serves a fixed history and records the requested window
+type historyStorage struct {
+	history []storage.HistoryEntry
+	since   time.Time
+}
+
+func (s *historyStorage) SaveResult(result monitor.Result) error { return nil }
+func (s *historyStorage) GetHistory(siteName string, since time.Time) ([]storage.HistoryEntry, error) {
+	s.since = since
+	return s.history, nil
+}
+func (s *historyStorage) GetAllHistory(since time.Time) ([]storage.HistoryEntry, error) {
+	return s.history, nil
+}
+func (s *historyStorage) GetStats(siteName string, since time.Time) (storage.Stats, error) {
+	return storage.Stats{}, nil
+}
+func (s *historyStorage) GetAllStats(since time.Time) (map[string]storage.Stats, error) {
+	return nil, nil
+}
+func (s *historyStorage) Close() error { return nil }
+func (s *historyStorage) Init() error  { return nil }
+
+func TestAdvancedMetrics_Config(t *testing.T) {
+	now := time.Now()
+	store := &historyStorage{}
+	// Most recent first, as storage returns it
+	for i, ms := range []int{50, 150, 450, 900, 2000} {
+		store.history = append(store.history, storage.HistoryEntry{
+			Success:   true,
+			Duration:  time.Duration(ms) * time.Millisecond,
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	store.history = append(store.history, storage.HistoryEntry{
+		Error:     "Get https://example.com: dial tcp: lookup example.com: no such host",
+		Timestamp: now.Add(-10 * time.Minute),
+	})
+
+	calc := NewAdvancedMetricsCalculator(store)
+	err := calc.SetConfig(config.MetricsConfig{
+		Advanced: config.AdvancedMetricsConfig{
+			Enabled:       true,
+			Percentiles:   []float64{50, 99.9},
+			RetentionDays: 7,
+			SLATargets:    map[string]string{"99.9": "Gold"},
+		},
+		Performance: config.PerformanceMetricsConfig{
+			ResponseTimeBuckets: []int{100, 500, 1000},
+			ErrorClassification: map[string][]string{"dns": {"no such host"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+
+	metrics, err := calc.CalculateAdvancedMetrics("example", now.AddDate(0, 0, -30), "30d")
+	if err != nil {
+		t.Fatalf("CalculateAdvancedMetrics failed: %v", err)
+	}
+
+	if store.since.Before(now.AddDate(0, 0, -8)) {
+		t.Errorf("Expected the window to be clamped to 7 days, got %v", store.since)
+	}
+	if len(metrics.Percentiles) != 2 || metrics.Percentiles["p50"] != 450*time.Millisecond {
+		t.Errorf("Unexpected percentiles %v", metrics.Percentiles)
+	}
+
+	expected := []HistogramBucket{{100, 1}, {500, 2}, {1000, 1}, {0, 1}}
+	if len(metrics.ResponseTimeHistogram) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, metrics.ResponseTimeHistogram)
+	}
+	for i := range expected {
+		if metrics.ResponseTimeHistogram[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, metrics.ResponseTimeHistogram)
+		}
+	}
+
+	if metrics.ResponseTimeTrend != TrendUnknown {
+		t.Errorf("Expected no trend analysis, got %s", metrics.ResponseTimeTrend)
+	}
+	if _, ok := metrics.SLACompliance["Gold"]; !ok || len(metrics.SLACompliance) != 1 {
+		t.Errorf("Expected only the configured SLA target, got %v", metrics.SLACompliance)
+	}
+	for _, stats := range metrics.ErrorBreakdown {
+		if stats.Pattern != "dns" {
+			t.Errorf("Expected the configured error category, got %s", stats.Pattern)
+		}
+	}
+
+	calc.SetConfig(config.MetricsConfig{})
+	if _, err := calc.CalculateAdvancedMetrics("example", now.AddDate(0, 0, -1), "1d"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Expected ErrDisabled, got %v", err)
+	}
+}
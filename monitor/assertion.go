@@ -0,0 +1,309 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"site-monitor/config"
+	"strconv"
+	"strings"
+)
+
+// maxBodyBytes bounds how much of a response body is kept for assertions.
+// Longer bodies are still counted in full for size assertions.
+const maxBodyBytes = 10 << 20
+
+// AssertionResult records the outcome of a single response assertion
+type AssertionResult struct {
+	Type    string `json:"type"`
+	Target  string `json:"target"` // What was asserted, e.g. `$.status eq ok`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"` // Why the assertion failed
+}
+
+// assertion is a validated, ready-to-evaluate assertion
+type assertion struct {
+	config.AssertionConfig
+	regex *regexp.Regexp
+	path  []pathSegment
+}
+
+// pathSegment is one step of a JSONPath: an object key or an array index
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// compileAssertions validates assertion configs and prepares them for evaluation
+func compileAssertions(configs []config.AssertionConfig) ([]assertion, error) {
+	assertions := make([]assertion, 0, len(configs))
+
+	for i, cfg := range configs {
+		a := assertion{AssertionConfig: cfg}
+
+		switch cfg.Type {
+		case "contains", "not_contains":
+			if cfg.Value == "" {
+				return nil, fmt.Errorf("assertion %d (%s): value is required", i, cfg.Type)
+			}
+		case "regex":
+			re, err := regexp.Compile(cfg.Value)
+			if err != nil {
+				return nil, fmt.Errorf("assertion %d (regex): %w", i, err)
+			}
+			a.regex = re
+		case "json_path":
+			path, err := parseJSONPath(cfg.Path)
+			if err != nil {
+				return nil, fmt.Errorf("assertion %d (json_path): %w", i, err)
+			}
+			a.path = path
+			if a.Operator == "" {
+				a.Operator = "eq"
+			}
+			switch a.Operator {
+			case "eq", "ne":
+			case "gt", "gte", "lt", "lte":
+				if _, err := strconv.ParseFloat(cfg.Value, 64); err != nil {
+					return nil, fmt.Errorf("assertion %d (json_path): operator %s needs a numeric value, got %q", i, a.Operator, cfg.Value)
+				}
+			default:
+				return nil, fmt.Errorf("assertion %d (json_path): unknown operator %q (expected eq, ne, gt, gte, lt or lte)", i, a.Operator)
+			}
+		case "size":
+			if cfg.Min < 0 || cfg.Max < 0 {
+				return nil, fmt.Errorf("assertion %d (size): min and max must not be negative", i)
+			}
+			if cfg.Min == 0 && cfg.Max == 0 {
+				return nil, fmt.Errorf("assertion %d (size): min or max is required", i)
+			}
+			if cfg.Max > 0 && cfg.Min > cfg.Max {
+				return nil, fmt.Errorf("assertion %d (size): min %d is greater than max %d", i, cfg.Min, cfg.Max)
+			}
+		default:
+			return nil, fmt.Errorf("assertion %d: unknown type %q (expected contains, not_contains, regex, json_path or size)", i, cfg.Type)
+		}
+
+		assertions = append(assertions, a)
+	}
+
+	return assertions, nil
+}
+
+// evaluateAssertions runs every assertion against body and returns the
+// individual outcomes along with the first failure message, if any
+func evaluateAssertions(assertions []assertion, body []byte, size int64) ([]AssertionResult, string) {
+	results := make([]AssertionResult, 0, len(assertions))
+	failure := ""
+
+	for _, a := range assertions {
+		result := a.evaluate(body, size)
+		if !result.Passed && failure == "" {
+			failure = result.Message
+		}
+		results = append(results, result)
+	}
+
+	return results, failure
+}
+
+// evaluate checks a single assertion against the response body, size being
+// the length of the whole body of which at most maxBodyBytes were kept
+func (a assertion) evaluate(body []byte, size int64) AssertionResult {
+	result := AssertionResult{Type: a.Type, Target: a.target(), Passed: true}
+
+	fail := func(format string, args ...interface{}) AssertionResult {
+		result.Passed = false
+		result.Message = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	switch a.Type {
+	case "contains":
+		if !strings.Contains(string(body), a.Value) {
+			return fail("body does not contain %q", a.Value)
+		}
+	case "not_contains":
+		if strings.Contains(string(body), a.Value) {
+			return fail("body contains %q", a.Value)
+		}
+	case "regex":
+		if !a.regex.Match(body) {
+			return fail("body does not match /%s/", a.Value)
+		}
+	case "json_path":
+		var document interface{}
+		if err := json.Unmarshal(body, &document); err != nil {
+			return fail("body is not valid JSON: %v", err)
+		}
+		actual, ok := lookupJSONPath(document, a.path)
+		if !ok {
+			return fail("%s not found", a.Path)
+		}
+		if passed, err := compareJSON(actual, a.Operator, a.Value); err != nil {
+			return fail("%s: %v", a.Path, err)
+		} else if !passed {
+			return fail("%s is %s, expected %s %s", a.Path, formatJSON(actual), a.Operator, a.Value)
+		}
+	case "size":
+		if size < a.Min {
+			return fail("body size %d bytes is below minimum %d", size, a.Min)
+		}
+		if a.Max > 0 && size > a.Max {
+			return fail("body size %d bytes exceeds maximum %d", size, a.Max)
+		}
+	}
+
+	return result
+}
+
+// target describes what the assertion checks, for display and storage
+func (a assertion) target() string {
+	switch a.Type {
+	case "json_path":
+		return fmt.Sprintf("%s %s %s", a.Path, a.Operator, a.Value)
+	case "size":
+		if a.Max > 0 {
+			return fmt.Sprintf("%d-%d bytes", a.Min, a.Max)
+		}
+		return fmt.Sprintf(">= %d bytes", a.Min)
+	default:
+		return a.Value
+	}
+}
+
+// parseJSONPath parses a simple JSONPath such as $.data.items[0]['id'].
+// Only child keys and array indexes are supported.
+func parseJSONPath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	rest := strings.TrimPrefix(path, "$")
+	var segments []pathSegment
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty key", path)
+			}
+			segments = append(segments, pathSegment{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, pathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path %q: bad index %q", path, inner)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+		default:
+			// Allow a leading key without "$." (e.g. "status")
+			if len(segments) == 0 && !strings.HasPrefix(path, "$") {
+				rest = "." + rest
+				continue
+			}
+			return nil, fmt.Errorf("invalid path %q: unexpected %q", path, rest[0])
+		}
+	}
+
+	return segments, nil
+}
+
+// lookupJSONPath walks a decoded JSON document along path
+func lookupJSONPath(document interface{}, path []pathSegment) (interface{}, bool) {
+	current := document
+	for _, segment := range path {
+		if segment.isIndex {
+			array, ok := current.([]interface{})
+			if !ok || segment.index >= len(array) {
+				return nil, false
+			}
+			current = array[segment.index]
+			continue
+		}
+
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[segment.key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// compareJSON compares a decoded JSON value with the configured expected value.
+// The expected value is parsed as JSON when possible and as a plain string otherwise.
+func compareJSON(actual interface{}, operator, expected string) (bool, error) {
+	switch operator {
+	case "eq", "ne":
+		equal := jsonEqual(actual, expected)
+		if operator == "ne" {
+			return !equal, nil
+		}
+		return equal, nil
+	}
+
+	number, ok := actual.(float64)
+	if !ok {
+		return false, fmt.Errorf("%s is not a number", formatJSON(actual))
+	}
+	limit, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false, fmt.Errorf("expected value %q is not a number", expected)
+	}
+
+	switch operator {
+	case "gt":
+		return number > limit, nil
+	case "gte":
+		return number >= limit, nil
+	case "lt":
+		return number < limit, nil
+	case "lte":
+		return number <= limit, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", operator)
+}
+
+// jsonEqual reports whether actual equals expected, accepting either a JSON
+// literal ("200", "true", "null") or a bare string ("ok")
+func jsonEqual(actual interface{}, expected string) bool {
+	if s, ok := actual.(string); ok && s == expected {
+		return true
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(expected), &parsed); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(actual, parsed)
+}
+
+// formatJSON renders a decoded JSON value for error messages
+func formatJSON(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
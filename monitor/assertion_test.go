@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"site-monitor/config"
+	"strings"
+	"testing"
+)
+
+func TestAssertions_Evaluate(t *testing.T) {
+	body := []byte(`{"status":"ok","version":"2.1","db":{"latency_ms":42,"replicas":[{"id":"a"}]},"ready":true}`)
+
+	tests := []struct {
+		name      string
+		assertion config.AssertionConfig
+		passed    bool
+	}{
+		{"contains", config.AssertionConfig{Type: "contains", Value: `"status":"ok"`}, true},
+		{"contains missing", config.AssertionConfig{Type: "contains", Value: "Welcome"}, false},
+		{"not contains", config.AssertionConfig{Type: "not_contains", Value: "Database error"}, true},
+		{"not contains present", config.AssertionConfig{Type: "not_contains", Value: "latency"}, false},
+		{"regex", config.AssertionConfig{Type: "regex", Value: `"version":"2\.\d+"`}, true},
+		{"regex no match", config.AssertionConfig{Type: "regex", Value: `^<html`}, false},
+		{"json eq string", config.AssertionConfig{Type: "json_path", Path: "$.status", Value: "ok"}, true},
+		{"json eq bool", config.AssertionConfig{Type: "json_path", Path: "$.ready", Value: "true"}, true},
+		{"json ne", config.AssertionConfig{Type: "json_path", Path: "$.status", Operator: "ne", Value: "down"}, true},
+		{"json nested index", config.AssertionConfig{Type: "json_path", Path: "$.db.replicas[0]['id']", Value: "a"}, true},
+		{"json lt", config.AssertionConfig{Type: "json_path", Path: "$.db.latency_ms", Operator: "lt", Value: "100"}, true},
+		{"json gt fails", config.AssertionConfig{Type: "json_path", Path: "$.db.latency_ms", Operator: "gt", Value: "100"}, false},
+		{"json missing path", config.AssertionConfig{Type: "json_path", Path: "$.db.missing", Value: "x"}, false},
+		{"json gt on string", config.AssertionConfig{Type: "json_path", Path: "$.status", Operator: "gte", Value: "1"}, false},
+		{"size within", config.AssertionConfig{Type: "size", Min: 10, Max: 1000}, true},
+		{"size too small", config.AssertionConfig{Type: "size", Min: 1000}, false},
+		{"size too large", config.AssertionConfig{Type: "size", Max: 10}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertions, err := compileAssertions([]config.AssertionConfig{tt.assertion})
+			if err != nil {
+				t.Fatalf("compileAssertions failed: %v", err)
+			}
+
+			results, failure := evaluateAssertions(assertions, body, int64(len(body)))
+			if results[0].Passed != tt.passed {
+				t.Errorf("Expected passed=%v, got %+v", tt.passed, results[0])
+			}
+			if tt.passed != (failure == "") {
+				t.Errorf("Unexpected failure message %q", failure)
+			}
+		})
+	}
+}
+
+func TestAssertions_InvalidConfig(t *testing.T) {
+	invalid := []config.AssertionConfig{
+		{Type: "unknown"},
+		{Type: "contains"},
+		{Type: "regex", Value: "("},
+		{Type: "json_path", Value: "ok"},
+		{Type: "json_path", Path: "$.a[x]", Value: "ok"},
+		{Type: "json_path", Path: "$.a", Operator: "like", Value: "ok"},
+		{Type: "json_path", Path: "$.a", Operator: "gt", Value: "ok"},
+		{Type: "size"},
+		{Type: "size", Min: 10, Max: 5},
+	}
+
+	for _, cfg := range invalid {
+		if _, err := compileAssertions([]config.AssertionConfig{cfg}); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestHTTPChecker_AssertionFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>Database error</html>"))
+	}))
+	defer server.Close()
+
+	checker, err := NewChecker(config.Site{
+		URL:     server.URL,
+		Timeout: "5s",
+		Assertions: []config.AssertionConfig{
+			{Type: "contains", Value: "<html>"},
+			{Type: "not_contains", Value: "Database error"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewChecker failed: %v", err)
+	}
+
+	result := checker.Check(context.Background())
+	if result.Success {
+		t.Fatal("Expected check to fail on assertion")
+	}
+	if !strings.Contains(result.Error, `body contains "Database error"`) {
+		t.Errorf("Expected failing assertion in error, got %q", result.Error)
+	}
+	if len(result.Assertions) != 2 || !result.Assertions[0].Passed || result.Assertions[1].Passed {
+		t.Errorf("Unexpected assertion results: %+v", result.Assertions)
+	}
+}
+
+func TestHTTPChecker_SizeBeyondBodyLimit(t *testing.T) {
+	const size = maxBodyBytes + 1<<20
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), size))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		assertion config.AssertionConfig
+		success   bool
+	}{
+		{"min above the limit", config.AssertionConfig{Type: "size", Min: maxBodyBytes + 1}, true},
+		{"max at the limit", config.AssertionConfig{Type: "size", Max: maxBodyBytes}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewChecker(config.Site{URL: server.URL, Timeout: "5s", Assertions: []config.AssertionConfig{tt.assertion}})
+			if err != nil {
+				t.Fatalf("NewChecker failed: %v", err)
+			}
+			if result := checker.Check(context.Background()); result.Success != tt.success {
+				t.Errorf("Expected success %v for a %d byte body, got %+v", tt.success, size, result.Assertions)
+			}
+		})
+	}
+}
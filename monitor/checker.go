@@ -1,34 +1,150 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
-	"net/http"
+	"site-monitor/config"
+	"sort"
+	"sync"
 	"time"
 )
 
-// Storage interface to avoid circular imports
-type Storage interface {
-	SaveResult(result Result) error
+// Checker performs a single probe and reports its outcome
+type Checker interface {
+	Check(ctx context.Context) Result
 }
 
+// Factory builds a Checker from a site configuration
+type Factory func(site config.Site) (Checker, error)
+
+// DefaultType is the check type used when a site does not set one
+const DefaultType = "http"
+
+var (
+	registry   = make(map[string]Factory)
+	registryMu sync.RWMutex
+)
+
+// Register makes a check type available under the given name.
+// It is intended to be called from init functions.
+func Register(checkType string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[checkType]; exists {
+		panic(fmt.Sprintf("monitor: check type %q registered twice", checkType))
+	}
+	registry[checkType] = factory
+}
+
+// NewChecker builds the Checker registered for the site's type. A site
+// pinned to an address family gets results labeled with it, and
+// ip_version "both" probes each family with its own checker.
+func NewChecker(site config.Site) (Checker, error) {
+	families := site.GetIPVersions()
+	if len(families) == 0 {
+		return newChecker(site)
+	}
+
+	checkers := make([]Checker, 0, len(families))
+	for _, family := range families {
+		familySite := site
+		familySite.IPVersion = family
+		checker, err := newChecker(familySite)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, &familyChecker{family: family, checker: checker})
+	}
+	if len(checkers) == 1 {
+		return checkers[0], nil
+	}
+	return &dualStackChecker{checkers: checkers}, nil
+}
+
+// newChecker builds the Checker registered for the site's type
+func newChecker(site config.Site) (Checker, error) {
+	checkType := site.Type
+	if checkType == "" {
+		checkType = DefaultType
+	}
+
+	registryMu.RLock()
+	factory, exists := registry[checkType]
+	registryMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown check type %q (available: %v)", checkType, Types())
+	}
+
+	return factory(site)
+}
+
+// Types returns the names of all registered check types
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for checkType := range registry {
+		types = append(types, checkType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Monitor describes a single check: what to probe and how often.
+// Scheduling is owned by the scheduler package.
 type Monitor struct {
-	Name     string // Display name for the monitor
-	URL      string
-	Interval time.Duration
-	client   *http.Client
-	storage  Storage // Storage for persisting results
+	Name       string // Display name for the monitor
+	URL        string
+	Interval   time.Duration
+	Retries    int           // Extra attempts before a check fails
+	RetryDelay time.Duration // Wait between attempts
+	Confirm    bool          // Failures are confirmed by an immediate second check
+	checker    Checker
 }
 
-// New creates a new monitor instance
+// New creates a new monitor instance using the default HTTP check
 func New(url string, interval time.Duration) *Monitor {
 	return &Monitor{
 		Name:     url, // Default name is URL
 		URL:      url,
 		Interval: interval,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		checker:  NewHTTPChecker(url, 10*time.Second),
+	}
+}
+
+// NewFromSite creates a monitor for a configured site, using the checker
+// registered for the site's type
+func NewFromSite(site config.Site) (*Monitor, error) {
+	interval, err := site.GetInterval()
+	if err != nil {
+		return nil, fmt.Errorf("invalid interval: %w", err)
+	}
+
+	retryDelay, err := site.GetRetryDelay()
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry_delay: %w", err)
+	}
+	if site.Retries < 0 {
+		return nil, fmt.Errorf("retries must not be negative, got %d", site.Retries)
+	}
+
+	checker, err := NewChecker(site)
+	if err != nil {
+		return nil, err
 	}
+
+	return &Monitor{
+		Name:       site.Name,
+		URL:        site.URL,
+		Interval:   interval,
+		Retries:    site.Retries,
+		RetryDelay: retryDelay,
+		Confirm:    site.Confirm,
+		checker:    checker,
+	}, nil
 }
 
 // SetName sets a custom name for the monitor
@@ -38,67 +154,57 @@ func (m *Monitor) SetName(name string) {
 
 // SetTimeout sets custom timeout for HTTP requests
 func (m *Monitor) SetTimeout(timeout time.Duration) {
-	m.client.Timeout = timeout
+	if hc, ok := m.checker.(*HTTPChecker); ok {
+		hc.SetTimeout(timeout)
+	}
 }
 
-// SetStorage sets the storage backend for persisting results
-func (m *Monitor) SetStorage(storage Storage) {
-	m.storage = storage
+// SetChecker replaces the checker used by the monitor
+func (m *Monitor) SetChecker(checker Checker) {
+	m.checker = checker
 }
 
-// Start begins the monitoring loop
-func (m *Monitor) Start() error {
-	ticker := time.NewTicker(m.Interval)
-	defer ticker.Stop()
-
-	// First check immediately
-	result := m.check()
-	fmt.Println(result)
-	m.saveResult(result)
-
-	// Use for range instead of for { select {} }
-	for range ticker.C {
-		result := m.check()
-		fmt.Println(result)
-		m.saveResult(result)
-	}
-
-	return nil // This will never be reached, but satisfies the function signature
+// SetRetries sets how many extra attempts a failing check makes and the wait between them
+func (m *Monitor) SetRetries(retries int, delay time.Duration) {
+	m.Retries = retries
+	m.RetryDelay = delay
 }
 
-// check performs a single HTTP check
-func (m *Monitor) check() Result {
-	start := time.Now()
-
-	resp, err := m.client.Get(m.URL)
-	duration := time.Since(start)
-
-	result := Result{
-		Name:      m.Name, // Include monitor name
-		URL:       m.URL,
-		Duration:  duration,
-		Timestamp: time.Now(),
-	}
+// Check runs the monitor's checker, retrying failures up to Retries times,
+// and labels the result with the monitor name
+func (m *Monitor) Check(ctx context.Context) Result {
+	var result Result
+	for attempt := 1; ; attempt++ {
+		result = m.checker.Check(ctx)
+		result.Attempts = attempt
+		if result.Success || attempt > m.Retries {
+			break
+		}
 
-	if err != nil {
-		result.Success = false
-		result.Error = err.Error()
-		return result
+		timer := time.NewTimer(m.RetryDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			break
+		}
 	}
-	defer resp.Body.Close()
 
-	result.Status = resp.StatusCode
-	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 400
-
-	return result
-}
-
-// saveResult saves the result to storage if available
-func (m *Monitor) saveResult(result Result) {
-	if m.storage != nil {
-		if err := m.storage.SaveResult(result); err != nil {
-			// Log error but don't fail the monitoring
-			fmt.Printf("⚠️ Failed to save result for %s: %v\n", m.Name, err)
+	label := func(result *Result) {
+		result.Name = m.Name
+		if result.URL == "" {
+			result.URL = m.URL
 		}
+		if result.Timestamp.IsZero() {
+			result.Timestamp = time.Now()
+		}
+	}
+	label(&result)
+	for i := range result.Families {
+		label(&result.Families[i])
+		result.Families[i].Attempts = result.Attempts
 	}
+	return result
 }
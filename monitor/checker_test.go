@@ -0,0 +1,300 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"site-monitor/config"
+	"testing"
+	"time"
+)
+
+// staticChecker always returns the same result
+type staticChecker struct {
+	result Result
+}
+
+func (c staticChecker) Check(ctx context.Context) Result {
+	return c.result
+}
+
+func TestNewChecker_DefaultsToHTTP(t *testing.T) {
+	checker, err := NewChecker(config.Site{Name: "site", URL: "https://example.com", Timeout: "5s"})
+	if err != nil {
+		t.Fatalf("NewChecker failed: %v", err)
+	}
+	if _, ok := checker.(*HTTPChecker); !ok {
+		t.Errorf("Expected *HTTPChecker, got %T", checker)
+	}
+}
+
+func TestNewChecker_DefaultTimeout(t *testing.T) {
+	sites := []config.Site{
+		{Name: "http", URL: "https://example.com"},
+		{Name: "tcp", Type: "tcp", URL: "localhost:5432"},
+		{Name: "dns", Type: "dns", URL: "example.com"},
+		{Name: "grpc", Type: "grpc", URL: "localhost:50051"},
+		{Name: "websocket", Type: "websocket", URL: "wss://example.com/ws"},
+		{Name: "smtp", Type: "smtp", URL: "smtp://mail.example.com:25"},
+	}
+	for _, site := range sites {
+		if _, err := NewChecker(site); err != nil {
+			t.Errorf("Expected %s to build without a timeout, got %v", site.Name, err)
+		}
+	}
+
+	checker, err := NewChecker(config.Site{Name: "tcp", Type: "tcp", URL: "localhost:5432"})
+	if err != nil {
+		t.Fatalf("NewChecker failed: %v", err)
+	}
+	if timeout := checker.(*TCPChecker).Timeout; timeout != config.DefaultTimeout {
+		t.Errorf("Expected the default timeout, got %v", timeout)
+	}
+}
+
+func TestNewChecker_UnknownType(t *testing.T) {
+	if _, err := NewChecker(config.Site{Name: "site", Type: "carrier-pigeon"}); err == nil {
+		t.Error("Expected error for unknown check type")
+	}
+}
+
+func TestRegister_CustomType(t *testing.T) {
+	Register("static-test", func(site config.Site) (Checker, error) {
+		return staticChecker{result: Result{Success: true, Status: 42}}, nil
+	})
+
+	m, err := NewFromSite(config.Site{Name: "custom", Type: "static-test", Interval: "1m"})
+	if err != nil {
+		t.Fatalf("NewFromSite failed: %v", err)
+	}
+
+	result := m.Check(context.Background())
+	if result.Name != "custom" {
+		t.Errorf("Expected result to be labelled with monitor name, got %q", result.Name)
+	}
+	if !result.Success || result.Status != 42 {
+		t.Errorf("Expected result from custom checker, got %+v", result)
+	}
+	if result.Timestamp.IsZero() {
+		t.Error("Expected timestamp to be filled in")
+	}
+}
+
+// flakyChecker fails until it has been called failures times
+type flakyChecker struct {
+	failures int
+	calls    int
+}
+
+func (c *flakyChecker) Check(ctx context.Context) Result {
+	c.calls++
+	if c.calls <= c.failures {
+		return Result{Success: false, Error: "flaky"}
+	}
+	return Result{Success: true, Status: 200}
+}
+
+func TestMonitor_Retries(t *testing.T) {
+	t.Run("recovers", func(t *testing.T) {
+		m := New("http://example.invalid", time.Minute)
+		m.SetChecker(&flakyChecker{failures: 2})
+		m.SetRetries(2, time.Millisecond)
+
+		result := m.Check(context.Background())
+		if !result.Success || result.Attempts != 3 {
+			t.Errorf("Expected success on the third attempt, got success=%v attempts=%d", result.Success, result.Attempts)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		checker := &flakyChecker{failures: 5}
+		m := New("http://example.invalid", time.Minute)
+		m.SetChecker(checker)
+		m.SetRetries(1, time.Millisecond)
+
+		result := m.Check(context.Background())
+		if result.Success || result.Attempts != 2 || checker.calls != 2 {
+			t.Errorf("Expected failure after 2 attempts, got success=%v attempts=%d calls=%d", result.Success, result.Attempts, checker.calls)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		checker := &flakyChecker{failures: 5}
+		m := New("http://example.invalid", time.Minute)
+		m.SetChecker(checker)
+		m.SetRetries(3, time.Hour)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if result := m.Check(ctx); result.Success || checker.calls != 1 {
+			t.Errorf("Expected retries to stop on cancellation, got %d calls", checker.calls)
+		}
+	})
+}
+
+func TestNewFromSite_RetryConfig(t *testing.T) {
+	m, err := NewFromSite(config.Site{Name: "site", URL: "https://example.com", Interval: "1m", Timeout: "5s", Retries: 2, RetryDelay: "250ms", Confirm: true})
+	if err != nil {
+		t.Fatalf("NewFromSite failed: %v", err)
+	}
+	if m.Retries != 2 || m.RetryDelay != 250*time.Millisecond || !m.Confirm {
+		t.Errorf("Unexpected retry settings: %+v", m)
+	}
+
+	if _, err := NewFromSite(config.Site{Name: "site", URL: "https://example.com", Interval: "1m", Timeout: "5s", RetryDelay: "soon"}); err == nil {
+		t.Error("Expected an error for an invalid retry_delay")
+	}
+	if _, err := NewFromSite(config.Site{Name: "site", URL: "https://example.com", Interval: "1m", Timeout: "5s", Retries: -1}); err == nil {
+		t.Error("Expected an error for negative retries")
+	}
+}
+
+func TestHTTPChecker_Check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ok := NewHTTPChecker(server.URL, 5*time.Second).Check(context.Background())
+	if !ok.Success || ok.Status != http.StatusOK {
+		t.Errorf("Expected successful check, got %+v", ok)
+	}
+
+	down := NewHTTPChecker(server.URL+"/down", 5*time.Second).Check(context.Background())
+	if down.Success || down.Status != http.StatusServiceUnavailable {
+		t.Errorf("Expected failed check with 503, got %+v", down)
+	}
+}
+
+func TestHTTPChecker_RequestOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Env") != "prod" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	checker, err := NewChecker(config.Site{
+		URL:                 server.URL,
+		Timeout:             "5s",
+		Method:              "post",
+		Headers:             map[string]string{"X-Env": "prod"},
+		Body:                `{"ping":true}`,
+		Auth:                &config.AuthConfig{Type: "bearer", Token: "secret"},
+		ExpectedStatusCodes: []int{201},
+	})
+	if err != nil {
+		t.Fatalf("NewChecker failed: %v", err)
+	}
+
+	result := checker.Check(context.Background())
+	if !result.Success || result.Status != http.StatusCreated {
+		t.Errorf("Expected 201 to be accepted, got %+v", result)
+	}
+}
+
+func TestHTTPChecker_RedirectPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/final":
+			w.WriteHeader(http.StatusOK)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			http.Redirect(w, r, "/final", http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+
+	noFollow := false
+	tests := []struct {
+		name    string
+		site    config.Site
+		status  int
+		success bool
+	}{
+		{"follows by default", config.Site{URL: server.URL + "/start", Timeout: "5s"}, 200, true},
+		{"no follow accepts 3xx by default", config.Site{URL: server.URL + "/start", Timeout: "5s", FollowRedirects: &noFollow}, 301, true},
+		{"no follow with strict codes", config.Site{URL: server.URL + "/start", Timeout: "5s", FollowRedirects: &noFollow, ExpectedStatusCodes: []int{200}}, 301, false},
+		{"too many hops", config.Site{URL: server.URL + "/loop", Timeout: "5s", MaxRedirects: 3}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewChecker(tt.site)
+			if err != nil {
+				t.Fatalf("NewChecker failed: %v", err)
+			}
+
+			result := checker.Check(context.Background())
+			if result.Success != tt.success || result.Status != tt.status {
+				t.Errorf("Expected success=%v status=%d, got success=%v status=%d (%s)",
+					tt.success, tt.status, result.Success, result.Status, result.Error)
+			}
+		})
+	}
+}
+
+func TestHTTPChecker_Timings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	checker := NewHTTPChecker(server.URL, 5*time.Second)
+	checker.client.Transport = server.Client().Transport
+
+	result := checker.Check(context.Background())
+	if !result.Success {
+		t.Fatalf("Expected success, got %+v", result)
+	}
+
+	timings := result.Timings
+	if timings.Connect <= 0 || timings.TLS <= 0 {
+		t.Errorf("Expected connect and TLS phases to be recorded, got %+v", timings)
+	}
+	if timings.TTFB < 20*time.Millisecond {
+		t.Errorf("Expected TTFB to include server processing time, got %v", timings.TTFB)
+	}
+	if total := timings.DNS + timings.Connect + timings.TLS + timings.TTFB + timings.Transfer; total > result.Duration {
+		t.Errorf("Phases (%v) exceed total duration (%v)", total, result.Duration)
+	}
+}
+
+func TestHTTPChecker_CertificateExpiry(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	leaf := server.Certificate()
+	days := int(time.Until(leaf.NotAfter).Hours() / 24)
+
+	for _, warnDays := range []int{30, days + 1} {
+		checker := NewHTTPChecker(server.URL, 5*time.Second)
+		checker.client.Transport = server.Client().Transport
+		checker.SSLWarnDays = warnDays
+
+		result := checker.Check(context.Background())
+		if !result.Success {
+			t.Fatalf("Expected success, got %+v", result)
+		}
+		if len(result.Metrics) != 1 || result.Metrics[0].Label != CertificateDaysMetric || int(result.Metrics[0].Value) != days {
+			t.Errorf("Expected %d days left to be reported, got %+v", days, result.Metrics)
+		}
+
+		expiring := warnDays > days
+		if (result.GetSeverity() == SeverityWarning) != expiring {
+			t.Errorf("warn at %d days: expected warning=%v, got severity %s (%s)", warnDays, expiring, result.GetSeverity(), result.Error)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"site-monitor/config"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	Register("dns", newDNSCheckerFromSite)
+}
+
+// DNSChecker resolves a name and asserts on the returned records.
+// It remembers the previous answer set so that changes can be alerted on.
+type DNSChecker struct {
+	Name            string // Name to resolve
+	RecordType      string // A, AAAA, CNAME, MX or TXT
+	Resolver        string // host:port of the DNS server, empty for the system resolver
+	ExpectedRecords []string
+	Timeout         time.Duration
+
+	resolver *net.Resolver
+	mu       sync.Mutex
+	previous []string // Last successful answer set, sorted
+}
+
+// dnsRecordTypes lists the supported record types
+var dnsRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "TXT"}
+
+// NewDNSChecker creates a DNS checker querying resolver (host:port, or empty
+// for the system resolver)
+func NewDNSChecker(name, recordType, resolver string, timeout time.Duration) *DNSChecker {
+	c := &DNSChecker{
+		Name:       name,
+		RecordType: strings.ToUpper(recordType),
+		Resolver:   resolver,
+		Timeout:    timeout,
+		resolver:   net.DefaultResolver,
+	}
+
+	if resolver != "" {
+		c.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, resolver)
+			},
+		}
+	}
+
+	return c
+}
+
+// newDNSCheckerFromSite builds a DNS checker from a site configuration
+func newDNSCheckerFromSite(site config.Site) (Checker, error) {
+	timeout, err := site.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimPrefix(site.URL, "dns://")
+	if name == "" || strings.ContainsAny(name, "/:") {
+		return nil, fmt.Errorf("dns check needs a host name, got %q", site.URL)
+	}
+
+	recordType := strings.ToUpper(site.RecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+	supported := false
+	for _, t := range dnsRecordTypes {
+		if recordType == t {
+			supported = true
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("unsupported record type %q (expected one of %v)", site.RecordType, dnsRecordTypes)
+	}
+
+	if site.Resolver != "" {
+		if _, _, err := net.SplitHostPort(site.Resolver); err != nil {
+			return nil, fmt.Errorf("resolver must be in host:port format, got %q", site.Resolver)
+		}
+	}
+
+	c := NewDNSChecker(name, recordType, site.Resolver, timeout)
+	c.ExpectedRecords = site.ExpectedRecords
+	return c, nil
+}
+
+// Check resolves the name, verifies the expected records and reports
+// whether the answer set changed since the previous successful check
+func (c *DNSChecker) Check(ctx context.Context) Result {
+	start := time.Now()
+
+	result := Result{
+		URL:       "dns://" + c.Name,
+		Timestamp: start,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	records, err := c.lookup(ctx)
+	result.Duration = time.Since(start)
+	result.Timings.DNS = result.Duration
+	result.Timestamp = time.Now()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Records = records
+
+	c.mu.Lock()
+	if c.previous != nil && !equalStrings(c.previous, records) {
+		result.Change = fmt.Sprintf("%s %s answer changed from [%s] to [%s]",
+			c.Name, c.RecordType, strings.Join(c.previous, ", "), strings.Join(records, ", "))
+	}
+	c.previous = records
+	c.mu.Unlock()
+
+	if missing := c.missingRecords(records); len(missing) > 0 {
+		result.Error = fmt.Sprintf("missing expected %s records [%s], got [%s]",
+			c.RecordType, strings.Join(missing, ", "), strings.Join(records, ", "))
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// lookup queries the records of the configured type, normalized and sorted
+func (c *DNSChecker) lookup(ctx context.Context) ([]string, error) {
+	var records []string
+
+	switch c.RecordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if c.RecordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := c.resolver.LookupIP(ctx, network, c.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		cname, err := c.resolver.LookupCNAME(ctx, c.Name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, normalizeDNSName(cname))
+	case "MX":
+		mxs, err := c.resolver.LookupMX(ctx, c.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, fmt.Sprintf("%d %s", mx.Pref, normalizeDNSName(mx.Host)))
+		}
+	case "TXT":
+		txts, err := c.resolver.LookupTXT(ctx, c.Name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, txts...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", c.RecordType)
+	}
+
+	sort.Strings(records)
+	return records, nil
+}
+
+// missingRecords returns the expected records absent from the answer.
+// Names are compared case-insensitively without the trailing dot, and an
+// expected MX host matches regardless of its preference.
+func (c *DNSChecker) missingRecords(records []string) []string {
+	var missing []string
+
+	for _, expected := range c.ExpectedRecords {
+		want := expected
+		if c.RecordType != "TXT" {
+			want = normalizeDNSName(expected)
+		}
+
+		found := false
+		for _, record := range records {
+			if record == want || (c.RecordType == "MX" && strings.HasSuffix(record, " "+want)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, expected)
+		}
+	}
+
+	return missing
+}
+
+// normalizeDNSName lowercases a name and strips the trailing dot
+func normalizeDNSName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// equalStrings reports whether two sorted slices hold the same values
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"site-monitor/config"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsStub is an in-process DNS server answering from a static record table
+type dnsStub struct {
+	mu      sync.Mutex
+	records map[dnsKey][]dnsmessage.Resource
+	conn    net.PacketConn
+}
+
+type dnsKey struct {
+	name  string
+	qtype dnsmessage.Type
+}
+
+// startDNSStub starts a UDP DNS server on a random local port
+func startDNSStub(t *testing.T) *dnsStub {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	stub := &dnsStub{records: make(map[dnsKey][]dnsmessage.Resource), conn: conn}
+	go stub.serve()
+	return stub
+}
+
+// set replaces the answers for name and type
+func (s *dnsStub) set(name string, qtype dnsmessage.Type, bodies ...dnsmessage.ResourceBody) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := dnsKey{name: strings.ToLower(name), qtype: qtype}
+	s.records[key] = nil
+	for _, body := range bodies {
+		s.records[key] = append(s.records[key], dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{
+				Name:  dnsmessage.MustNewName(name),
+				Type:  qtype,
+				Class: dnsmessage.ClassINET,
+				TTL:   60,
+			},
+			Body: body,
+		})
+	}
+}
+
+func (s *dnsStub) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		var query dnsmessage.Message
+		if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+			continue
+		}
+		question := query.Questions[0]
+
+		s.mu.Lock()
+		answers, found := s.records[dnsKey{name: strings.ToLower(question.Name.String()), qtype: question.Type}]
+		s.mu.Unlock()
+
+		response := dnsmessage.Message{
+			Header: dnsmessage.Header{
+				ID:            query.ID,
+				Response:      true,
+				Authoritative: true,
+			},
+			Questions: query.Questions,
+			Answers:   answers,
+		}
+		if !found {
+			response.RCode = dnsmessage.RCodeNameError
+		}
+
+		packed, err := response.Pack()
+		if err != nil {
+			continue
+		}
+		s.conn.WriteTo(packed, addr)
+	}
+}
+
+func TestDNSChecker(t *testing.T) {
+	stub := startDNSStub(t)
+	stub.set("app.example.test.", dnsmessage.TypeA,
+		&dnsmessage.AResource{A: [4]byte{192, 0, 2, 10}},
+		&dnsmessage.AResource{A: [4]byte{192, 0, 2, 11}})
+	stub.set("app.example.test.", dnsmessage.TypeAAAA,
+		&dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}})
+	stub.set("example.test.", dnsmessage.TypeMX,
+		&dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.test.")})
+	stub.set("example.test.", dnsmessage.TypeTXT,
+		&dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}})
+	stub.set("www.example.test.", dnsmessage.TypeCNAME,
+		&dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("app.example.test.")})
+
+	resolver := stub.conn.LocalAddr().String()
+
+	tests := []struct {
+		name     string
+		host     string
+		record   string
+		expected []string
+		success  bool
+	}{
+		{"A", "app.example.test", "A", []string{"192.0.2.10", "192.0.2.11"}, true},
+		{"A missing value", "app.example.test", "A", []string{"192.0.2.99"}, false},
+		{"AAAA", "app.example.test", "AAAA", []string{"2001:db8::1"}, true},
+		{"MX host only", "example.test", "MX", []string{"Mail.Example.Test."}, true},
+		{"MX with preference", "example.test", "MX", []string{"20 mail.example.test"}, false},
+		{"TXT", "example.test", "TXT", []string{"v=spf1 -all"}, true},
+		{"CNAME", "www.example.test", "CNAME", []string{"app.example.test"}, true},
+		{"no expectations", "app.example.test", "A", nil, true},
+		{"NXDOMAIN", "missing.example.test", "A", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewChecker(config.Site{
+				Type:            "dns",
+				URL:             tt.host,
+				Timeout:         "2s",
+				Resolver:        resolver,
+				RecordType:      tt.record,
+				ExpectedRecords: tt.expected,
+			})
+			if err != nil {
+				t.Fatalf("NewChecker failed: %v", err)
+			}
+
+			result := checker.Check(context.Background())
+			if result.Success != tt.success {
+				t.Errorf("Expected success=%v, got %+v", tt.success, result)
+			}
+			if result.Timings.DNS <= 0 {
+				t.Errorf("Expected resolution time to be recorded")
+			}
+		})
+	}
+}
+
+func TestDNSChecker_DetectsChanges(t *testing.T) {
+	stub := startDNSStub(t)
+	stub.set("api.example.test.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+
+	checker, err := NewChecker(config.Site{
+		Type:     "dns",
+		URL:      "api.example.test",
+		Timeout:  "2s",
+		Resolver: stub.conn.LocalAddr().String(),
+	})
+	if err != nil {
+		t.Fatalf("NewChecker failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if result := checker.Check(context.Background()); result.Change != "" {
+			t.Fatalf("Check %d: expected no change, got %q", i, result.Change)
+		}
+	}
+
+	stub.set("api.example.test.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{203, 0, 113, 7}})
+
+	result := checker.Check(context.Background())
+	if !strings.Contains(result.Change, "192.0.2.1") || !strings.Contains(result.Change, "203.0.113.7") {
+		t.Errorf("Expected change from old to new answer, got %q", result.Change)
+	}
+	if !result.Success {
+		t.Errorf("A change alone should not fail the check: %+v", result)
+	}
+}
+
+func TestDNSChecker_InvalidConfig(t *testing.T) {
+	invalid := []config.Site{
+		{Type: "dns", URL: "https://example.com", Timeout: "1s"},
+		{Type: "dns", URL: "example.com", Timeout: "1s", RecordType: "SRV"},
+		{Type: "dns", URL: "example.com", Timeout: "1s", Resolver: "1.1.1.1"},
+	}
+
+	for _, site := range invalid {
+		if _, err := NewChecker(site); err == nil {
+			t.Errorf("Expected error for %+v", site)
+		}
+	}
+}
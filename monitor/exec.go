@@ -0,0 +1,282 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"site-monitor/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPluginOutputBytes bounds how much plugin output is kept
+const maxPluginOutputBytes = 64 << 10
+
+func init() {
+	Register("exec", newExecCheckerFromSite)
+}
+
+// Metric is a named performance value, as reported in Nagios perfdata
+type Metric struct {
+	Label string   `json:"label"`
+	Value float64  `json:"value"`
+	Unit  string   `json:"unit,omitempty"`
+	Warn  string   `json:"warn,omitempty"` // Warning range, as reported
+	Crit  string   `json:"crit,omitempty"` // Critical range, as reported
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// Nagios plugin exit codes
+const (
+	pluginOK       = 0
+	pluginWarning  = 1
+	pluginCritical = 2
+	pluginUnknown  = 3
+)
+
+// ExecChecker runs a Nagios-compatible plugin and maps its exit code
+// (0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN) to a result severity
+type ExecChecker struct {
+	Command string
+	Args    []string
+	Timeout time.Duration
+}
+
+// NewExecChecker creates a plugin checker
+func NewExecChecker(command string, args []string, timeout time.Duration) *ExecChecker {
+	return &ExecChecker{
+		Command: command,
+		Args:    args,
+		Timeout: timeout,
+	}
+}
+
+// newExecCheckerFromSite builds a plugin checker from a site configuration
+func newExecCheckerFromSite(site config.Site) (Checker, error) {
+	timeout, err := site.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	if site.Command == "" {
+		return nil, fmt.Errorf("exec check needs a command")
+	}
+
+	return NewExecChecker(site.Command, site.Args, timeout), nil
+}
+
+// Check runs the plugin once and parses its output and performance data
+func (c *ExecChecker) Check(ctx context.Context) Result {
+	start := time.Now()
+
+	result := Result{
+		URL:       "exec://" + c.Command,
+		Timestamp: start,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: maxPluginOutputBytes}
+	stderr := &limitedBuffer{limit: maxPluginOutputBytes}
+	cmd := exec.CommandContext(ctx, c.Command, c.Args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second // Don't hang on children keeping the pipes open
+
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+	result.Timestamp = time.Now()
+
+	output, metrics := parsePluginOutput(stdout.String())
+	result.Metrics = metrics
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		// Like Nagios, a plugin that times out is critical
+		result.Severity = SeverityCritical
+		result.Status = pluginCritical
+		result.Error = fmt.Sprintf("plugin timed out after %v", c.Timeout)
+		return result
+	case err == nil:
+		result.Status = 0
+	case errors.As(err, &exitErr):
+		result.Status = exitErr.ExitCode()
+	default:
+		result.Severity = SeverityUnknown
+		result.Status = pluginUnknown
+		result.Error = fmt.Sprintf("failed to run plugin: %v", err)
+		return result
+	}
+
+	result.Severity = severityFromExitCode(result.Status)
+	result.Success = result.Severity == SeverityOK || result.Severity == SeverityWarning
+
+	if result.Severity != SeverityOK {
+		if output == "" {
+			output = strings.TrimSpace(stderr.String())
+		}
+		if output == "" {
+			output = fmt.Sprintf("plugin exited with code %d", result.Status)
+		}
+		result.Error = output
+	}
+
+	return result
+}
+
+// severityFromExitCode maps a plugin exit code to a severity.
+// Codes outside 0-3 are unknown.
+func severityFromExitCode(code int) Severity {
+	switch code {
+	case pluginOK:
+		return SeverityOK
+	case pluginWarning:
+		return SeverityWarning
+	case pluginCritical:
+		return SeverityCritical
+	default:
+		return SeverityUnknown
+	}
+}
+
+// parsePluginOutput splits plugin output into its status text (the first
+// line) and the performance data found after "|" on any line
+func parsePluginOutput(output string) (string, []Metric) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+
+	var text string
+	var perfdata []string
+	for i, line := range lines {
+		body, perf, hasPerf := strings.Cut(line, "|")
+		if i == 0 {
+			text = strings.TrimSpace(body)
+		}
+		if hasPerf {
+			perfdata = append(perfdata, perf)
+		}
+	}
+
+	return text, parsePerfdata(strings.Join(perfdata, " "))
+}
+
+// parsePerfdata parses 'label'=value[UOM];[warn];[crit];[min];[max] entries.
+// Malformed entries and undetermined ("U") values are skipped.
+func parsePerfdata(perfdata string) []Metric {
+	var metrics []Metric
+
+	rest := strings.TrimSpace(perfdata)
+	for rest != "" {
+		var label string
+		if rest[0] == '\'' {
+			// Quoted labels may contain spaces; '' is an escaped quote
+			end := 1
+			for end < len(rest) {
+				if rest[end] == '\'' {
+					if end+1 < len(rest) && rest[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(rest) {
+				break
+			}
+			label = strings.ReplaceAll(rest[1:end], "''", "'")
+			rest = rest[end+1:]
+		} else {
+			eq := strings.IndexByte(rest, '=')
+			if eq == -1 {
+				break
+			}
+			label = rest[:eq]
+			rest = rest[eq:]
+		}
+
+		if !strings.HasPrefix(rest, "=") {
+			break
+		}
+		rest = rest[1:]
+
+		field := rest
+		if space := strings.IndexAny(rest, " \t"); space != -1 {
+			field = rest[:space]
+			rest = strings.TrimSpace(rest[space:])
+		} else {
+			rest = ""
+		}
+
+		if metric, ok := parsePerfValue(strings.TrimSpace(label), field); ok {
+			metrics = append(metrics, metric)
+		}
+	}
+
+	return metrics
+}
+
+// parsePerfValue parses value[UOM];[warn];[crit];[min];[max]
+func parsePerfValue(label, field string) (Metric, bool) {
+	parts := strings.Split(field, ";")
+
+	number := strings.TrimRightFunc(parts[0], func(r rune) bool {
+		return !(r >= '0' && r <= '9') && r != '.'
+	})
+	value, err := strconv.ParseFloat(number, 64)
+	if label == "" || err != nil {
+		return Metric{}, false
+	}
+
+	metric := Metric{
+		Label: label,
+		Value: value,
+		Unit:  parts[0][len(number):],
+	}
+
+	optional := func(i int) string {
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+	metric.Warn = optional(1)
+	metric.Crit = optional(2)
+	if min, err := strconv.ParseFloat(optional(3), 64); err == nil {
+		metric.Min = &min
+	}
+	if max, err := strconv.ParseFloat(optional(4), 64); err == nil {
+		metric.Max = &max
+	}
+
+	return metric, true
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a plugin flooding its output cannot exhaust memory
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write always reports success so the plugin is never stopped by a broken pipe
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// String returns the kept output
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
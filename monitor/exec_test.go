@@ -0,0 +1,138 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writePlugin writes an executable shell script and returns its path
+func writePlugin(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "check_test.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+	return path
+}
+
+func TestExecChecker_ExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		severity Severity
+		success  bool
+		err      string
+	}{
+		{"ok", "echo 'OK - all good'; exit 0", SeverityOK, true, ""},
+		{"warning", "echo 'WARNING - disk 85%'; exit 1", SeverityWarning, true, "WARNING - disk 85%"},
+		{"critical", "echo 'CRITICAL - disk 99%'; exit 2", SeverityCritical, false, "CRITICAL - disk 99%"},
+		{"unknown", "echo 'UNKNOWN - no data' >&2; exit 3", SeverityUnknown, false, "UNKNOWN - no data"},
+		{"out of range", "exit 7", SeverityUnknown, false, "plugin exited with code 7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewExecChecker(writePlugin(t, tt.script), nil, 5*time.Second)
+			result := checker.Check(context.Background())
+
+			if result.Severity != tt.severity {
+				t.Errorf("Expected severity %s, got %s", tt.severity, result.Severity)
+			}
+			if result.Success != tt.success {
+				t.Errorf("Expected success %v, got %v", tt.success, result.Success)
+			}
+			if result.Error != tt.err {
+				t.Errorf("Expected error %q, got %q", tt.err, result.Error)
+			}
+		})
+	}
+}
+
+func TestExecChecker_Args(t *testing.T) {
+	checker := NewExecChecker(writePlugin(t, `[ "$1" = "-w" ] && [ "$2" = "80" ] || exit 2`), []string{"-w", "80"}, 5*time.Second)
+	if result := checker.Check(context.Background()); !result.Success {
+		t.Errorf("Expected arguments to reach the plugin, got %+v", result)
+	}
+}
+
+func TestExecChecker_Timeout(t *testing.T) {
+	checker := NewExecChecker(writePlugin(t, "sleep 5"), nil, 100*time.Millisecond)
+	result := checker.Check(context.Background())
+
+	if result.Success || result.Severity != SeverityCritical {
+		t.Errorf("Expected a critical timeout, got %+v", result)
+	}
+	if result.Duration > 3*time.Second {
+		t.Errorf("Expected the plugin to be killed, took %v", result.Duration)
+	}
+}
+
+func TestExecChecker_BoundsOutput(t *testing.T) {
+	// Four times the kept output on each stream
+	script := `i=0
+while [ $i -lt 4096 ]; do
+	echo 'CRITICAL - 0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef'
+	echo 'stderr 0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef' >&2
+	i=$((i+1))
+done
+exit 2`
+	result := NewExecChecker(writePlugin(t, script), nil, 5*time.Second).Check(context.Background())
+
+	if result.Severity != SeverityCritical {
+		t.Errorf("Expected the exit code to survive the discarded output, got %s (%s)", result.Severity, result.Error)
+	}
+	if len(result.Error) > maxPluginOutputBytes {
+		t.Errorf("Expected at most %d bytes of output, got %d", maxPluginOutputBytes, len(result.Error))
+	}
+
+	stdout := &limitedBuffer{limit: 4}
+	if n, err := stdout.Write([]byte("abcdef")); n != 6 || err != nil || stdout.String() != "abcd" {
+		t.Errorf("Expected the first 4 bytes to be kept, got %q (%d, %v)", stdout.String(), n, err)
+	}
+}
+
+func TestExecChecker_MissingCommand(t *testing.T) {
+	checker := NewExecChecker(filepath.Join(t.TempDir(), "missing"), nil, time.Second)
+	result := checker.Check(context.Background())
+
+	if result.Success || result.Severity != SeverityUnknown {
+		t.Errorf("Expected an unknown result, got %+v", result)
+	}
+}
+
+func TestParsePluginOutput(t *testing.T) {
+	output := "DISK OK - free space: / 3326 MB (56%); | /=2643MB;5948;5958;0;5968\n" +
+		"/ 15272 MB (77%);\n" +
+		"/boot 68 MB (69%); | /boot=68MB;88;93;0;98 'home dir'=69%;80;90 load=U time=0.5s"
+
+	text, metrics := parsePluginOutput(output)
+	if text != "DISK OK - free space: / 3326 MB (56%);" {
+		t.Errorf("Unexpected text %q", text)
+	}
+
+	if len(metrics) != 4 {
+		t.Fatalf("Expected 4 metrics, got %d: %+v", len(metrics), metrics)
+	}
+
+	root := metrics[0]
+	if root.Label != "/" || root.Value != 2643 || root.Unit != "MB" || root.Warn != "5948" || root.Crit != "5958" {
+		t.Errorf("Unexpected metric %+v", root)
+	}
+	if root.Min == nil || *root.Min != 0 || root.Max == nil || *root.Max != 5968 {
+		t.Errorf("Unexpected min/max on %+v", root)
+	}
+	if metrics[2].Label != "home dir" || metrics[2].Unit != "%" || metrics[2].Max != nil {
+		t.Errorf("Unexpected quoted metric %+v", metrics[2])
+	}
+	if metrics[3].Label != "time" || metrics[3].Value != 0.5 || metrics[3].Unit != "s" {
+		t.Errorf("Unexpected metric %+v", metrics[3])
+	}
+}
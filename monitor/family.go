@@ -0,0 +1,185 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"site-monitor/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+// familyNames are the display names of the address families
+var familyNames = map[string]string{
+	config.IPv4: "IPv4",
+	config.IPv6: "IPv6",
+}
+
+// ipFamily returns the address family of an IP
+func ipFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return config.IPv4
+	}
+	return config.IPv6
+}
+
+// isPinned reports whether a site restricts the addresses its connections go to
+func isPinned(site config.Site) bool {
+	return site.IPVersion != "" || len(site.ResolveTo) > 0
+}
+
+// newSiteDialer returns the dial function for a site's connections. When the
+// site sets ip_version or resolve_to, the host is replaced by the matching
+// addresses, tried in order, so a missing family fails instead of falling
+// back to the other one. Proxies then connect to the pinned address.
+func newSiteDialer(site config.Site) (DialContextFunc, error) {
+	dial, err := NewDialer(site.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if !isPinned(site) {
+		return dial, nil
+	}
+
+	family := site.IPVersion
+	if family == config.IPBoth {
+		return nil, fmt.Errorf("ip_version %q needs a checker per family", family)
+	}
+	resolveTo := site.ResolveTo
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		ips, err := pinnedAddresses(ctx, host, family, resolveTo)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ip := range ips {
+			var conn net.Conn
+			conn, err = dial(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}, nil
+}
+
+// pinnedAddresses returns the addresses to connect to for host: the
+// resolve_to addresses, or the DNS answer, restricted to family if set
+func pinnedAddresses(ctx context.Context, host, family string, resolveTo []string) ([]net.IP, error) {
+	var candidates []net.IP
+	switch {
+	case len(resolveTo) > 0:
+		for _, address := range resolveTo {
+			if ip := net.ParseIP(address); ip != nil {
+				candidates = append(candidates, ip)
+			}
+		}
+	case net.ParseIP(host) != nil:
+		candidates = []net.IP{net.ParseIP(host)}
+	default:
+		network := "ip"
+		if family != "" {
+			network = "ip" + strings.TrimPrefix(family, "v")
+		}
+		ips, err := net.DefaultResolver.LookupIP(ctx, network, host)
+		if err != nil {
+			return nil, err
+		}
+		candidates = ips
+	}
+
+	var ips []net.IP
+	for _, ip := range candidates {
+		if family == "" || ipFamily(ip) == family {
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 && family != "" {
+		return nil, fmt.Errorf("no %s address for %s", familyNames[family], host)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no address for %s", host)
+	}
+	return ips, nil
+}
+
+// familyChecker labels the results of a checker pinned to one address family
+type familyChecker struct {
+	family  string
+	checker Checker
+}
+
+func (c *familyChecker) Check(ctx context.Context) Result {
+	result := c.checker.Check(ctx)
+	result.IPVersion = c.family
+	return result
+}
+
+// dualStackChecker probes every address family in parallel. The check
+// succeeds only if every family does; the per-family results are kept in
+// Families.
+type dualStackChecker struct {
+	checkers []Checker
+}
+
+func (c *dualStackChecker) Check(ctx context.Context) Result {
+	results := make([]Result, len(c.checkers))
+
+	var wg sync.WaitGroup
+	for i, checker := range c.checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			results[i] = checker.Check(ctx)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	return combineFamilies(results)
+}
+
+// severityRank orders severities from best to worst
+var severityRank = map[Severity]int{
+	SeverityOK:       0,
+	SeverityWarning:  1,
+	SeverityUnknown:  2,
+	SeverityCritical: 3,
+}
+
+// combineFamilies merges per-family results into the worst of them, with
+// every family's error and the longest duration
+func combineFamilies(results []Result) Result {
+	combined := results[0]
+	for _, result := range results[1:] {
+		if severityRank[result.GetSeverity()] > severityRank[combined.GetSeverity()] {
+			combined = result
+		}
+	}
+
+	var errs []string
+	var timestamp time.Time
+	for _, result := range results {
+		if result.Error != "" {
+			errs = append(errs, familyNames[result.IPVersion]+": "+result.Error)
+		}
+		if result.Duration > combined.Duration {
+			combined.Duration = result.Duration
+		}
+		if result.Timestamp.After(timestamp) {
+			timestamp = result.Timestamp
+		}
+	}
+
+	combined.IPVersion = ""
+	combined.Error = strings.Join(errs, "; ")
+	combined.Timestamp = timestamp
+	combined.Families = results
+	return combined
+}
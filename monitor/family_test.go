@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"site-monitor/config"
+	"strings"
+	"testing"
+)
+
+func TestHTTPChecker_ResolveToKeepsHost(t *testing.T) {
+	var host string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	m, err := NewFromSite(config.Site{
+		Name:      "backend-1",
+		URL:       "http://shop.internal:" + port + "/health",
+		Interval:  "1m",
+		Timeout:   "5s",
+		IPVersion: config.IPv4,
+		ResolveTo: []string{"127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatalf("NewFromSite failed: %v", err)
+	}
+
+	result := m.Check(context.Background())
+	if !result.Success {
+		t.Fatalf("Expected success against the pinned address, got %q", result.Error)
+	}
+	if host != "shop.internal:"+port {
+		t.Errorf("Expected the URL host to be sent, got %q", host)
+	}
+	if result.IPVersion != config.IPv4 || len(result.Families) != 0 {
+		t.Errorf("Expected a single IPv4 result, got %q with %d families", result.IPVersion, len(result.Families))
+	}
+}
+
+func TestMonitor_DualStack(t *testing.T) {
+	// The server only listens on IPv4, like a host whose AAAA record is broken
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	m, err := NewFromSite(config.Site{
+		Name:      "dual",
+		URL:       "http://localhost:" + port,
+		Interval:  "1m",
+		Timeout:   "5s",
+		IPVersion: config.IPBoth,
+		ResolveTo: []string{"127.0.0.1", "::1"},
+	})
+	if err != nil {
+		t.Fatalf("NewFromSite failed: %v", err)
+	}
+
+	result := m.Check(context.Background())
+	if result.Success {
+		t.Fatal("Expected the check to fail when one family is down")
+	}
+	if !strings.HasPrefix(result.Error, "IPv6: ") {
+		t.Errorf("Expected the error to name the failing family, got %q", result.Error)
+	}
+	if len(result.Families) != 2 {
+		t.Fatalf("Expected a result per family, got %d", len(result.Families))
+	}
+
+	v4, v6 := result.Families[0], result.Families[1]
+	if v4.IPVersion != config.IPv4 || !v4.Success {
+		t.Errorf("Expected IPv4 to succeed, got %+v", v4)
+	}
+	if v6.IPVersion != config.IPv6 || v6.Success {
+		t.Errorf("Expected IPv6 to fail, got %+v", v6)
+	}
+	for _, family := range result.Families {
+		if family.Name != "dual" || family.Attempts != 1 || family.Timestamp.IsZero() {
+			t.Errorf("Expected family results to be labelled, got %+v", family)
+		}
+	}
+}
+
+func TestPinnedAddresses(t *testing.T) {
+	ctx := context.Background()
+	resolveTo := []string{"192.0.2.10", "2001:db8::10", "192.0.2.11"}
+
+	ips, err := pinnedAddresses(ctx, "shop.internal", config.IPv4, resolveTo)
+	if err != nil || len(ips) != 2 || ips[0].String() != "192.0.2.10" || ips[1].String() != "192.0.2.11" {
+		t.Errorf("Expected the IPv4 addresses in order, got %v (%v)", ips, err)
+	}
+
+	if _, err := pinnedAddresses(ctx, "127.0.0.1", config.IPv6, nil); err == nil || !strings.Contains(err.Error(), "no IPv6 address") {
+		t.Errorf("Expected no IPv6 address for an IPv4 literal, got %v", err)
+	}
+}
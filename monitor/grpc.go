@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"site-monitor/config"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	Register("grpc", newGRPCCheckerFromSite)
+}
+
+// GRPCChecker queries a server with the standard gRPC health checking
+// protocol (grpc.health.v1.Health/Check). SERVING is a success,
+// NOT_SERVING is critical and UNKNOWN is reported as unknown.
+type GRPCChecker struct {
+	Address string // host:port
+	Service string // Health service name, empty for the whole server
+	Timeout time.Duration
+	TLS     bool
+	creds   credentials.TransportCredentials
+	dial    DialContextFunc // Nil dials directly
+}
+
+// NewGRPCChecker creates a plaintext gRPC health checker
+func NewGRPCChecker(address, service string, timeout time.Duration) *GRPCChecker {
+	return &GRPCChecker{
+		Address: address,
+		Service: service,
+		Timeout: timeout,
+		creds:   insecure.NewCredentials(),
+	}
+}
+
+// newGRPCCheckerFromSite builds a gRPC health checker from a site configuration
+func newGRPCCheckerFromSite(site config.Site) (Checker, error) {
+	timeout, err := site.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	address := site.URL
+	useTLS := site.TLS != nil
+	switch {
+	case strings.HasPrefix(address, "grpcs://"):
+		address = strings.TrimPrefix(address, "grpcs://")
+		useTLS = true
+	case strings.HasPrefix(address, "grpc://"):
+		address = strings.TrimPrefix(address, "grpc://")
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil || strings.Contains(address, "/") {
+		return nil, fmt.Errorf("grpc check needs a host:port address, got %q", site.URL)
+	}
+
+	c := NewGRPCChecker(address, site.Service, timeout)
+	if useTLS {
+		tlsConfig, err := NewTLSConfig(site.TLS)
+		if err != nil {
+			return nil, err
+		}
+		c.TLS = true
+		c.creds = credentials.NewTLS(tlsConfig)
+	}
+	if site.Proxy != nil || isPinned(site) {
+		if c.dial, err = newSiteDialer(site); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Check dials the server and asks for the health of the configured service
+func (c *GRPCChecker) Check(ctx context.Context) Result {
+	start := time.Now()
+
+	scheme := "grpc://"
+	if c.TLS {
+		scheme = "grpcs://"
+	}
+	result := Result{
+		URL:       scheme + c.Address,
+		Timestamp: start,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	// Dial does not block: the connection is made by the health call, bounded
+	// by ctx. The call is not wait-for-ready, so dial and handshake failures
+	// surface as an Unavailable error instead of waiting for the timeout.
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(c.creds),
+		grpc.WithUserAgent("SiteMonitor/1.0"),
+	}
+	if c.dial != nil {
+		options = append(options, grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			return c.dial(ctx, "tcp", address)
+		}))
+	}
+	conn, err := grpc.Dial(c.Address, options...)
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Timestamp = time.Now()
+		result.Error = fmt.Sprintf("failed to create client: %v", err)
+		return result
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: c.Service})
+	result.Duration = time.Since(start)
+	result.Timestamp = time.Now()
+
+	// Status holds the gRPC status code of the health call (0 = OK)
+	if err != nil {
+		st := status.Convert(err)
+		result.Status = int(st.Code())
+		switch st.Code() {
+		case codes.NotFound:
+			result.Severity = SeverityUnknown
+			result.Error = fmt.Sprintf("health service %q is not registered", c.Service)
+		case codes.Unavailable:
+			result.Error = fmt.Sprintf("failed to connect: %s", st.Message())
+		default:
+			result.Error = fmt.Sprintf("health check failed: %s: %s", st.Code(), st.Message())
+		}
+		return result
+	}
+
+	switch resp.GetStatus() {
+	case healthpb.HealthCheckResponse_SERVING:
+		result.Success = true
+		result.Severity = SeverityOK
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		result.Severity = SeverityCritical
+		result.Error = "health status NOT_SERVING"
+	default:
+		result.Severity = SeverityUnknown
+		result.Error = fmt.Sprintf("health status %s", resp.GetStatus())
+	}
+
+	return result
+}
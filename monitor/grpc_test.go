@@ -0,0 +1,162 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"site-monitor/config"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startHealthServer runs an in-process gRPC health server and returns its
+// address along with the health service to set statuses on
+func startHealthServer(t *testing.T, tlsConfig *tls.Config) (string, *health.Server) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(opts...)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String(), healthServer
+}
+
+func TestGRPCChecker_Statuses(t *testing.T) {
+	address, healthServer := startHealthServer(t, nil)
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("billing", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthServer.SetServingStatus("search", healthpb.HealthCheckResponse_UNKNOWN)
+
+	tests := []struct {
+		service  string
+		success  bool
+		severity Severity
+	}{
+		{"", true, SeverityOK}, // Overall server health defaults to SERVING
+		{"orders", true, SeverityOK},
+		{"billing", false, SeverityCritical},
+		{"search", false, SeverityUnknown},
+		{"missing", false, SeverityUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run("service "+tt.service, func(t *testing.T) {
+			checker, err := NewChecker(config.Site{Type: "grpc", URL: "grpc://" + address, Service: tt.service, Timeout: "5s"})
+			if err != nil {
+				t.Fatalf("Failed to create checker: %v", err)
+			}
+
+			result := checker.Check(context.Background())
+			if result.Success != tt.success || result.Severity != tt.severity {
+				t.Errorf("Expected success=%v severity=%s, got success=%v severity=%s (%s)",
+					tt.success, tt.severity, result.Success, result.Severity, result.Error)
+			}
+		})
+	}
+}
+
+func TestGRPCChecker_TLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	t.Run("tls", func(t *testing.T) {
+		address, _ := startHealthServer(t, pki.serverConfig(false))
+
+		checker, err := NewChecker(config.Site{Type: "grpc", URL: "grpcs://" + address, Timeout: "5s",
+			TLS: &config.TLSConfig{CAFile: pki.caFile}})
+		if err != nil {
+			t.Fatalf("Failed to create checker: %v", err)
+		}
+		if result := checker.Check(context.Background()); !result.Success {
+			t.Errorf("Expected TLS health check to succeed, got %q", result.Error)
+		}
+	})
+
+	t.Run("mtls", func(t *testing.T) {
+		address, _ := startHealthServer(t, pki.serverConfig(true))
+
+		site := config.Site{Type: "grpc", URL: address, Timeout: "5s", TLS: &config.TLSConfig{CAFile: pki.caFile}}
+		checker, err := NewChecker(site)
+		if err != nil {
+			t.Fatalf("Failed to create checker: %v", err)
+		}
+		if result := checker.Check(context.Background()); result.Success {
+			t.Error("Expected the handshake to fail without a client certificate")
+		}
+
+		site.TLS.CertFile = pki.clientCert
+		site.TLS.KeyFile = pki.clientKey
+		checker, err = NewChecker(site)
+		if err != nil {
+			t.Fatalf("Failed to create checker: %v", err)
+		}
+		if result := checker.Check(context.Background()); !result.Success {
+			t.Errorf("Expected mTLS health check to succeed, got %q", result.Error)
+		}
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		address, _ := startHealthServer(t, pki.serverConfig(false))
+
+		checker, err := NewChecker(config.Site{Type: "grpc", URL: "grpcs://" + address, Timeout: "2s"})
+		if err != nil {
+			t.Fatalf("Failed to create checker: %v", err)
+		}
+		result := checker.Check(context.Background())
+		if result.Success || !strings.Contains(result.Error, "failed to connect") {
+			t.Errorf("Expected a connection failure for an untrusted certificate, got %q", result.Error)
+		}
+	})
+}
+
+func TestGRPCChecker_Timeout(t *testing.T) {
+	// A server that accepts connections but never speaks HTTP/2
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	result := NewGRPCChecker(listener.Addr().String(), "", 200*time.Millisecond).Check(context.Background())
+	if result.Success {
+		t.Fatal("Expected a silent server to fail the check")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the check to give up after its timeout, took %v", elapsed)
+	}
+}
+
+func TestGRPCChecker_InvalidAddress(t *testing.T) {
+	for _, url := range []string{"", "localhost", "https://localhost:50051/health"} {
+		if _, err := NewChecker(config.Site{Type: "grpc", URL: url}); err == nil {
+			t.Errorf("Expected an error for %q", url)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"site-monitor/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("http", newHTTPCheckerFromSite)
+}
+
+// HTTPChecker checks a URL with an HTTP request
+type HTTPChecker struct {
+	URL                 string
+	Method              string            // Defaults to GET
+	Headers             map[string]string // Extra request headers
+	Body                string            // Request body
+	Auth                *config.AuthConfig
+	ExpectedStatusCodes []int // Empty means any 2xx or 3xx status
+	SSLWarnDays         int   // Warn when the certificate expires within this many days (0 = not checked)
+	assertions          []assertion
+	client              *http.Client
+}
+
+// NewHTTPChecker creates a new HTTP checker that sends GET requests and
+// follows up to config.DefaultMaxRedirects redirects
+func NewHTTPChecker(url string, timeout time.Duration) *HTTPChecker {
+	c := &HTTPChecker{
+		URL:    url,
+		Method: http.MethodGet,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+	c.SetRedirectPolicy(true, config.DefaultMaxRedirects)
+	return c
+}
+
+// newHTTPCheckerFromSite builds an HTTP checker from a site configuration
+func newHTTPCheckerFromSite(site config.Site) (Checker, error) {
+	timeout, err := site.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAuth(site.Auth); err != nil {
+		return nil, err
+	}
+
+	assertions, err := compileAssertions(site.Assertions)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewHTTPChecker(site.URL, timeout)
+	if site.Method != "" {
+		c.Method = strings.ToUpper(site.Method)
+	}
+	c.Headers = site.Headers
+	c.Body = site.Body
+	c.Auth = site.Auth
+	c.ExpectedStatusCodes = site.ExpectedStatusCodes
+	c.assertions = assertions
+	if site.SSLCheck {
+		c.SSLWarnDays = site.GetSSLWarnDays()
+	}
+	c.SetRedirectPolicy(site.ShouldFollowRedirects(), site.GetMaxRedirects())
+
+	transport, err := newHTTPTransport(site)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		c.client.Transport = transport
+	}
+
+	return c, nil
+}
+
+// SetTimeout sets custom timeout for HTTP requests
+func (c *HTTPChecker) SetTimeout(timeout time.Duration) {
+	c.client.Timeout = timeout
+}
+
+// SetRedirectPolicy configures whether redirects are followed and the
+// maximum number of hops. When not following, the redirect response itself
+// is evaluated against the expected status codes.
+func (c *HTTPChecker) SetRedirectPolicy(follow bool, maxRedirects int) {
+	c.client.CheckRedirect = redirectPolicy(follow, maxRedirects)
+}
+
+// redirectPolicy returns an http.Client CheckRedirect function
+func redirectPolicy(follow bool, maxRedirects int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if !follow {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+}
+
+// validateAuth checks that an auth configuration uses a supported type
+func validateAuth(auth *config.AuthConfig) error {
+	if auth == nil {
+		return nil
+	}
+	switch auth.Type {
+	case "basic", "bearer":
+		return nil
+	default:
+		return fmt.Errorf("unsupported auth type %q (expected basic or bearer)", auth.Type)
+	}
+}
+
+// Check performs a single HTTP check
+func (c *HTTPChecker) Check(ctx context.Context) Result {
+	result, _ := c.check(ctx, len(c.assertions) > 0)
+	return result
+}
+
+// response is what a transaction step can extract variables from
+type response struct {
+	header  http.Header
+	cookies []*http.Cookie
+	body    []byte
+}
+
+// check performs the request and evaluates the result. The response is
+// returned when a status was received; its body is only kept if keepBody is set.
+func (c *HTTPChecker) check(ctx context.Context, keepBody bool) (Result, *response) {
+	start := time.Now()
+
+	result := Result{
+		URL:       c.URL,
+		Timestamp: start,
+	}
+
+	trace := &timingTrace{}
+	req, err := c.newRequest(httptrace.WithClientTrace(ctx, trace.clientTrace()))
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Timestamp = time.Now()
+		result.Timings = trace.done()
+		result.Success = false
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	// The body is always read so the transfer phase can be timed; it is only
+	// kept when there is something to assert on or extract from
+	var body []byte
+	var size int64
+	var readErr error
+	if keepBody {
+		body, readErr = io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+		size = int64(len(body))
+		if readErr == nil && size == maxBodyBytes {
+			// Count the rest so size assertions see the whole body
+			var rest int64
+			rest, readErr = io.Copy(io.Discard, resp.Body)
+			size += rest
+		}
+	} else {
+		_, readErr = io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyBytes))
+	}
+	result.Duration = time.Since(start)
+	result.Timestamp = time.Now()
+	result.Timings = trace.done()
+
+	r := &response{header: resp.Header, cookies: resp.Cookies(), body: body}
+
+	result.Status = resp.StatusCode
+	result.Success = c.isExpectedStatus(resp.StatusCode)
+	if !result.Success {
+		result.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+		return result, r
+	}
+
+	if readErr != nil {
+		result.Success = false
+		result.Error = fmt.Sprintf("failed to read response body: %v", readErr)
+		return result, r
+	}
+
+	if len(c.assertions) > 0 {
+		var failure string
+		result.Assertions, failure = evaluateAssertions(c.assertions, body, size)
+		if failure != "" {
+			result.Success = false
+			result.Error = "assertion failed: " + failure
+			return result, r
+		}
+	}
+
+	c.checkCertificate(resp, &result)
+	return result, r
+}
+
+// CertificateDaysMetric is the metric reporting the days left before the
+// server certificate expires, set by checks with ssl_check enabled
+const CertificateDaysMetric = "ssl_days_left"
+
+// checkCertificate reports the days left on the server certificate and
+// downgrades a successful result to a warning when it expires soon
+func (c *HTTPChecker) checkCertificate(resp *http.Response, result *Result) {
+	if c.SSLWarnDays <= 0 || resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return
+	}
+
+	days := int(time.Until(resp.TLS.PeerCertificates[0].NotAfter).Hours() / 24)
+	result.Metrics = append(result.Metrics, Metric{
+		Label: CertificateDaysMetric,
+		Value: float64(days),
+		Unit:  "d",
+		Warn:  strconv.Itoa(c.SSLWarnDays),
+	})
+
+	if days <= c.SSLWarnDays {
+		result.Severity = SeverityWarning
+		result.Error = fmt.Sprintf("certificate expires in %d days", days)
+	}
+}
+
+// newRequest builds the HTTP request with method, body, headers and auth
+func (c *HTTPChecker) newRequest(ctx context.Context) (*http.Request, error) {
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if c.Body != "" {
+		body = strings.NewReader(c.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "SiteMonitor/1.0")
+	for key, value := range c.Headers {
+		// Host must be set on the request itself, not in the header map
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	if c.Auth != nil {
+		switch c.Auth.Type {
+		case "basic":
+			req.SetBasicAuth(c.Auth.Username, c.Auth.Password)
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+c.Auth.Token)
+		}
+	}
+
+	return req, nil
+}
+
+// isExpectedStatus checks a status code against the expected list,
+// falling back to accepting any 2xx or 3xx status
+func (c *HTTPChecker) isExpectedStatus(status int) bool {
+	if len(c.ExpectedStatusCodes) == 0 {
+		return status >= 200 && status < 400
+	}
+
+	for _, expected := range c.ExpectedStatusCodes {
+		if status == expected {
+			return true
+		}
+	}
+	return false
+}
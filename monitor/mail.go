@@ -0,0 +1,412 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"site-monitor/config"
+	"strings"
+	"time"
+)
+
+// mailProtocol describes the dialogue of a mail protocol. Only
+// session-level commands are used: a check never authenticates or sends mail.
+type mailProtocol struct {
+	port         string // Default port
+	tlsScheme    string // URL scheme for implicit TLS
+	tlsPort      string // Default port for implicit TLS
+	banner       func(s *mailSession) error
+	capabilities func(s *mailSession) ([]string, error)
+	startTLS     func(s *mailSession) error
+	startTLSCap  string // Capability advertising STARTTLS support
+	quit         func(s *mailSession)
+}
+
+var mailProtocols = map[string]mailProtocol{
+	"smtp": {
+		port: "25", tlsScheme: "smtps", tlsPort: "465",
+		banner:       smtpBanner,
+		capabilities: smtpCapabilities,
+		startTLS:     smtpStartTLS,
+		startTLSCap:  "STARTTLS",
+		quit:         func(s *mailSession) { s.smtpCommand(221, "QUIT") },
+	},
+	"imap": {
+		port: "143", tlsScheme: "imaps", tlsPort: "993",
+		banner:       imapBanner,
+		capabilities: imapCapabilities,
+		startTLS:     func(s *mailSession) error { _, err := s.imapCommand("STARTTLS"); return err },
+		startTLSCap:  "STARTTLS",
+		quit:         func(s *mailSession) { s.imapCommand("LOGOUT") },
+	},
+	"pop3": {
+		port: "110", tlsScheme: "pop3s", tlsPort: "995",
+		banner:       func(s *mailSession) error { _, err := s.pop3Reply(); return err },
+		capabilities: pop3Capabilities,
+		startTLS:     func(s *mailSession) error { _, err := s.pop3Command("STLS"); return err },
+		startTLSCap:  "STLS",
+		quit:         func(s *mailSession) { s.pop3Command("QUIT") },
+	},
+}
+
+func init() {
+	for name := range mailProtocols {
+		name := name
+		Register(name, func(site config.Site) (Checker, error) {
+			return newMailCheckerFromSite(name, site)
+		})
+	}
+}
+
+// MailChecker connects to an SMTP, IMAP or POP3 server, reads the banner
+// and optionally upgrades with STARTTLS and verifies advertised capabilities
+type MailChecker struct {
+	Protocol     string // smtp, imap or pop3
+	Address      string // host:port
+	Timeout      time.Duration
+	ImplicitTLS  bool     // TLS from the first byte (smtps, imaps, pop3s)
+	StartTLS     bool     // Upgrade with STARTTLS after the banner
+	Capabilities []string // Capabilities the server must advertise
+	tlsConfig    *tls.Config
+	dial         DialContextFunc
+}
+
+// newMailCheckerFromSite builds a mail checker for the protocol from a site configuration
+func newMailCheckerFromSite(protocol string, site config.Site) (Checker, error) {
+	proto := mailProtocols[protocol]
+
+	timeout, err := site.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &MailChecker{
+		Protocol:     protocol,
+		Timeout:      timeout,
+		StartTLS:     site.StartTLS,
+		Capabilities: site.Capabilities,
+	}
+	if c.dial, err = newSiteDialer(site); err != nil {
+		return nil, err
+	}
+
+	address := site.URL
+	port := proto.port
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, fmt.Errorf("%s check needs a host:port address, got %q", protocol, site.URL)
+		}
+		switch u.Scheme {
+		case protocol:
+		case proto.tlsScheme:
+			c.ImplicitTLS = true
+			port = proto.tlsPort
+		default:
+			return nil, fmt.Errorf("%s check needs a %s:// or %s:// URL, got %q", protocol, protocol, proto.tlsScheme, site.URL)
+		}
+		address = u.Host
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, port)
+	}
+	if host, _, err := net.SplitHostPort(address); err != nil || host == "" || strings.Contains(address, "/") {
+		return nil, fmt.Errorf("%s check needs a host:port address, got %q", protocol, site.URL)
+	}
+	c.Address = address
+
+	if c.ImplicitTLS && c.StartTLS {
+		return nil, fmt.Errorf("starttls cannot be combined with %s://", proto.tlsScheme)
+	}
+	if c.ImplicitTLS || c.StartTLS {
+		if c.tlsConfig, err = NewTLSConfig(site.TLS); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// mailSession is an open connection speaking a mail protocol
+type mailSession struct {
+	conn net.Conn
+	text *textproto.Conn
+	tag  int // IMAP command tag counter
+}
+
+// Check runs the session: banner, optional STARTTLS and capabilities, then quit
+func (c *MailChecker) Check(ctx context.Context) Result {
+	start := time.Now()
+	proto := mailProtocols[c.Protocol]
+
+	scheme := c.Protocol
+	if c.ImplicitTLS {
+		scheme = proto.tlsScheme
+	}
+	result := Result{
+		URL:       scheme + "://" + c.Address,
+		Timestamp: start,
+	}
+
+	fail := func(format string, args ...interface{}) Result {
+		result.Duration = time.Since(start)
+		result.Timestamp = time.Now()
+		result.Error = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	conn, err := c.dial(ctx, "tcp", c.Address)
+	result.Timings.Connect = time.Since(start)
+	if err != nil {
+		return fail("connection failed: %v", err)
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes if the check is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	session := &mailSession{conn: conn}
+	if c.ImplicitTLS {
+		tlsStart := time.Now()
+		err := session.upgrade(ctx, c.tlsConfig, c.Address)
+		result.Timings.TLS = time.Since(tlsStart)
+		if err != nil {
+			return fail("TLS handshake failed: %v", err)
+		}
+	} else {
+		session.text = textproto.NewConn(conn)
+	}
+
+	bannerStart := time.Now()
+	err = proto.banner(session)
+	result.Timings.TTFB = time.Since(bannerStart)
+	if err != nil {
+		return fail("unexpected banner: %v", err)
+	}
+
+	exchangeStart := time.Now()
+	var upgradeTime time.Duration
+	if c.StartTLS || len(c.Capabilities) > 0 {
+		capabilities, err := proto.capabilities(session)
+		if err != nil {
+			return fail("capability request failed: %v", err)
+		}
+
+		if c.StartTLS {
+			if !hasCapability(capabilities, proto.startTLSCap) {
+				return fail("server does not advertise %s", proto.startTLSCap)
+			}
+			if err := proto.startTLS(session); err != nil {
+				return fail("%s refused: %v", proto.startTLSCap, err)
+			}
+
+			tlsStart := time.Now()
+			err := session.upgrade(ctx, c.tlsConfig, c.Address)
+			upgradeTime = time.Since(tlsStart)
+			result.Timings.TLS = upgradeTime
+			if err != nil {
+				return fail("TLS handshake failed: %v", err)
+			}
+
+			// Capabilities announced before STARTTLS must be discarded
+			if capabilities, err = proto.capabilities(session); err != nil {
+				return fail("capability request after STARTTLS failed: %v", err)
+			}
+		}
+
+		for _, expected := range c.Capabilities {
+			if !hasCapability(capabilities, expected) {
+				return fail("capability %q not advertised", expected)
+			}
+		}
+	}
+	result.Timings.Transfer = time.Since(exchangeStart) - upgradeTime
+
+	proto.quit(session)
+
+	result.Duration = time.Since(start)
+	result.Timestamp = time.Now()
+	result.Success = true
+	return result
+}
+
+// upgrade performs a TLS handshake over the session's connection
+func (s *mailSession) upgrade(ctx context.Context, tlsConfig *tls.Config, address string) error {
+	cfg := tlsConfig.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(address)
+	}
+
+	tlsConn := tls.Client(s.conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return err
+	}
+	s.conn = tlsConn
+	s.text = textproto.NewConn(tlsConn)
+	return nil
+}
+
+// hasCapability reports whether capabilities include expected, compared
+// case-insensitively. Words after the first must appear among the
+// capability's parameters, so "AUTH PLAIN" matches "AUTH LOGIN PLAIN".
+func hasCapability(capabilities []string, expected string) bool {
+	want := strings.Fields(strings.ToUpper(expected))
+	if len(want) == 0 {
+		return true
+	}
+
+	for _, capability := range capabilities {
+		have := strings.Fields(strings.ToUpper(capability))
+		if len(have) == 0 || have[0] != want[0] {
+			continue
+		}
+		params := make(map[string]bool, len(have)-1)
+		for _, param := range have[1:] {
+			params[param] = true
+		}
+		found := true
+		for _, param := range want[1:] {
+			found = found && params[param]
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// smtpBanner reads the 220 greeting
+func smtpBanner(s *mailSession) error {
+	_, _, err := s.text.ReadResponse(220)
+	return err
+}
+
+// smtpCapabilities sends EHLO and returns the advertised extensions
+func smtpCapabilities(s *mailSession) ([]string, error) {
+	message, err := s.smtpCommand(250, "EHLO %s", ehloHostname())
+	if err != nil {
+		return nil, err
+	}
+	// The first line is the server greeting, extensions follow
+	lines := strings.Split(message, "\n")
+	return lines[1:], nil
+}
+
+// smtpStartTLS asks the server to start TLS
+func smtpStartTLS(s *mailSession) error {
+	_, err := s.smtpCommand(220, "STARTTLS")
+	return err
+}
+
+// smtpCommand sends a command and reads a reply with the expected code
+func (s *mailSession) smtpCommand(expectCode int, format string, args ...interface{}) (string, error) {
+	id, err := s.text.Cmd(format, args...)
+	if err != nil {
+		return "", err
+	}
+	s.text.StartResponse(id)
+	defer s.text.EndResponse(id)
+
+	_, message, err := s.text.ReadResponse(expectCode)
+	return message, err
+}
+
+// ehloHostname is the name announced in EHLO
+func ehloHostname() string {
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "localhost"
+}
+
+// imapBanner reads the untagged greeting
+func imapBanner(s *mailSession) error {
+	line, err := s.text.ReadLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "* OK") && !strings.HasPrefix(line, "* PREAUTH") {
+		return fmt.Errorf("%s", line)
+	}
+	return nil
+}
+
+// imapCapabilities sends CAPABILITY and returns the advertised capabilities
+func imapCapabilities(s *mailSession) ([]string, error) {
+	untagged, err := s.imapCommand("CAPABILITY")
+	if err != nil {
+		return nil, err
+	}
+
+	var capabilities []string
+	for _, line := range untagged {
+		if rest, ok := strings.CutPrefix(line, "* CAPABILITY "); ok {
+			capabilities = append(capabilities, strings.Fields(rest)...)
+		}
+	}
+	return capabilities, nil
+}
+
+// imapCommand sends a tagged command and returns the untagged lines
+// received before its tagged OK
+func (s *mailSession) imapCommand(command string) ([]string, error) {
+	s.tag++
+	tag := fmt.Sprintf("a%d", s.tag)
+	if err := s.text.PrintfLine("%s %s", tag, command); err != nil {
+		return nil, err
+	}
+
+	var untagged []string
+	for {
+		line, err := s.text.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if rest, ok := strings.CutPrefix(line, tag+" "); ok {
+			if !strings.HasPrefix(rest, "OK") {
+				return nil, fmt.Errorf("%s", rest)
+			}
+			return untagged, nil
+		}
+		untagged = append(untagged, line)
+	}
+}
+
+// pop3Capabilities sends CAPA and returns the advertised capabilities
+func pop3Capabilities(s *mailSession) ([]string, error) {
+	if _, err := s.pop3Command("CAPA"); err != nil {
+		return nil, err
+	}
+	return s.text.ReadDotLines()
+}
+
+// pop3Command sends a command and checks for a +OK status
+func (s *mailSession) pop3Command(command string) (string, error) {
+	if err := s.text.PrintfLine("%s", command); err != nil {
+		return "", err
+	}
+	return s.pop3Reply()
+}
+
+// pop3Reply reads a status line, failing on -ERR
+func (s *mailSession) pop3Reply() (string, error) {
+	line, err := s.text.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return "", fmt.Errorf("%s", line)
+	}
+	return line, nil
+}
@@ -0,0 +1,356 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"site-monitor/config"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeMailServer speaks just enough SMTP, IMAP or POP3 for the checks and
+// records every command it receives
+type fakeMailServer struct {
+	protocol string
+	banner   string      // Greeting override
+	tls      *tls.Config // Offers STARTTLS when set (and not implicit)
+	implicit bool        // TLS from the first byte
+
+	mu       sync.Mutex
+	commands []string
+}
+
+// start serves the protocol on a local port and returns its address
+func (s *fakeMailServer) start(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	if s.implicit {
+		listener = tls.NewListener(listener, s.tls)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// received returns the commands received so far
+func (s *fakeMailServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *fakeMailServer) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+	write := func(lines ...string) {
+		for _, line := range lines {
+			fmt.Fprintf(conn, "%s\r\n", line)
+		}
+	}
+	secure := s.implicit
+	canStartTLS := func() bool { return s.tls != nil && !secure }
+
+	greetings := map[string]string{"smtp": "220 mail.test ESMTP", "imap": "* OK IMAP4rev1 ready", "pop3": "+OK POP3 ready"}
+	greeting := greetings[s.protocol]
+	if s.banner != "" {
+		greeting = s.banner
+	}
+	write(greeting)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		tag, command, _ := strings.Cut(line, " ")
+		if s.protocol != "imap" {
+			command, tag = line, ""
+		}
+		verb := strings.ToUpper(strings.Fields(command + " ")[0])
+
+		upgrade := func() bool {
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return false
+			}
+			conn, reader, secure = tlsConn, bufio.NewReader(tlsConn), true
+			return true
+		}
+
+		switch s.protocol + " " + verb {
+		case "smtp EHLO":
+			lines := []string{"250-mail.test", "250-SIZE 1000000"}
+			if canStartTLS() {
+				lines = append(lines, "250-STARTTLS")
+			}
+			if secure {
+				lines = append(lines, "250-AUTH LOGIN PLAIN")
+			}
+			write(append(lines, "250 8BITMIME")...)
+		case "smtp STARTTLS":
+			write("220 Ready to start TLS")
+			if !upgrade() {
+				return
+			}
+		case "smtp QUIT":
+			write("221 Bye")
+			return
+		case "imap CAPABILITY":
+			capabilities := "* CAPABILITY IMAP4rev1"
+			if canStartTLS() {
+				capabilities += " STARTTLS LOGINDISABLED"
+			} else {
+				capabilities += " AUTH=PLAIN"
+			}
+			write(capabilities, tag+" OK CAPABILITY completed")
+		case "imap STARTTLS":
+			write(tag + " OK Begin TLS negotiation now")
+			if !upgrade() {
+				return
+			}
+		case "imap LOGOUT":
+			write("* BYE logging out", tag+" OK LOGOUT completed")
+			return
+		case "pop3 CAPA":
+			lines := []string{"+OK Capability list follows", "USER"}
+			if canStartTLS() {
+				lines = append(lines, "STLS")
+			}
+			write(append(lines, "SASL PLAIN", ".")...)
+		case "pop3 STLS":
+			write("+OK Begin TLS negotiation")
+			if !upgrade() {
+				return
+			}
+		case "pop3 QUIT":
+			write("+OK Bye")
+			return
+		default:
+			switch s.protocol {
+			case "smtp":
+				write("502 Command not implemented")
+			case "imap":
+				write(tag + " BAD unknown command")
+			default:
+				write("-ERR unknown command")
+			}
+		}
+	}
+}
+
+func newMailChecker(t *testing.T, site config.Site) Checker {
+	t.Helper()
+	if site.Timeout == "" {
+		site.Timeout = "5s"
+	}
+	checker, err := NewChecker(site)
+	if err != nil {
+		t.Fatalf("Failed to create %s checker: %v", site.Type, err)
+	}
+	return checker
+}
+
+func TestMailChecker_Banner(t *testing.T) {
+	for _, protocol := range []string{"smtp", "imap", "pop3"} {
+		t.Run(protocol, func(t *testing.T) {
+			server := &fakeMailServer{protocol: protocol}
+			address := server.start(t)
+
+			result := newMailChecker(t, config.Site{Type: protocol, URL: address}).Check(context.Background())
+			if !result.Success {
+				t.Fatalf("Expected banner check to succeed, got %q", result.Error)
+			}
+			if result.Timings.Connect <= 0 || result.Timings.TTFB <= 0 {
+				t.Errorf("Expected connect and banner timings, got %+v", result.Timings)
+			}
+			if commands := server.received(); len(commands) != 1 {
+				t.Errorf("Expected only the quit command, got %v", commands)
+			}
+		})
+	}
+}
+
+func TestMailChecker_BadBanner(t *testing.T) {
+	tests := map[string]string{
+		"smtp": "554 No service",
+		"imap": "* BYE Too many connections",
+		"pop3": "-ERR Maintenance",
+	}
+	for protocol, banner := range tests {
+		t.Run(protocol, func(t *testing.T) {
+			server := &fakeMailServer{protocol: protocol, banner: banner}
+			result := newMailChecker(t, config.Site{Type: protocol, URL: server.start(t)}).Check(context.Background())
+			if result.Success || !strings.HasPrefix(result.Error, "unexpected banner") {
+				t.Errorf("Expected a banner failure, got %q", result.Error)
+			}
+		})
+	}
+}
+
+func TestMailChecker_StartTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	tests := []struct {
+		protocol     string
+		capabilities []string
+		commands     []string
+	}{
+		{"smtp", []string{"AUTH PLAIN", "8bitmime"}, []string{"EHLO", "STARTTLS", "EHLO", "QUIT"}},
+		{"imap", []string{"AUTH=PLAIN"}, []string{"a1 CAPABILITY", "a2 STARTTLS", "a3 CAPABILITY", "a4 LOGOUT"}},
+		{"pop3", []string{"SASL PLAIN"}, []string{"CAPA", "STLS", "CAPA", "QUIT"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			server := &fakeMailServer{protocol: tt.protocol, tls: pki.serverConfig(false)}
+			address := server.start(t)
+
+			result := newMailChecker(t, config.Site{
+				Type:         tt.protocol,
+				URL:          address,
+				StartTLS:     true,
+				Capabilities: tt.capabilities,
+				TLS:          &config.TLSConfig{CAFile: pki.caFile},
+			}).Check(context.Background())
+			if !result.Success {
+				t.Fatalf("Expected STARTTLS check to succeed, got %q", result.Error)
+			}
+			if result.Timings.TLS <= 0 || result.Timings.Transfer <= 0 {
+				t.Errorf("Expected TLS and exchange timings, got %+v", result.Timings)
+			}
+
+			// Commands are compared by prefix: EHLO carries the local hostname
+			commands := server.received()
+			if len(commands) != len(tt.commands) {
+				t.Fatalf("Expected commands %v, got %v", tt.commands, commands)
+			}
+			for i, command := range commands {
+				if !strings.HasPrefix(command, tt.commands[i]) {
+					t.Errorf("Expected command %q, got %q", tt.commands[i], command)
+				}
+			}
+		})
+	}
+}
+
+func TestMailChecker_ImplicitTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	server := &fakeMailServer{protocol: "pop3", tls: pki.serverConfig(false), implicit: true}
+	address := server.start(t)
+
+	result := newMailChecker(t, config.Site{
+		Type:         "pop3",
+		URL:          "pop3s://" + address,
+		Capabilities: []string{"USER"},
+		TLS:          &config.TLSConfig{CAFile: pki.caFile},
+	}).Check(context.Background())
+	if !result.Success {
+		t.Fatalf("Expected pop3s check to succeed, got %q", result.Error)
+	}
+	if result.URL != "pop3s://"+address || result.Timings.TLS <= 0 {
+		t.Errorf("Unexpected result %s %+v", result.URL, result.Timings)
+	}
+}
+
+func TestMailChecker_Failures(t *testing.T) {
+	t.Run("starttls not offered", func(t *testing.T) {
+		server := &fakeMailServer{protocol: "smtp"}
+		result := newMailChecker(t, config.Site{Type: "smtp", URL: server.start(t), StartTLS: true}).Check(context.Background())
+		if result.Success || result.Error != "server does not advertise STARTTLS" {
+			t.Errorf("Expected a missing STARTTLS failure, got %q", result.Error)
+		}
+	})
+
+	t.Run("missing capability", func(t *testing.T) {
+		server := &fakeMailServer{protocol: "imap"}
+		result := newMailChecker(t, config.Site{Type: "imap", URL: server.start(t), Capabilities: []string{"IDLE"}}).Check(context.Background())
+		if result.Success || result.Error != `capability "IDLE" not advertised` {
+			t.Errorf("Expected a capability failure, got %q", result.Error)
+		}
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		listener, _ := net.Listen("tcp", "127.0.0.1:0")
+		address := listener.Addr().String()
+		listener.Close()
+
+		result := newMailChecker(t, config.Site{Type: "smtp", URL: address}).Check(context.Background())
+		if result.Success || !strings.HasPrefix(result.Error, "connection failed") {
+			t.Errorf("Expected a connection failure, got %q", result.Error)
+		}
+	})
+}
+
+func TestMailChecker_Config(t *testing.T) {
+	valid := map[string]string{
+		"mail.example.com":         "mail.example.com:25",
+		"smtp://mail.example.com":  "mail.example.com:25",
+		"smtps://mail.example.com": "mail.example.com:465",
+		"mail.example.com:587":     "mail.example.com:587",
+	}
+	for url, address := range valid {
+		checker, err := NewChecker(config.Site{Type: "smtp", URL: url, Timeout: "5s"})
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", url, err)
+			continue
+		}
+		if got := checker.(*MailChecker).Address; got != address {
+			t.Errorf("Expected %q to dial %s, got %s", url, address, got)
+		}
+	}
+
+	invalid := []config.Site{
+		{Type: "smtp", URL: ""},
+		{Type: "smtp", URL: "imap://mail.example.com"},
+		{Type: "imap", URL: "imaps://mail.example.com", StartTLS: true},
+		{Type: "pop3", URL: "pop3://mail.example.com/inbox"},
+	}
+	for _, site := range invalid {
+		site.Timeout = "5s"
+		if _, err := NewChecker(site); err == nil {
+			t.Errorf("Expected an error for %s %q", site.Type, site.URL)
+		}
+	}
+}
+
+func TestHasCapability(t *testing.T) {
+	capabilities := []string{"SIZE 1000000", "AUTH LOGIN PLAIN", "8BITMIME"}
+
+	tests := map[string]bool{
+		"auth":             true,
+		"AUTH PLAIN":       true,
+		"AUTH PLAIN LOGIN": true,
+		"AUTH CRAM-MD5":    false,
+		"STARTTLS":         false,
+		"8BITMIME":         true,
+	}
+	for expected, want := range tests {
+		if got := hasCapability(capabilities, expected); got != want {
+			t.Errorf("hasCapability(%q) = %v, expected %v", expected, got, want)
+		}
+	}
+}
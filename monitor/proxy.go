@@ -0,0 +1,228 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"site-monitor/config"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// DialContextFunc opens a connection to address, like net.Dialer.DialContext
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// NewDialer returns a dial function that connects through the configured
+// proxy: http and https proxies are tunneled with CONNECT, socks5 proxies
+// use SOCKS5 (resolving names locally, or on the proxy for socks5h). A nil
+// config dials directly.
+func NewDialer(cfg *config.ProxyConfig) (DialContextFunc, error) {
+	direct := &net.Dialer{}
+	if cfg == nil {
+		return direct.DialContext, nil
+	}
+
+	proxyURL, err := parseProxyURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https":
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dialConnect(ctx, direct, proxyURL, address)
+		}, nil
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if proxyURL.User != nil {
+			password, _ := proxyURL.User.Password()
+			auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+		}
+		socks, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, direct)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOCKS5 proxy: %w", err)
+		}
+		dialer := socks.(proxy.ContextDialer)
+
+		if proxyURL.Scheme == "socks5h" {
+			return dialer.DialContext, nil
+		}
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			resolved, err := resolveAddress(ctx, address)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, resolved)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (expected http, https, socks5 or socks5h)", proxyURL.Scheme)
+	}
+}
+
+// parseProxyURL parses the proxy URL, applying the configured credentials
+func parseProxyURL(cfg *config.ProxyConfig) (*url.URL, error) {
+	proxyURL, err := url.Parse(cfg.URL)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", cfg.URL)
+	}
+	if cfg.Username != "" {
+		proxyURL.User = url.UserPassword(cfg.Username, cfg.Password)
+	}
+	return proxyURL, nil
+}
+
+// resolveAddress replaces the host of address with its first IP address
+func resolveAddress(ctx context.Context, address string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return address, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(addrs[0].IP.String(), port), nil
+}
+
+// dialConnect opens a tunnel to address through an HTTP proxy
+func dialConnect(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, address string) (net.Conn, error) {
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	// The handshake must not outlive the check
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s failed: %s", address, resp.Status)
+	}
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were read ahead
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// newHTTPTransport returns a transport applying the site's TLS, proxy and
+// address pinning settings, or nil to use the default transport
+func newHTTPTransport(site config.Site) (*http.Transport, error) {
+	if site.TLS == nil && site.Proxy == nil && !isPinned(site) {
+		return nil, nil
+	}
+
+	var transport *http.Transport
+	var err error
+	if isPinned(site) {
+		// Pinned connections tunnel through any proxy, so that the proxy
+		// connects to the pinned address rather than resolving the host
+		dial, err := newSiteDialer(site)
+		if err != nil {
+			return nil, err
+		}
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = dial
+	} else if transport, err = NewProxyTransport(site.Proxy); err != nil {
+		return nil, err
+	}
+
+	if site.TLS != nil {
+		if transport.TLSClientConfig, err = NewTLSConfig(site.TLS); err != nil {
+			return nil, err
+		}
+	}
+	return transport, nil
+}
+
+// NewProxyTransport returns a copy of the default transport that sends
+// requests through the configured proxy, or directly for a nil config
+func NewProxyTransport(cfg *config.ProxyConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg == nil {
+		return transport, nil
+	}
+
+	proxyURL, err := parseProxyURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch proxyURL.Scheme {
+	case "http", "https":
+		// Plain requests are forwarded, https ones tunneled with CONNECT
+		transport.Proxy = http.ProxyURL(proxyURL)
+	default:
+		dial, err := NewDialer(cfg)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = nil
+		transport.DialContext = dial
+	}
+	return transport, nil
+}
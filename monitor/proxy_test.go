@@ -0,0 +1,251 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"site-monitor/config"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// pipe copies between two connections until either side closes
+func pipe(a, b net.Conn) {
+	go func() {
+		io.Copy(a, b)
+		a.Close()
+	}()
+	io.Copy(b, a)
+	b.Close()
+}
+
+// startConnectProxy starts an HTTP proxy that only tunnels CONNECT requests
+// authenticated as user:password, counting the tunnels opened
+func startConnectProxy(t *testing.T, user, password string) (string, *int32) {
+	t.Helper()
+	var tunnels int32
+	credentials := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != credentials {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		atomic.AddInt32(&tunnels, 1)
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		pipe(conn, target)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL, &tunnels
+}
+
+// startSOCKS5Proxy starts a SOCKS5 proxy requiring user:password and records
+// the host of every CONNECT request
+func startSOCKS5Proxy(t *testing.T, user, password string) (string, func() []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	var hosts []string
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		read := func(n int) []byte {
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil
+			}
+			return buf
+		}
+
+		// Greeting, then username/password authentication (RFC 1929)
+		header := read(2)
+		if header == nil || read(int(header[1])) == nil {
+			return
+		}
+		conn.Write([]byte{5, 2})
+		version := read(2)
+		if version == nil {
+			return
+		}
+		gotUser := string(read(int(version[1])))
+		gotPassword := string(read(int(read(1)[0])))
+		if gotUser != user || gotPassword != password {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+
+		// CONNECT request
+		request := read(4)
+		if request == nil {
+			return
+		}
+		var host string
+		switch request[3] {
+		case 1:
+			host = net.IP(read(4)).String()
+		case 3:
+			host = string(read(int(read(1)[0])))
+		case 4:
+			host = net.IP(read(16)).String()
+		}
+		port := binary.BigEndian.Uint16(read(2))
+		mu.Lock()
+		hosts = append(hosts, host)
+		mu.Unlock()
+
+		target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+		if err != nil {
+			conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		pipe(conn, target)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), hosts...)
+	}
+}
+
+func TestHTTPChecker_ClientCertificateThroughProxy(t *testing.T) {
+	pki := newTestPKI(t)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.TLS = pki.serverConfig(true)
+	server.StartTLS()
+	defer server.Close()
+
+	proxyURL, tunnels := startConnectProxy(t, "monitor", "secret")
+
+	site := config.Site{
+		Name:     "internal",
+		URL:      server.URL,
+		Interval: "1m",
+		Timeout:  "5s",
+		TLS: &config.TLSConfig{
+			CAFile:     pki.caFile,
+			CertFile:   pki.clientCert,
+			KeyFile:    pki.clientKey,
+			ServerName: "localhost",
+			MinVersion: "1.3",
+		},
+		Proxy: &config.ProxyConfig{URL: proxyURL, Username: "monitor", Password: "secret"},
+	}
+	checker, err := NewFromSite(site)
+	if err != nil {
+		t.Fatalf("NewFromSite failed: %v", err)
+	}
+	if result := checker.Check(context.Background()); !result.Success {
+		t.Fatalf("Expected success through the proxy, got %q", result.Error)
+	}
+	if atomic.LoadInt32(tunnels) != 1 {
+		t.Errorf("Expected 1 tunnel, got %d", atomic.LoadInt32(tunnels))
+	}
+
+	// Without a client certificate the server rejects the handshake
+	site.TLS.CertFile, site.TLS.KeyFile = "", ""
+	checker, _ = NewFromSite(site)
+	if result := checker.Check(context.Background()); result.Success {
+		t.Error("Expected failure without a client certificate")
+	}
+
+	// Wrong proxy credentials fail before reaching the server
+	site.Proxy.Password = "wrong"
+	checker, _ = NewFromSite(site)
+	if result := checker.Check(context.Background()); result.Success || !strings.Contains(result.Error, "Proxy Authentication Required") {
+		t.Errorf("Expected a proxy authentication failure, got %q", result.Error)
+	}
+}
+
+func TestTCPChecker_SOCKS5(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			io.WriteString(conn, "SSH-2.0-OpenSSH_9.6\r\n")
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(target.Addr().String())
+
+	proxyAddress, hosts := startSOCKS5Proxy(t, "monitor", "secret")
+
+	tests := []struct {
+		scheme string
+		host   string // Host the proxy is asked to connect to
+	}{
+		{"socks5", "127.0.0.1"}, // Resolved locally
+		{"socks5h", "localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			checker, err := NewFromSite(config.Site{
+				Type:     "tcp",
+				URL:      "localhost:" + port,
+				Interval: "1m",
+				Timeout:  "5s",
+				Expect:   "SSH-2.0",
+				Proxy:    &config.ProxyConfig{URL: tt.scheme + "://monitor:secret@" + proxyAddress},
+			})
+			if err != nil {
+				t.Fatalf("NewFromSite failed: %v", err)
+			}
+			if result := checker.Check(context.Background()); !result.Success {
+				t.Fatalf("Expected success through the proxy, got %q", result.Error)
+			}
+			if got := hosts(); got[len(got)-1] != tt.host {
+				t.Errorf("Expected the proxy to connect to %s, got %v", tt.host, got)
+			}
+		})
+	}
+}
@@ -13,6 +13,38 @@ type Result struct {
 	Timestamp time.Time     `json:"timestamp"`
 	Success   bool          `json:"success"`
 	Error     string        `json:"error,omitempty"`
+	Attempts  int           `json:"attempts,omitempty"` // Checks made, including retries and confirmation
+
+	// Severity refines Success: warning results are successful but degraded,
+	// unknown results failed to determine the state. Empty means derived from Success.
+	Severity Severity `json:"severity,omitempty"`
+
+	// Metrics holds performance data reported by the check (exec checks)
+	Metrics []Metric `json:"metrics,omitempty"`
+
+	// Timings breaks Duration down into request phases (HTTP checks only)
+	Timings Timings `json:"timings"`
+
+	// Records holds the answer of a DNS check
+	Records []string `json:"records,omitempty"`
+
+	// Change describes how the probed target changed since the previous check
+	// (e.g. a new DNS answer set); empty when nothing changed
+	Change string `json:"change,omitempty"`
+
+	// Assertions holds the outcome of each response assertion, if any were configured
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+
+	// Steps holds the outcome of each transaction step, up to the first failure
+	Steps []StepResult `json:"steps,omitempty"`
+
+	// IPVersion is the address family the check was pinned to (v4 or v6),
+	// empty when it was not
+	IPVersion string `json:"ip_version,omitempty"`
+
+	// Families holds the result of each address family when a site is
+	// probed over both; the result itself is the worst of them
+	Families []Result `json:"families,omitempty"`
 }
 
 // String returns a formatted string representation of the result
@@ -20,12 +52,25 @@ func (r Result) String() string {
 	status := "✅ OK"
 	if !r.Success {
 		status = "❌ ERROR" // ← Corrigé (pas de := car on réassigne)
+	} else if r.GetSeverity() == SeverityWarning {
+		status = "⚠️ WARNING"
 	}
 
-	return fmt.Sprintf("[%s] %s (%s) - Status: %d - Duration: %v",
+	line := fmt.Sprintf("[%s] %s (%s) - Status: %d - Duration: %v",
 		r.Timestamp.Format("15:04:05"),
 		status,
 		r.Name,
 		r.Status,
 		r.Duration)
+	if r.IPVersion != "" {
+		line += " - " + familyNames[r.IPVersion]
+	}
+	for _, family := range r.Families {
+		mark := "✅"
+		if !family.Success {
+			mark = "❌"
+		}
+		line += " - " + familyNames[family.IPVersion] + " " + mark
+	}
+	return line
 }
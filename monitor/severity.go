@@ -0,0 +1,24 @@
+package monitor
+
+// Severity qualifies a result beyond Success, for checks that can report
+// a degraded state (e.g. Nagios plugins)
+type Severity string
+
+const (
+	SeverityOK       Severity = "ok"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+	SeverityUnknown  Severity = "unknown"
+)
+
+// GetSeverity returns the result severity, derived from Success when the
+// checker did not set one
+func (r Result) GetSeverity() Severity {
+	if r.Severity != "" {
+		return r.Severity
+	}
+	if r.Success {
+		return SeverityOK
+	}
+	return SeverityCritical
+}
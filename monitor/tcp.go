@@ -0,0 +1,164 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"site-monitor/config"
+	"strings"
+	"time"
+)
+
+// maxTCPResponseBytes bounds how much is read while waiting for the expected response
+const maxTCPResponseBytes = 64 << 10
+
+func init() {
+	Register("tcp", newTCPCheckerFromSite)
+}
+
+// TCPChecker checks that a TCP port accepts connections and optionally
+// answers a payload with an expected response
+type TCPChecker struct {
+	Address     string // host:port
+	Timeout     time.Duration
+	Send        string         // Written once connected, if set
+	Expect      string         // Expected response prefix, if set
+	ExpectRegex *regexp.Regexp // Expected response pattern, if set
+	dial        DialContextFunc
+}
+
+// NewTCPChecker creates a TCP checker that only verifies the connection
+func NewTCPChecker(address string, timeout time.Duration) *TCPChecker {
+	return &TCPChecker{
+		Address: address,
+		Timeout: timeout,
+		dial:    (&net.Dialer{}).DialContext,
+	}
+}
+
+// newTCPCheckerFromSite builds a TCP checker from a site configuration
+func newTCPCheckerFromSite(site config.Site) (Checker, error) {
+	timeout, err := site.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	address := strings.TrimPrefix(site.URL, "tcp://")
+	if _, port, err := net.SplitHostPort(address); err != nil || strings.Contains(address, "/") {
+		return nil, fmt.Errorf("tcp check needs a host:port address, got %q", site.URL)
+	} else if _, err := net.LookupPort("tcp", port); err != nil {
+		return nil, fmt.Errorf("tcp check has an invalid port in %q: %w", site.URL, err)
+	}
+
+	c := NewTCPChecker(address, timeout)
+	if c.dial, err = newSiteDialer(site); err != nil {
+		return nil, err
+	}
+	c.Send = site.Send
+	c.Expect = site.Expect
+	if site.ExpectRegex != "" {
+		if c.ExpectRegex, err = regexp.Compile(site.ExpectRegex); err != nil {
+			return nil, fmt.Errorf("invalid expect_regex: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// Check dials the address and runs the optional send/expect exchange
+func (c *TCPChecker) Check(ctx context.Context) Result {
+	start := time.Now()
+
+	result := Result{
+		URL:       "tcp://" + c.Address,
+		Timestamp: start,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	conn, err := c.dial(ctx, "tcp", c.Address)
+	result.Timings.Connect = time.Since(start)
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	err = c.exchange(conn)
+	result.Duration = time.Since(start)
+	result.Timestamp = time.Now()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	return result
+}
+
+// exchange writes the payload and waits for the expected response
+func (c *TCPChecker) exchange(conn net.Conn) error {
+	if c.Send != "" {
+		if _, err := io.WriteString(conn, c.Send); err != nil {
+			return fmt.Errorf("failed to send payload: %w", err)
+		}
+	}
+
+	if c.Expect == "" && c.ExpectRegex == nil {
+		return nil
+	}
+
+	var response []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		response = append(response, buf[:n]...)
+
+		if c.matches(response) {
+			return nil
+		}
+		if c.Expect != "" && len(response) >= len(c.Expect) && c.ExpectRegex == nil {
+			return fmt.Errorf("unexpected response %q (expected prefix %q)", truncate(response, 64), c.Expect)
+		}
+		if len(response) >= maxTCPResponseBytes {
+			break
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+	}
+
+	return fmt.Errorf("unexpected response %q", truncate(response, 64))
+}
+
+// matches reports whether the response satisfies every configured expectation
+func (c *TCPChecker) matches(response []byte) bool {
+	if c.Expect != "" && !bytes.HasPrefix(response, []byte(c.Expect)) {
+		return false
+	}
+	if c.ExpectRegex != nil && !c.ExpectRegex.Match(response) {
+		return false
+	}
+	return true
+}
+
+// truncate shortens a response for error messages
+func truncate(data []byte, max int) string {
+	if len(data) > max {
+		return string(data[:max]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"site-monitor/config"
+	"strings"
+	"testing"
+)
+
+// startTCPServer runs a line-based echo server that greets clients first
+func startTCPServer(t *testing.T, greeting string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				if greeting != "" {
+					conn.Write([]byte(greeting))
+				}
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				conn.Write([]byte("+" + strings.TrimSpace(line) + "\r\n"))
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestTCPChecker(t *testing.T) {
+	address := startTCPServer(t, "")
+	banner := startTCPServer(t, "220 mail.example.com ESMTP\r\n")
+
+	tests := []struct {
+		name    string
+		site    config.Site
+		success bool
+	}{
+		{"connect only", config.Site{URL: address}, true},
+		{"tcp scheme", config.Site{URL: "tcp://" + address}, true},
+		{"send and expect prefix", config.Site{URL: address, Send: "PING\r\n", Expect: "+PONG"}, false},
+		{"send and expect echo", config.Site{URL: address, Send: "PONG\r\n", Expect: "+PONG"}, true},
+		{"expect regex", config.Site{URL: address, Send: "HELLO 42\r\n", ExpectRegex: `^\+HELLO \d+`}, true},
+		{"banner", config.Site{URL: banner, Expect: "220 "}, true},
+		{"wrong banner", config.Site{URL: banner, Expect: "421 "}, false},
+		{"closed port", config.Site{URL: closedAddress(t)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.site.Type = "tcp"
+			tt.site.Timeout = "2s"
+
+			checker, err := NewChecker(tt.site)
+			if err != nil {
+				t.Fatalf("NewChecker failed: %v", err)
+			}
+
+			result := checker.Check(context.Background())
+			if result.Success != tt.success {
+				t.Errorf("Expected success=%v, got %+v", tt.success, result)
+			}
+			if tt.success && result.Timings.Connect <= 0 {
+				t.Errorf("Expected connect latency to be recorded, got %+v", result.Timings)
+			}
+		})
+	}
+}
+
+func TestTCPChecker_InvalidAddress(t *testing.T) {
+	if _, err := NewChecker(config.Site{Type: "tcp", URL: "https://example.com", Timeout: "1s"}); err == nil {
+		t.Error("Expected error for address without port")
+	}
+}
+
+// closedAddress returns an address nothing is listening on
+func closedAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings breaks an HTTP check down into its phases. Phases that did not
+// happen (e.g. DNS and connect on a reused connection) are zero.
+// When redirects are followed, each phase is summed across all hops.
+// WebSocket checks report the upgrade response as TTFB and the wait for
+// the first message as Transfer; mail checks report the banner as TTFB and
+// the command exchanges as Transfer.
+type Timings struct {
+	DNS      time.Duration `json:"dns"`      // Name resolution
+	Connect  time.Duration `json:"connect"`  // TCP connection establishment
+	TLS      time.Duration `json:"tls"`      // TLS handshake
+	TTFB     time.Duration `json:"ttfb"`     // Connection ready to first response byte
+	Transfer time.Duration `json:"transfer"` // First response byte to end of body
+}
+
+// add returns the phase-by-phase sum of two timings
+func (t Timings) add(other Timings) Timings {
+	return Timings{
+		DNS:      t.DNS + other.DNS,
+		Connect:  t.Connect + other.Connect,
+		TLS:      t.TLS + other.TLS,
+		TTFB:     t.TTFB + other.TTFB,
+		Transfer: t.Transfer + other.Transfer,
+	}
+}
+
+// timingTrace collects Timings through httptrace hooks. Hooks may fire
+// from the dialer's goroutines, hence the mutex.
+type timingTrace struct {
+	mu      sync.Mutex
+	timings Timings
+
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	gotConn      time.Time
+	firstByte    time.Time
+}
+
+// clientTrace returns the httptrace hooks feeding this trace
+func (t *timingTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if !t.dnsStart.IsZero() {
+				t.timings.DNS += time.Since(t.dnsStart)
+				t.dnsStart = time.Time{}
+			}
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// Dual-stack dialing may race several connects; time from the first
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil && !t.connectStart.IsZero() {
+				t.timings.Connect += time.Since(t.connectStart)
+				t.connectStart = time.Time{}
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if !t.tlsStart.IsZero() {
+				t.timings.TLS += time.Since(t.tlsStart)
+				t.tlsStart = time.Time{}
+			}
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.closeTransfer(time.Now()) // A new hop ends the previous response
+			t.gotConn = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Now()
+			if !t.gotConn.IsZero() {
+				t.timings.TTFB += t.firstByte.Sub(t.gotConn)
+				t.gotConn = time.Time{}
+			}
+		},
+	}
+}
+
+// done closes the last phase once the response body has been read and
+// returns the collected timings
+func (t *timingTrace) done() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeTransfer(time.Now())
+	return t.timings
+}
+
+// closeTransfer accounts for the body transfer of the current response
+func (t *timingTrace) closeTransfer(now time.Time) {
+	if !t.firstByte.IsZero() {
+		t.timings.Transfer += now.Sub(t.firstByte)
+		t.firstByte = time.Time{}
+	}
+}
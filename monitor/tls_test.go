@@ -0,0 +1,147 @@
+package monitor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"site-monitor/config"
+	"testing"
+	"time"
+)
+
+// testPKI is a throwaway CA with a server and a client certificate
+type testPKI struct {
+	caPool     *x509.CertPool
+	server     tls.Certificate
+	caFile     string
+	clientCert string
+	clientKey  string
+}
+
+// newTestPKI generates a CA, a server certificate for 127.0.0.1/localhost
+// and a client certificate, writing the files mTLS clients need to a temp dir
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("Failed to issue certificate: %v", err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	serverCert, serverKey := issue(2, x509.ExtKeyUsageServerAuth)
+	server, err := tls.X509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatalf("Failed to load server certificate: %v", err)
+	}
+	clientCert, clientKey := issue(3, x509.ExtKeyUsageClientAuth)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return &testPKI{
+		caPool:     pool,
+		server:     server,
+		caFile:     write("ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		clientCert: write("client.pem", clientCert),
+		clientKey:  write("client-key.pem", clientKey),
+	}
+}
+
+// serverConfig returns a server TLS config, requiring client certificates if mutual is set
+func (p *testPKI) serverConfig(mutual bool) *tls.Config {
+	cfg := &tls.Config{Certificates: []tls.Certificate{p.server}}
+	if mutual {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = p.caPool
+	}
+	return cfg
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	pki := newTestPKI(t)
+
+	cfg, err := NewTLSConfig(&config.TLSConfig{
+		CAFile:     pki.caFile,
+		CertFile:   pki.clientCert,
+		KeyFile:    pki.clientKey,
+		ServerName: "internal.example",
+		MinVersion: "1.3",
+	})
+	if err != nil {
+		t.Fatalf("NewTLSConfig failed: %v", err)
+	}
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 1 || cfg.ServerName != "internal.example" || cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("Unexpected TLS config %+v", cfg)
+	}
+
+	invalid := []config.TLSConfig{
+		{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		{CAFile: pki.clientKey}, // No certificate in it
+		{CertFile: pki.clientCert},
+		{MinVersion: "1.4"},
+	}
+	for _, tlsConfig := range invalid {
+		tlsConfig := tlsConfig
+		if _, err := NewTLSConfig(&tlsConfig); err == nil {
+			t.Errorf("Expected an error for %+v", tlsConfig)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"site-monitor/config"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("transaction", newTransactionCheckerFromSite)
+}
+
+// variablePattern matches {{name}} references to extracted variables
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// StepResult records the outcome of a single transaction step
+type StepResult struct {
+	Name       string            `json:"name"`
+	URL        string            `json:"url"`
+	Status     int               `json:"status"`
+	Duration   time.Duration     `json:"duration"`
+	Success    bool              `json:"success"`
+	Error      string            `json:"error,omitempty"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+}
+
+// transactionStep is a validated, ready-to-run step
+type transactionStep struct {
+	config.StepConfig
+	assertions []assertion
+	extractors []extractor
+}
+
+// extractor is a validated variable extraction
+type extractor struct {
+	config.ExtractConfig
+	regex *regexp.Regexp
+	path  []pathSegment
+}
+
+// TransactionChecker runs ordered HTTP steps as one check. Steps share a
+// cookie jar and the variables extracted so far; the first failing step
+// ends the transaction.
+type TransactionChecker struct {
+	URL             string            // Reported URL (the site URL, or the first step's)
+	Headers         map[string]string // Headers sent with every step
+	Auth            *config.AuthConfig
+	Timeout         time.Duration // Per-step request timeout
+	FollowRedirects bool
+	MaxRedirects    int
+	steps           []transactionStep
+	transport       http.RoundTripper // Nil uses the default transport
+}
+
+// newTransactionCheckerFromSite builds a transaction checker from a site configuration
+func newTransactionCheckerFromSite(site config.Site) (Checker, error) {
+	timeout, err := site.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAuth(site.Auth); err != nil {
+		return nil, err
+	}
+
+	steps, err := compileSteps(site.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &TransactionChecker{
+		URL:             site.URL,
+		Headers:         site.Headers,
+		Auth:            site.Auth,
+		Timeout:         timeout,
+		FollowRedirects: site.ShouldFollowRedirects(),
+		MaxRedirects:    site.GetMaxRedirects(),
+		steps:           steps,
+	}
+	if c.URL == "" {
+		c.URL = steps[0].URL
+	}
+
+	transport, err := newHTTPTransport(site)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		c.transport = transport
+	}
+
+	return c, nil
+}
+
+// compileSteps validates step configs, including that every {{variable}}
+// is extracted by an earlier step
+func compileSteps(configs []config.StepConfig) ([]transactionStep, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("transaction needs at least one step")
+	}
+
+	defined := make(map[string]bool)
+	steps := make([]transactionStep, 0, len(configs))
+
+	for i, cfg := range configs {
+		step := transactionStep{StepConfig: cfg}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step %d", i+1)
+		}
+		step.Method = strings.ToUpper(step.Method)
+
+		wrap := func(err error) error {
+			return fmt.Errorf("step %d (%s): %w", i+1, step.Name, err)
+		}
+
+		if step.URL == "" {
+			return nil, wrap(fmt.Errorf("url is required"))
+		}
+
+		templates := []string{step.URL, step.Body}
+		for key, value := range step.Headers {
+			templates = append(templates, key, value)
+		}
+		for _, template := range templates {
+			for _, match := range variablePattern.FindAllStringSubmatch(template, -1) {
+				if !defined[match[1]] {
+					return nil, wrap(fmt.Errorf("variable %q is not extracted by an earlier step", match[1]))
+				}
+			}
+		}
+
+		assertions, err := compileAssertions(step.StepConfig.Assertions)
+		if err != nil {
+			return nil, wrap(err)
+		}
+		step.assertions = assertions
+
+		for j, ex := range step.Extract {
+			compiled, err := compileExtractor(ex)
+			if err != nil {
+				return nil, wrap(fmt.Errorf("extract %d: %w", j, err))
+			}
+			step.extractors = append(step.extractors, compiled)
+		}
+
+		// Variables only become visible to later steps
+		for _, ex := range step.extractors {
+			defined[ex.Var] = true
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// compileExtractor validates an extraction config
+func compileExtractor(cfg config.ExtractConfig) (extractor, error) {
+	ex := extractor{ExtractConfig: cfg}
+
+	if !variablePattern.MatchString("{{" + cfg.Var + "}}") {
+		return ex, fmt.Errorf("invalid variable name %q", cfg.Var)
+	}
+
+	switch cfg.Type {
+	case "json_path":
+		path, err := parseJSONPath(cfg.Path)
+		if err != nil {
+			return ex, err
+		}
+		ex.path = path
+	case "regex":
+		re, err := regexp.Compile(cfg.Value)
+		if err != nil {
+			return ex, err
+		}
+		ex.regex = re
+	case "header", "cookie":
+		if cfg.Value == "" {
+			return ex, fmt.Errorf("%s name is required in value", cfg.Type)
+		}
+	default:
+		return ex, fmt.Errorf("unknown type %q (expected json_path, regex, header or cookie)", cfg.Type)
+	}
+
+	return ex, nil
+}
+
+// Check runs every step in order, stopping at the first failure
+func (c *TransactionChecker) Check(ctx context.Context) Result {
+	start := time.Now()
+
+	result := Result{
+		URL:       c.URL,
+		Timestamp: start,
+	}
+
+	// Each run starts a fresh session
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	client := &http.Client{
+		Timeout:       c.Timeout,
+		Transport:     c.transport,
+		Jar:           jar,
+		CheckRedirect: redirectPolicy(c.FollowRedirects, c.MaxRedirects),
+	}
+
+	vars := make(map[string]string)
+	for i, step := range c.steps {
+		stepResult, timings := c.runStep(ctx, client, step, vars)
+		result.Steps = append(result.Steps, stepResult)
+		result.Status = stepResult.Status
+		result.Timings = result.Timings.add(timings)
+
+		if !stepResult.Success {
+			result.Error = fmt.Sprintf("step %d (%s): %s", i+1, step.Name, stepResult.Error)
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+	result.Timestamp = time.Now()
+	result.Success = result.Error == ""
+
+	return result
+}
+
+// runStep sends one step's request and extracts its variables into vars
+func (c *TransactionChecker) runStep(ctx context.Context, client *http.Client, step transactionStep, vars map[string]string) (StepResult, Timings) {
+	expand := func(template string) string {
+		return variablePattern.ReplaceAllStringFunc(template, func(match string) string {
+			return vars[variablePattern.FindStringSubmatch(match)[1]]
+		})
+	}
+
+	headers := make(map[string]string, len(c.Headers)+len(step.Headers))
+	for key, value := range c.Headers {
+		headers[key] = value
+	}
+	for key, value := range step.Headers {
+		headers[expand(key)] = expand(value)
+	}
+
+	hc := &HTTPChecker{
+		URL:                 expand(step.URL),
+		Method:              step.Method,
+		Headers:             headers,
+		Body:                expand(step.Body),
+		Auth:                c.Auth,
+		ExpectedStatusCodes: step.ExpectedStatusCodes,
+		assertions:          step.assertions,
+		client:              client,
+	}
+
+	checked, resp := hc.check(ctx, len(step.assertions) > 0 || len(step.extractors) > 0)
+	stepResult := StepResult{
+		Name:       step.Name,
+		URL:        hc.URL,
+		Status:     checked.Status,
+		Duration:   checked.Duration,
+		Success:    checked.Success,
+		Error:      checked.Error,
+		Assertions: checked.Assertions,
+	}
+	if !stepResult.Success {
+		return stepResult, checked.Timings
+	}
+
+	for _, ex := range step.extractors {
+		value, err := ex.extract(resp, client.Jar, hc.URL)
+		if err != nil {
+			stepResult.Success = false
+			stepResult.Error = fmt.Sprintf("extract %s: %v", ex.Var, err)
+			break
+		}
+		vars[ex.Var] = value
+	}
+
+	return stepResult, checked.Timings
+}
+
+// extract reads the variable's value from a step response
+func (ex extractor) extract(resp *response, jar http.CookieJar, rawURL string) (string, error) {
+	switch ex.Type {
+	case "json_path":
+		var document interface{}
+		if err := json.Unmarshal(resp.body, &document); err != nil {
+			return "", fmt.Errorf("body is not valid JSON: %v", err)
+		}
+		value, ok := lookupJSONPath(document, ex.path)
+		if !ok {
+			return "", fmt.Errorf("%s not found", ex.Path)
+		}
+		if s, isString := value.(string); isString {
+			return s, nil
+		}
+		return formatJSON(value), nil
+
+	case "regex":
+		match := ex.regex.FindSubmatch(resp.body)
+		if match == nil {
+			return "", fmt.Errorf("body does not match /%s/", ex.Value)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+
+	case "header":
+		values := resp.header.Values(ex.Value)
+		if len(values) == 0 {
+			return "", fmt.Errorf("header %s not present", ex.Value)
+		}
+		return values[0], nil
+
+	case "cookie":
+		// Cookies set on the final response first, then any the session holds
+		for _, cookie := range resp.cookies {
+			if cookie.Name == ex.Value {
+				return cookie.Value, nil
+			}
+		}
+		if u, err := url.Parse(rawURL); err == nil {
+			for _, cookie := range jar.Cookies(u) {
+				if cookie.Name == ex.Value {
+					return cookie.Value, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("cookie %s not set", ex.Value)
+	}
+
+	return "", fmt.Errorf("unknown type %q", ex.Type)
+}
@@ -0,0 +1,179 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"site-monitor/config"
+	"strings"
+	"testing"
+)
+
+// newLoginServer serves a login -> profile -> logout flow guarded by a
+// bearer token from the login body and a session cookie
+func newLoginServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t", Path: "/"})
+		w.Header().Set("X-Request-Id", "req-42")
+		json.NewEncoder(w).Encode(map[string]interface{}{"token": "abc123", "user": map[string]int{"id": 7}})
+	})
+	mux.HandleFunc("/users/7", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "s3cr3t" || r.Header.Get("Authorization") != "Bearer abc123" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`<p>Welcome back, <b>alice</b></p>`))
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "alice" || r.Header.Get("X-Request-Id") != "req-42" {
+			http.Error(w, "bad logout", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func loginSteps(base string) []config.StepConfig {
+	return []config.StepConfig{
+		{
+			Name:   "login",
+			URL:    base + "/login",
+			Method: "post",
+			Body:   `{"user":"alice"}`,
+			Extract: []config.ExtractConfig{
+				{Var: "token", Type: "json_path", Path: "$.token"},
+				{Var: "user_id", Type: "json_path", Path: "$.user.id"},
+				{Var: "request_id", Type: "header", Value: "X-Request-Id"},
+				{Var: "session", Type: "cookie", Value: "session"},
+			},
+		},
+		{
+			Name:       "profile",
+			URL:        base + "/users/{{user_id}}",
+			Headers:    map[string]string{"Authorization": "Bearer {{ token }}"},
+			Assertions: []config.AssertionConfig{{Type: "contains", Value: "Welcome back"}},
+			Extract:    []config.ExtractConfig{{Var: "name", Type: "regex", Value: `<b>(\w+)</b>`}},
+		},
+		{
+			Name:                "logout",
+			URL:                 base + "/logout?name={{name}}",
+			Headers:             map[string]string{"X-Request-Id": "{{request_id}}"},
+			ExpectedStatusCodes: []int{204},
+		},
+	}
+}
+
+func TestTransactionChecker_Flow(t *testing.T) {
+	server := newLoginServer(t)
+
+	checker, err := NewChecker(config.Site{Name: "login", Type: "transaction", Timeout: "5s", Steps: loginSteps(server.URL)})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+
+	result := checker.Check(context.Background())
+	if !result.Success {
+		t.Fatalf("Expected transaction to succeed, got %q (steps: %+v)", result.Error, result.Steps)
+	}
+	if result.URL != server.URL+"/login" {
+		t.Errorf("Expected the first step URL, got %s", result.URL)
+	}
+	if result.Status != http.StatusNoContent {
+		t.Errorf("Expected the last step status, got %d", result.Status)
+	}
+
+	if len(result.Steps) != 3 {
+		t.Fatalf("Expected 3 step results, got %d", len(result.Steps))
+	}
+	if result.Steps[1].URL != server.URL+"/users/7" {
+		t.Errorf("Expected the expanded URL, got %s", result.Steps[1].URL)
+	}
+	for _, step := range result.Steps {
+		if !step.Success || step.Duration <= 0 {
+			t.Errorf("Unexpected step result %+v", step)
+		}
+	}
+}
+
+func TestTransactionChecker_StopsAtFailingStep(t *testing.T) {
+	server := newLoginServer(t)
+
+	steps := loginSteps(server.URL)
+	steps[1].Assertions = []config.AssertionConfig{{Type: "contains", Value: "Goodbye"}}
+
+	checker, err := NewChecker(config.Site{Name: "login", Type: "transaction", Timeout: "5s", Steps: steps})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+
+	result := checker.Check(context.Background())
+	if result.Success {
+		t.Fatal("Expected transaction to fail")
+	}
+	if len(result.Steps) != 2 {
+		t.Fatalf("Expected the run to stop after 2 steps, got %d", len(result.Steps))
+	}
+	if result.Steps[1].Success || len(result.Steps[1].Assertions) != 1 {
+		t.Errorf("Expected the profile step to fail its assertion, got %+v", result.Steps[1])
+	}
+	if !strings.HasPrefix(result.Error, "step 2 (profile): assertion failed") {
+		t.Errorf("Expected the error to name the step, got %q", result.Error)
+	}
+}
+
+func TestTransactionChecker_ExtractFailure(t *testing.T) {
+	server := newLoginServer(t)
+
+	steps := loginSteps(server.URL)[:1]
+	steps[0].Extract = []config.ExtractConfig{{Var: "missing", Type: "cookie", Value: "nope"}}
+
+	checker, err := NewChecker(config.Site{Name: "login", Type: "transaction", Timeout: "5s", Steps: steps})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+
+	result := checker.Check(context.Background())
+	if result.Success || result.Error != "step 1 (login): extract missing: cookie nope not set" {
+		t.Errorf("Expected an extraction failure, got %q", result.Error)
+	}
+}
+
+func TestTransactionChecker_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []config.StepConfig
+	}{
+		{"no steps", nil},
+		{"missing url", []config.StepConfig{{Name: "a"}}},
+		{"undefined variable", []config.StepConfig{{URL: "http://example.com/{{token}}"}}},
+		{"variable from same step", []config.StepConfig{{
+			URL:     "http://example.com/{{token}}",
+			Extract: []config.ExtractConfig{{Var: "token", Type: "header", Value: "X-Token"}},
+		}}},
+		{"unknown extract type", []config.StepConfig{{URL: "http://example.com", Extract: []config.ExtractConfig{{Var: "a", Type: "xpath"}}}}},
+		{"invalid variable name", []config.StepConfig{{URL: "http://example.com", Extract: []config.ExtractConfig{{Var: "a-b", Type: "header", Value: "X"}}}}},
+		{"bad regex", []config.StepConfig{{URL: "http://example.com", Extract: []config.ExtractConfig{{Var: "a", Type: "regex", Value: "("}}}}},
+		{"bad assertion", []config.StepConfig{{URL: "http://example.com", Assertions: []config.AssertionConfig{{Type: "bogus"}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewChecker(config.Site{Name: "tx", Type: "transaction", Steps: tt.steps}); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"site-monitor/config"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	Register("websocket", newWebSocketCheckerFromSite)
+}
+
+// WebSocketChecker performs a WebSocket upgrade and, when a message is
+// sent or assertions are configured, waits for the first message
+type WebSocketChecker struct {
+	URL        string            // ws:// or wss:// URL
+	Headers    map[string]string // Extra handshake headers
+	Timeout    time.Duration
+	Send       string // Text message sent once connected, if set
+	assertions []assertion
+	dialer     *websocket.Dialer
+}
+
+// NewWebSocketChecker creates a checker that only verifies the upgrade
+func NewWebSocketChecker(url string, timeout time.Duration) *WebSocketChecker {
+	return &WebSocketChecker{
+		URL:     url,
+		Timeout: timeout,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: timeout,
+		},
+	}
+}
+
+// newWebSocketCheckerFromSite builds a WebSocket checker from a site configuration
+func newWebSocketCheckerFromSite(site config.Site) (Checker, error) {
+	timeout, err := site.GetTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(site.URL)
+	if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		return nil, fmt.Errorf("websocket check needs a ws:// or wss:// URL, got %q", site.URL)
+	}
+
+	assertions, err := compileAssertions(site.Assertions)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewWebSocketChecker(site.URL, timeout)
+	c.Headers = site.Headers
+	c.Send = site.Send
+	c.assertions = assertions
+
+	if site.Proxy != nil || isPinned(site) {
+		dial, err := newSiteDialer(site)
+		if err != nil {
+			return nil, err
+		}
+		c.dialer.Proxy = nil
+		c.dialer.NetDialContext = dial
+	}
+
+	if u.Scheme == "wss" {
+		if c.dialer.TLSClientConfig, err = NewTLSConfig(site.TLS); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Check performs the upgrade and the optional message exchange
+func (c *WebSocketChecker) Check(ctx context.Context) Result {
+	start := time.Now()
+
+	result := Result{
+		URL:       c.URL,
+		Timestamp: start,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	header := http.Header{}
+	header.Set("User-Agent", "SiteMonitor/1.0")
+	for key, value := range c.Headers {
+		header.Set(key, value)
+	}
+
+	trace := &timingTrace{}
+	conn, resp, err := c.dialer.DialContext(httptrace.WithClientTrace(ctx, trace.clientTrace()), c.URL, header)
+	result.Timings = handshakeTimings(trace.done(), time.Since(start))
+	if resp != nil {
+		result.Status = resp.StatusCode
+	}
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Timestamp = time.Now()
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			result.Error = fmt.Sprintf("upgrade refused with status %d", resp.StatusCode)
+		} else {
+			result.Error = fmt.Sprintf("handshake failed: %v", err)
+		}
+		return result
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes if the check is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadLimit(maxBodyBytes)
+	conn.SetWriteDeadline(deadline)
+	conn.SetReadDeadline(deadline)
+
+	if c.Send == "" && len(c.assertions) == 0 {
+		result.Duration = time.Since(start)
+		result.Timestamp = time.Now()
+		result.Success = true
+		closeWebSocket(conn)
+		return result
+	}
+
+	messageStart := time.Now()
+	if c.Send != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(c.Send)); err != nil {
+			result.Duration = time.Since(start)
+			result.Timestamp = time.Now()
+			result.Error = fmt.Sprintf("failed to send message: %v", err)
+			return result
+		}
+	}
+
+	_, message, err := conn.ReadMessage()
+	result.Timings.Transfer = time.Since(messageStart)
+	result.Duration = time.Since(start)
+	result.Timestamp = time.Now()
+	if err != nil {
+		var netErr net.Error
+		if (errors.As(err, &netErr) && netErr.Timeout()) || ctx.Err() != nil {
+			result.Error = fmt.Sprintf("no message received within %v", c.Timeout)
+		} else {
+			result.Error = fmt.Sprintf("failed to read message: %v", err)
+		}
+		return result
+	}
+
+	result.Success = true
+	if len(c.assertions) > 0 {
+		var failure string
+		result.Assertions, failure = evaluateAssertions(c.assertions, message, int64(len(message)))
+		if failure != "" {
+			result.Success = false
+			result.Error = "assertion failed: " + failure
+		}
+	}
+
+	closeWebSocket(conn)
+	return result
+}
+
+// handshakeTimings fills TTFB with the wait for the upgrade response: the
+// dialer reports the connection before the TLS handshake, so it is derived
+// from the total handshake time instead
+func handshakeTimings(timings Timings, handshake time.Duration) Timings {
+	timings.TTFB = handshake - timings.DNS - timings.Connect - timings.TLS
+	if timings.TTFB < 0 {
+		timings.TTFB = 0
+	}
+	timings.Transfer = 0
+	return timings
+}
+
+// closeWebSocket sends a normal close frame before the connection is dropped
+func closeWebSocket(conn *websocket.Conn) {
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+}
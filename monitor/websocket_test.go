@@ -0,0 +1,194 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"site-monitor/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newWebSocketServer serves /echo, which echoes the first message,
+// /silent, which never replies, and /greet, which greets on connect
+func newWebSocketServer(t *testing.T, tls bool, pki *testPKI) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(messageType, message)
+		conn.ReadMessage() // Wait for the close frame
+	})
+	mux.HandleFunc("/silent", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/greet", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"welcome","version":3}`))
+		conn.ReadMessage()
+	})
+
+	server := httptest.NewUnstartedServer(mux)
+	if tls {
+		server.TLS = pki.serverConfig(false)
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func wsURL(server *httptest.Server, path string) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http") + path
+}
+
+func TestWebSocketChecker_Upgrade(t *testing.T) {
+	server := newWebSocketServer(t, false, nil)
+
+	checker, err := NewChecker(config.Site{Type: "websocket", URL: wsURL(server, "/silent"), Timeout: "5s"})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+
+	result := checker.Check(context.Background())
+	if !result.Success {
+		t.Fatalf("Expected upgrade to succeed, got %q", result.Error)
+	}
+	if result.Status != http.StatusSwitchingProtocols {
+		t.Errorf("Expected status 101, got %d", result.Status)
+	}
+	if result.Timings.TTFB <= 0 || result.Timings.Transfer != 0 {
+		t.Errorf("Expected only handshake timings, got %+v", result.Timings)
+	}
+}
+
+func TestWebSocketChecker_SendAndAssert(t *testing.T) {
+	server := newWebSocketServer(t, false, nil)
+
+	site := config.Site{
+		Type:       "websocket",
+		URL:        wsURL(server, "/echo"),
+		Timeout:    "5s",
+		Send:       `{"ping":1}`,
+		Assertions: []config.AssertionConfig{{Type: "json_path", Path: "$.ping", Value: "1"}},
+	}
+	checker, err := NewChecker(site)
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+
+	result := checker.Check(context.Background())
+	if !result.Success {
+		t.Fatalf("Expected echo to pass, got %q", result.Error)
+	}
+	if len(result.Assertions) != 1 || result.Timings.Transfer <= 0 {
+		t.Errorf("Expected assertion results and first-message latency, got %+v", result)
+	}
+
+	site.Assertions[0].Value = "2"
+	checker, _ = NewChecker(site)
+	if result := checker.Check(context.Background()); result.Success || !strings.HasPrefix(result.Error, "assertion failed") {
+		t.Errorf("Expected an assertion failure, got %q", result.Error)
+	}
+}
+
+func TestWebSocketChecker_FirstMessageWithoutSend(t *testing.T) {
+	server := newWebSocketServer(t, false, nil)
+
+	site := config.Site{
+		Type:       "websocket",
+		URL:        wsURL(server, "/greet"),
+		Timeout:    "5s",
+		Headers:    map[string]string{"Authorization": "Bearer secret"},
+		Assertions: []config.AssertionConfig{{Type: "contains", Value: "welcome"}},
+	}
+	checker, err := NewChecker(site)
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	if result := checker.Check(context.Background()); !result.Success {
+		t.Errorf("Expected greeting to pass, got %q", result.Error)
+	}
+
+	site.Headers = nil
+	checker, _ = NewChecker(site)
+	result := checker.Check(context.Background())
+	if result.Success || result.Status != http.StatusUnauthorized || result.Error != "upgrade refused with status 401" {
+		t.Errorf("Expected a refused upgrade, got %d %q", result.Status, result.Error)
+	}
+}
+
+func TestWebSocketChecker_NoReply(t *testing.T) {
+	server := newWebSocketServer(t, false, nil)
+
+	checker := NewWebSocketChecker(wsURL(server, "/silent"), 200*time.Millisecond)
+	checker.Send = "hello"
+
+	result := checker.Check(context.Background())
+	if result.Success || !strings.HasPrefix(result.Error, "no message received") {
+		t.Errorf("Expected a timeout, got %q", result.Error)
+	}
+}
+
+func TestWebSocketChecker_TLS(t *testing.T) {
+	pki := newTestPKI(t)
+	server := newWebSocketServer(t, true, pki)
+
+	checker, err := NewChecker(config.Site{
+		Type:    "websocket",
+		URL:     wsURL(server, "/echo"),
+		Timeout: "5s",
+		Send:    "hi",
+		TLS:     &config.TLSConfig{CAFile: pki.caFile},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+
+	result := checker.Check(context.Background())
+	if !result.Success {
+		t.Fatalf("Expected wss check to succeed, got %q", result.Error)
+	}
+	if result.Timings.TLS <= 0 {
+		t.Errorf("Expected a TLS handshake timing, got %+v", result.Timings)
+	}
+}
+
+func TestWebSocketChecker_InvalidURL(t *testing.T) {
+	for _, url := range []string{"", "http://example.com/ws", "ws://"} {
+		if _, err := NewChecker(config.Site{Type: "websocket", URL: url}); err == nil {
+			t.Errorf("Expected an error for %q", url)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"site-monitor/monitor"
+	"sync"
+)
+
+// Handler processes a single monitoring result. The context is cancelled
+// when the pipeline's drain deadline expires during shutdown.
+type Handler func(ctx context.Context, result monitor.Result) error
+
+// Pipeline fans out monitoring results to all registered subscribers.
+// Each subscriber owns a buffered queue and a dedicated goroutine, so a slow
+// subscriber (e.g. a webhook being retried) never delays the others.
+type Pipeline struct {
+	subscribers []*subscriber
+	bufferSize  int
+	started     bool
+	done        chan struct{} // Closed by Shutdown
+	closeOnce   sync.Once
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mu          sync.RWMutex
+	wg          sync.WaitGroup
+}
+
+// subscriber is a named consumer of the pipeline
+type subscriber struct {
+	name    string
+	handler Handler
+	queue   chan monitor.Result
+}
+
+// New creates a new result pipeline with the given per-subscriber buffer size
+func New(bufferSize int) *Pipeline {
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pipeline{
+		bufferSize: bufferSize,
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Subscribe registers a handler that receives every published result.
+// Subscribers must be registered before the pipeline is started.
+func (p *Pipeline) Subscribe(name string, handler Handler) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started {
+		return fmt.Errorf("cannot subscribe %s: pipeline already started", name)
+	}
+
+	p.subscribers = append(p.subscribers, &subscriber{
+		name:    name,
+		handler: handler,
+		queue:   make(chan monitor.Result, p.bufferSize),
+	})
+	return nil
+}
+
+// Start launches one dispatch goroutine per subscriber
+func (p *Pipeline) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started {
+		return
+	}
+	p.started = true
+
+	for _, sub := range p.subscribers {
+		p.wg.Add(1)
+		go p.dispatch(sub)
+	}
+}
+
+// Publish sends a result to every subscriber. It blocks while a subscriber
+// queue is full, applying backpressure to the producer instead of dropping
+// data, until the pipeline is shut down.
+func (p *Pipeline) Publish(result monitor.Result) {
+	// Subscribers are fixed once started, so the lock is not held while
+	// blocking on a full queue
+	p.mu.RLock()
+	subscribers := p.subscribers
+	p.mu.RUnlock()
+
+	for _, sub := range subscribers {
+		select {
+		case <-p.done:
+			log.Printf("⚠️ Pipeline closed, dropping result for %s", result.Name)
+			return
+		default:
+		}
+
+		select {
+		case sub.queue <- result:
+		case <-p.done:
+			log.Printf("⚠️ Pipeline closed, dropping result for %s", result.Name)
+			return
+		}
+	}
+}
+
+// Shutdown stops accepting new results and waits until every queued result
+// has been handled. If ctx expires first, the context passed to handlers is
+// cancelled so in-progress work (e.g. webhook retries) aborts, and ctx's
+// error is returned. Publishers blocked on a full queue return at once.
+func (p *Pipeline) Shutdown(ctx context.Context) error {
+	p.closeOnce.Do(func() { close(p.done) })
+
+	drained := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+
+	defer p.cancel()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("pipeline drain interrupted: %w", ctx.Err())
+	}
+}
+
+// dispatch delivers queued results to a single subscriber until the
+// pipeline is shut down and its queue is empty
+func (p *Pipeline) dispatch(sub *subscriber) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case result := <-sub.queue:
+			p.handle(sub, result)
+		case <-p.done:
+			for {
+				select {
+				case result := <-sub.queue:
+					p.handle(sub, result)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle passes a result to a subscriber, logging its failure
+func (p *Pipeline) handle(sub *subscriber, result monitor.Result) {
+	if err := sub.handler(p.ctx, result); err != nil {
+		log.Printf("⚠️ Subscriber %s failed to handle result for %s: %v", sub.name, result.Name, err)
+	}
+}
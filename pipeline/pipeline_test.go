@@ -0,0 +1,156 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"site-monitor/monitor"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPipeline_FansOutToAllSubscribers(t *testing.T) {
+	p := New(10)
+
+	var mu sync.Mutex
+	received := make(map[string][]string)
+
+	for _, name := range []string{"storage", "alerts"} {
+		name := name
+		err := p.Subscribe(name, func(ctx context.Context, result monitor.Result) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[name] = append(received[name], result.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Subscribe(%s) failed: %v", name, err)
+		}
+	}
+
+	p.Start()
+	for i := 0; i < 5; i++ {
+		p.Publish(monitor.Result{Name: fmt.Sprintf("site-%d", i)})
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	for _, name := range []string{"storage", "alerts"} {
+		if len(received[name]) != 5 {
+			t.Errorf("Subscriber %s received %d results, expected 5", name, len(received[name]))
+		}
+		for i, siteName := range received[name] {
+			if expected := fmt.Sprintf("site-%d", i); siteName != expected {
+				t.Errorf("Subscriber %s result %d: expected %s, got %s", name, i, expected, siteName)
+			}
+		}
+	}
+}
+
+func TestPipeline_HandlerErrorDoesNotStopDelivery(t *testing.T) {
+	p := New(10)
+
+	count := 0
+	if err := p.Subscribe("failing", func(ctx context.Context, result monitor.Result) error {
+		count++
+		return fmt.Errorf("boom")
+	}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	p.Start()
+	p.Publish(monitor.Result{Name: "a"})
+	p.Publish(monitor.Result{Name: "b"})
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 deliveries, got %d", count)
+	}
+}
+
+func TestPipeline_SubscribeAfterStart(t *testing.T) {
+	p := New(1)
+	p.Start()
+	defer p.Shutdown(context.Background())
+
+	if err := p.Subscribe("late", func(ctx context.Context, result monitor.Result) error { return nil }); err == nil {
+		t.Error("Expected error when subscribing after start")
+	}
+}
+
+func TestPipeline_ShutdownCancelsHandlersAfterDeadline(t *testing.T) {
+	p := New(1)
+
+	aborted := make(chan struct{})
+	if err := p.Subscribe("slow", func(ctx context.Context, result monitor.Result) error {
+		<-ctx.Done()
+		close(aborted)
+		return ctx.Err()
+	}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	p.Start()
+	p.Publish(monitor.Result{Name: "slow"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := p.Shutdown(ctx); err == nil {
+		t.Error("Expected drain timeout error")
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Error("Handler context was not cancelled after drain deadline")
+	}
+}
+
+func TestPipeline_ShutdownWithBlockedPublisher(t *testing.T) {
+	p := New(1)
+
+	// A subscriber stuck until its context is cancelled, e.g. retrying a webhook
+	if err := p.Subscribe("stuck", func(ctx context.Context, result monitor.Result) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	p.Start()
+
+	// The first result is being handled, the second fills the queue and the
+	// third blocks its publisher
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < 3; i++ {
+			p.Publish(monitor.Result{Name: "stuck"})
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- p.Shutdown(ctx) }()
+
+	select {
+	case err := <-shutdown:
+		if err == nil {
+			t.Error("Expected drain timeout error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not honor its deadline")
+	}
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Error("Expected the blocked publisher to return on shutdown")
+	}
+}
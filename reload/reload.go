@@ -0,0 +1,296 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"site-monitor/alerts"
+	"site-monitor/config"
+	"site-monitor/heartbeat"
+	"site-monitor/monitor"
+	"site-monitor/storage"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is how often the config file is polled for changes
+const DefaultWatchInterval = 2 * time.Second
+
+// Scheduler is the part of the check scheduler a reload drives
+type Scheduler interface {
+	Add(m *monitor.Monitor) error
+	Remove(name string) bool
+	Replace(m *monitor.Monitor) error
+}
+
+// Dashboard is the part of the dashboard server that serves the sites and
+// alert channels of the running configuration
+type Dashboard interface {
+	SetConfig(cfg *config.Config)
+}
+
+// Reloader applies configuration changes to a running daemon. Only the
+// sites that were added, removed or changed are touched, so unchanged checks
+// keep their schedule and every site keeps its alert state. A new
+// configuration is validated as a whole before anything is applied; if it
+// is invalid the running configuration stays in place.
+type Reloader struct {
+	scheduler Scheduler
+	storage   storage.Storage
+
+	current   *config.Config
+	alerts    *alerts.Manager // nil when alerting is not configured
+	dashboard Dashboard       // nil when the dashboard is not running
+	mu        sync.RWMutex
+}
+
+// New creates a reloader for a daemon already running cfg. The alert manager
+// is created here when cfg has an alerts section.
+func New(cfg *config.Config, scheduler Scheduler, store storage.Storage) *Reloader {
+	r := &Reloader{
+		scheduler: scheduler,
+		storage:   store,
+		current:   cfg,
+	}
+	if cfg.Alerts != nil {
+		r.alerts = alerts.NewManager(*cfg.Alerts, store)
+	}
+	return r
+}
+
+// ProcessResult forwards a result to the current alert manager. It is
+// subscribed to the result pipeline in place of the manager itself so that
+// alerting can be enabled or disabled by a reload.
+func (r *Reloader) ProcessResult(ctx context.Context, result monitor.Result) error {
+	r.mu.RLock()
+	manager := r.alerts
+	r.mu.RUnlock()
+
+	if manager == nil {
+		return nil
+	}
+	return manager.ProcessResult(ctx, result)
+}
+
+// SetDashboard registers the dashboard server to update on every reload
+func (r *Reloader) SetDashboard(dashboard Dashboard) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dashboard = dashboard
+}
+
+// Config returns the configuration currently applied
+func (r *Reloader) Config() *config.Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// Apply validates cfg and applies the differences with the running
+// configuration. Nothing is applied when an error is returned. Sites the
+// scheduler fails to take keep their previous definition, so the next
+// reload retries them.
+func (r *Reloader) Apply(cfg *config.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.Alerts != nil {
+		if err := alerts.ValidateTemplates(*cfg.Alerts); err != nil {
+			return err
+		}
+	}
+
+	changes := config.DiffSites(r.current.Sites, cfg.Sites)
+
+	// Build every new monitor first so a single bad site rejects the reload
+	monitors, err := buildMonitors(changes, r.storage)
+	if err != nil {
+		return err
+	}
+
+	warnRestartRequired(r.current, cfg)
+
+	for _, site := range changes.Removed {
+		r.scheduler.Remove(site.Name)
+		if r.alerts != nil {
+			r.alerts.Forget(site.Name)
+		}
+		log.Printf("➖ Removed %s", site.Name)
+	}
+	failed := make(map[string]bool)
+	for _, site := range changes.Changed {
+		if err := r.scheduler.Replace(monitors[site.Name]); err != nil {
+			log.Printf("❌ Failed to reschedule %s: %v", site.Name, err)
+			failed[site.Name] = true
+			continue
+		}
+		log.Printf("🔄 Updated %s", site.Name)
+	}
+	for _, site := range changes.Added {
+		if err := r.scheduler.Add(monitors[site.Name]); err != nil {
+			log.Printf("❌ Failed to schedule %s: %v", site.Name, err)
+			failed[site.Name] = true
+			continue
+		}
+		log.Printf("➕ Added %s", site.Name)
+	}
+
+	r.applyAlerts(cfg.Alerts)
+	r.current = appliedConfig(r.current, cfg, failed)
+	if r.dashboard != nil {
+		r.dashboard.SetConfig(r.current)
+	}
+
+	if changes.Empty() {
+		log.Printf("🔁 Configuration reloaded, no site changes")
+	} else {
+		log.Printf("🔁 Configuration reloaded: %d added, %d removed, %d updated",
+			len(changes.Added), len(changes.Removed), len(changes.Changed))
+	}
+	return nil
+}
+
+// ApplyFile loads filename and applies it, logging rather than returning
+// errors since reloads are triggered in the background
+func (r *Reloader) ApplyFile(filename string) {
+	cfg, err := config.Load(filename)
+	if err != nil {
+		log.Printf("❌ Ignoring new configuration, failed to load %s: %v", filename, err)
+		return
+	}
+	if err := r.Apply(cfg); err != nil {
+		log.Printf("❌ Ignoring invalid configuration in %s, keeping the running one:\n%v", filename, err)
+	}
+}
+
+// Run reloads filename whenever a signal arrives on signals (e.g. SIGHUP)
+// or the file changes on disk, until ctx is cancelled
+func (r *Reloader) Run(ctx context.Context, filename string, signals <-chan os.Signal) {
+	changes := config.Watch(ctx, filename, DefaultWatchInterval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			log.Printf("📨 Received %v, reloading %s", sig, filename)
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+			log.Printf("📝 %s changed, reloading", filename)
+		}
+		r.ApplyFile(filename)
+	}
+}
+
+// applyAlerts swaps the alert configuration, creating or dropping the
+// manager when the alerts section appears or disappears
+func (r *Reloader) applyAlerts(alertConfig *config.AlertConfig) {
+	switch {
+	case alertConfig == nil:
+		if r.alerts != nil {
+			log.Printf("🔕 Alerting disabled")
+		}
+		r.alerts = nil
+	case r.alerts == nil:
+		r.alerts = alerts.NewManager(*alertConfig, r.storage)
+	case !reflect.DeepEqual(*r.current.Alerts, *alertConfig):
+		r.alerts.Reload(*alertConfig)
+	}
+}
+
+// appliedConfig returns cfg with the failed sites reverted to their
+// definition in old, or dropped if they are new
+func appliedConfig(old, cfg *config.Config, failed map[string]bool) *config.Config {
+	if len(failed) == 0 {
+		return cfg
+	}
+
+	previous := make(map[string]config.Site, len(old.Sites))
+	for _, site := range old.Sites {
+		previous[site.Name] = site
+	}
+
+	applied := *cfg
+	applied.Sites = make([]config.Site, 0, len(cfg.Sites))
+	for _, site := range cfg.Sites {
+		if failed[site.Name] {
+			prev, ok := previous[site.Name]
+			if !ok {
+				continue
+			}
+			site = prev
+		}
+		applied.Sites = append(applied.Sites, site)
+	}
+	return &applied
+}
+
+// NewMonitor creates the monitor for a configured site. Heartbeat checks
+// read the pings the dashboard records in store.
+func NewMonitor(site config.Site, store storage.Storage) (*monitor.Monitor, error) {
+	m, err := monitor.NewFromSite(site)
+	if err != nil || site.Type != heartbeat.Type {
+		return m, err
+	}
+
+	pings, _ := store.(storage.HeartbeatStorage)
+	checker, err := heartbeat.NewCheckerFromSite(site, pings)
+	if err != nil {
+		return nil, err
+	}
+	m.SetChecker(checker)
+	return m, nil
+}
+
+// buildMonitors creates monitors for the added and changed sites, keyed by name
+func buildMonitors(changes config.SiteChanges, store storage.Storage) (map[string]*monitor.Monitor, error) {
+	var errs []error
+
+	monitors := make(map[string]*monitor.Monitor)
+	for _, site := range append(changes.Added, changes.Changed...) {
+		m, err := NewMonitor(site, store)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("site %s: %w", site.Name, err))
+			continue
+		}
+		monitors[site.Name] = m
+	}
+
+	return monitors, errors.Join(errs...)
+}
+
+// warnRestartRequired logs settings that only take effect on restart
+func warnRestartRequired(old, new *config.Config) {
+	if !reflect.DeepEqual(old.Scheduler, new.Scheduler) {
+		log.Printf("⚠️ scheduler settings changed; restart to apply them")
+	}
+	if old.DrainTimeout != new.DrainTimeout {
+		log.Printf("⚠️ drain_timeout changed; restart to apply it")
+	}
+
+	sections := []struct {
+		name     string
+		old, new any
+	}{
+		{"reports", old.Reports, new.Reports},
+		{"metrics", old.Metrics, new.Metrics},
+		{"ssl", old.SSL, new.SSL},
+		{"storage", old.Storage, new.Storage},
+		{"dashboard", old.Dashboard, new.Dashboard},
+		{"logging", old.Logging, new.Logging},
+		{"api", old.API, new.API},
+	}
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.old, section.new) {
+			log.Printf("⚠️ %s settings changed; restart to apply them", section.name)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"testing"
+	"time"
+)
+
+// fakeScheduler records the monitors registered through the scheduler interface
+type fakeScheduler struct {
+	monitors map[string]*monitor.Monitor
+	replaced []string
+	failing  string // Name of a site Add and Replace refuse
+}
+
+func newFakeScheduler() *fakeScheduler {
+	return &fakeScheduler{monitors: make(map[string]*monitor.Monitor)}
+}
+
+func (s *fakeScheduler) Add(m *monitor.Monitor) error {
+	if m.Name == s.failing {
+		return errors.New("refused")
+	}
+	s.monitors[m.Name] = m
+	return nil
+}
+
+func (s *fakeScheduler) Remove(name string) bool {
+	_, exists := s.monitors[name]
+	delete(s.monitors, name)
+	return exists
+}
+
+func (s *fakeScheduler) Replace(m *monitor.Monitor) error {
+	if m.Name == s.failing {
+		return errors.New("refused")
+	}
+	s.replaced = append(s.replaced, m.Name)
+	s.monitors[m.Name] = m
+	return nil
+}
+
+func site(name, interval string) config.Site {
+	return config.Site{Name: name, URL: "https://" + name + ".example.com", Interval: interval, Timeout: "5s"}
+}
+
+func testAlerts(failures int) *config.AlertConfig {
+	return &config.AlertConfig{Thresholds: config.ThresholdConfig{
+		ConsecutiveFailures:   failures,
+		ResponseTimeThreshold: "5s",
+		UptimeThreshold:       95,
+		UptimeWindow:          "24h",
+		PerformanceWindow:     "1h",
+		AlertCooldown:         "5m",
+	}}
+}
+
+// newReloader starts a reloader with every site of cfg already scheduled
+func newReloader(t *testing.T, cfg *config.Config) (*Reloader, *fakeScheduler) {
+	t.Helper()
+
+	scheduler := newFakeScheduler()
+	for _, s := range cfg.Sites {
+		m, err := monitor.NewFromSite(s)
+		if err != nil {
+			t.Fatalf("NewFromSite failed: %v", err)
+		}
+		scheduler.Add(m)
+	}
+	return New(cfg, scheduler, nil), scheduler
+}
+
+func TestReloader_AppliesSiteChanges(t *testing.T) {
+	r, scheduler := newReloader(t, &config.Config{Sites: []config.Site{
+		site("kept", "1m"), site("changed", "1m"), site("removed", "1m"),
+	}})
+	kept := scheduler.monitors["kept"]
+
+	err := r.Apply(&config.Config{Sites: []config.Site{
+		site("kept", "1m"), site("changed", "30s"), site("added", "1m"),
+	}})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if len(scheduler.monitors) != 3 || scheduler.monitors["removed"] != nil || scheduler.monitors["added"] == nil {
+		t.Errorf("Expected kept, changed and added to be scheduled, got %v", scheduler.monitors)
+	}
+	if scheduler.monitors["kept"] != kept {
+		t.Error("Expected the unchanged site to keep its monitor")
+	}
+	if len(scheduler.replaced) != 1 || scheduler.replaced[0] != "changed" {
+		t.Errorf("Expected only the changed site to be replaced, got %v", scheduler.replaced)
+	}
+	if scheduler.monitors["changed"].Interval != 30*time.Second {
+		t.Errorf("Expected the new interval, got %v", scheduler.monitors["changed"].Interval)
+	}
+	if len(r.Config().Sites) != 3 {
+		t.Errorf("Expected the new config to be current")
+	}
+}
+
+func TestReloader_RejectsInvalidConfig(t *testing.T) {
+	original := &config.Config{Sites: []config.Site{site("a", "1m")}}
+
+	tests := []struct {
+		name string
+		cfg  *config.Config
+	}{
+		{"bad interval", &config.Config{Sites: []config.Site{site("a", "1m"), site("b", "soon")}}},
+		{"zero interval", &config.Config{Sites: []config.Site{site("a", "0s")}}},
+		{"unknown type", &config.Config{Sites: []config.Site{{Name: "a", Type: "carrier-pigeon", Interval: "1m"}}}},
+		{"duplicate name", &config.Config{Sites: []config.Site{site("a", "1m"), site("a", "2m")}}},
+		{"bad alerts", &config.Config{Sites: []config.Site{site("a", "1m")}, Alerts: testAlerts(0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, scheduler := newReloader(t, original)
+			before := scheduler.monitors["a"]
+
+			if err := r.Apply(tt.cfg); err == nil {
+				t.Fatal("Expected the config to be rejected")
+			}
+			if r.Config() != original || len(scheduler.monitors) != 1 || scheduler.monitors["a"] != before {
+				t.Error("Expected the running config to be left untouched")
+			}
+		})
+	}
+}
+
+func TestReloader_SwapsAlerts(t *testing.T) {
+	sites := []config.Site{site("a", "1m")}
+	r, _ := newReloader(t, &config.Config{Sites: sites})
+
+	down := monitor.Result{Name: "a", Success: false, Timestamp: time.Now()}
+	if err := r.ProcessResult(context.Background(), down); err != nil {
+		t.Errorf("Expected results to be ignored without alerting, got %v", err)
+	}
+
+	if err := r.Apply(&config.Config{Sites: sites, Alerts: testAlerts(3)}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	manager := r.alerts
+	if manager == nil {
+		t.Fatal("Expected an alert manager once alerts are configured")
+	}
+	r.ProcessResult(context.Background(), down)
+
+	// Changing thresholds reconfigures the same manager and keeps site states
+	if err := r.Apply(&config.Config{Sites: sites, Alerts: testAlerts(5)}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if r.alerts != manager {
+		t.Error("Expected the alert manager to be reconfigured in place")
+	}
+	if state := manager.GetAlertStates()["a"]; state.ConsecutiveFails != 1 {
+		t.Errorf("Expected alert state to survive the reload, got %+v", state)
+	}
+
+	// Removing the site drops its state, removing the section disables alerting
+	if err := r.Apply(&config.Config{Alerts: testAlerts(5)}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if _, exists := manager.GetAlertStates()["a"]; exists {
+		t.Error("Expected the removed site's alert state to be dropped")
+	}
+	if err := r.Apply(&config.Config{}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if r.alerts != nil {
+		t.Error("Expected alerting to be disabled")
+	}
+}
+
+// fakeDashboard records the configurations pushed by the reloader
+type fakeDashboard struct {
+	configs []*config.Config
+}
+
+func (d *fakeDashboard) SetConfig(cfg *config.Config) {
+	d.configs = append(d.configs, cfg)
+}
+
+func TestReloader_RetriesFailedSites(t *testing.T) {
+	r, scheduler := newReloader(t, &config.Config{Sites: []config.Site{site("changed", "1m")}})
+	dashboard := &fakeDashboard{}
+	r.SetDashboard(dashboard)
+
+	apply := func(failing string, sites ...config.Site) {
+		t.Helper()
+		scheduler.failing = failing
+		if err := r.Apply(&config.Config{Sites: sites}); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+
+	// A site the scheduler refused keeps its previous definition
+	apply("changed", site("changed", "30s"))
+	if got := r.Config().Sites[0].Interval; got != "1m" {
+		t.Errorf("Expected the refused update to be left out of the current config, got %s", got)
+	}
+	apply("", site("changed", "30s"))
+	if scheduler.monitors["changed"].Interval != 30*time.Second {
+		t.Errorf("Expected the update to be retried, got %v", scheduler.monitors["changed"].Interval)
+	}
+
+	// A new site the scheduler refused is added by the next reload
+	apply("added", site("changed", "30s"), site("added", "1m"))
+	if scheduler.monitors["added"] != nil || len(r.Config().Sites) != 1 {
+		t.Errorf("Expected the refused site to be left out, got %+v", r.Config().Sites)
+	}
+	apply("", site("changed", "30s"), site("added", "1m"))
+	if scheduler.monitors["added"] == nil || len(r.Config().Sites) != 2 {
+		t.Errorf("Expected the added site to be retried, got %+v", r.Config().Sites)
+	}
+
+	if len(dashboard.configs) != 4 || dashboard.configs[3] != r.Config() {
+		t.Errorf("Expected the dashboard to follow every reload, got %d configs", len(dashboard.configs))
+	}
+}
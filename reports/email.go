@@ -2,13 +2,18 @@ package reports
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"fmt"
 	"html/template"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"site-monitor/config"
 	"site-monitor/metrics"
 	"site-monitor/ssl"
 	"site-monitor/storage"
+	"sort"
 	"strings"
 	"time"
 )
@@ -20,7 +25,9 @@ type ReportScheduler struct {
 	sslChecker  *ssl.SSLChecker
 	metricsCalc *metrics.AdvancedMetricsCalculator
 	schedules   map[string]*ReportSchedule
-	stopChannel chan struct{}
+	sites       map[string]config.Site // Configured sites by name, for URLs and SSL settings
+	sslWarnDays int                    // Days before expiry that certificates are flagged
+	done        chan struct{}
 }
 
 // ReportSchedule defines when and how to send reports
@@ -35,6 +42,11 @@ type ReportSchedule struct {
 	LastSent   time.Time       `json:"last_sent"`
 	NextDue    time.Time       `json:"next_due"`
 	Template   string          `json:"template"`
+
+	DayOfWeek        time.Weekday `json:"day_of_week"`        // Weekly reports
+	DayOfMonth       int          `json:"day_of_month"`       // Monthly reports, clamped to the month length (0 = 1st)
+	Hour             int          `json:"hour"`               // Hour of day the report is sent
+	IncludeCSVExport bool         `json:"include_csv_export"` // Attach the metrics as CSV
 }
 
 // ScheduleType defines report frequency
@@ -142,19 +154,100 @@ func NewReportScheduler(storage storage.Storage, emailConfig config.EmailConfig)
 		sslChecker:  ssl.NewSSLChecker(10 * time.Second),
 		metricsCalc: metrics.NewAdvancedMetricsCalculator(storage),
 		schedules:   make(map[string]*ReportSchedule),
-		stopChannel: make(chan struct{}),
+		sites:       make(map[string]config.Site),
+		sslWarnDays: config.DefaultSSLWarnDays,
+		done:        make(chan struct{}),
 	}
 }
 
+// Configure applies the reports, metrics and ssl sections of the
+// configuration, replacing any existing schedules with reports.email.schedules
+func (rs *ReportScheduler) Configure(cfg *config.Config) error {
+	if cfg.Alerts != nil {
+		rs.emailConfig = cfg.Alerts.Email
+	}
+
+	rs.sites = make(map[string]config.Site, len(cfg.Sites))
+	for _, site := range cfg.Sites {
+		rs.sites[site.Name] = site
+	}
+
+	if cfg.SSL != nil {
+		rs.sslChecker = nil // Certificates are left out of reports when ssl.enabled is false
+		if cfg.SSL.Enabled {
+			checker, err := ssl.NewFromConfig(*cfg.SSL)
+			if err != nil {
+				return err
+			}
+			rs.sslChecker = checker
+		}
+		rs.sslWarnDays = cfg.SSL.GetWarningDays()
+	}
+
+	if cfg.Metrics != nil {
+		if err := rs.metricsCalc.SetConfig(*cfg.Metrics); err != nil {
+			return fmt.Errorf("invalid metrics configuration: %w", err)
+		}
+	}
+
+	rs.schedules = make(map[string]*ReportSchedule)
+	if cfg.Reports == nil {
+		return nil
+	}
+
+	for _, sc := range cfg.Reports.Email.Schedules {
+		schedule := &ReportSchedule{
+			Name:             sc.Name,
+			Sites:            sc.Sites,
+			Recipients:       sc.Recipients,
+			Schedule:         ScheduleType(sc.Schedule),
+			Format:           ReportFormat(sc.Format),
+			Enabled:          cfg.Reports.Email.Enabled,
+			Template:         sc.Template,
+			DayOfWeek:        time.Weekday(sc.DayOfWeek),
+			DayOfMonth:       sc.DayOfMonth,
+			Hour:             sc.GetHour(),
+			IncludeCSVExport: sc.IncludeCSVExport,
+		}
+		if len(schedule.Recipients) == 0 {
+			schedule.Recipients = rs.emailConfig.Recipients
+		}
+		if schedule.Format == "" {
+			schedule.Format = FormatHTML
+		}
+		for _, section := range sc.Sections {
+			schedule.Sections = append(schedule.Sections, ReportSection(section))
+		}
+		rs.AddSchedule(schedule)
+	}
+
+	return nil
+}
+
+// Schedules returns the configured report schedules
+func (rs *ReportScheduler) Schedules() []*ReportSchedule {
+	schedules := make([]*ReportSchedule, 0, len(rs.schedules))
+	for _, schedule := range rs.schedules {
+		schedules = append(schedules, schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].Name < schedules[j].Name
+	})
+	return schedules
+}
+
 // AddSchedule adds a new report schedule
 func (rs *ReportScheduler) AddSchedule(schedule *ReportSchedule) {
 	rs.schedules[schedule.Name] = schedule
 	rs.calculateNextDue(schedule)
 }
 
-// Start begins the report scheduler
-func (rs *ReportScheduler) Start() {
+// Start begins the report scheduler in the background. It stops once ctx is
+// cancelled; a report that is being sent at that moment is completed first.
+func (rs *ReportScheduler) Start(ctx context.Context) {
 	go func() {
+		defer close(rs.done)
+
 		ticker := time.NewTicker(1 * time.Hour) // Check every hour
 		defer ticker.Stop()
 
@@ -162,16 +255,16 @@ func (rs *ReportScheduler) Start() {
 			select {
 			case <-ticker.C:
 				rs.checkAndSendReports()
-			case <-rs.stopChannel:
+			case <-ctx.Done():
 				return
 			}
 		}
 	}()
 }
 
-// Stop stops the report scheduler
-func (rs *ReportScheduler) Stop() {
-	close(rs.stopChannel)
+// Done returns a channel that is closed once the scheduler has stopped
+func (rs *ReportScheduler) Done() <-chan struct{} {
+	return rs.done
 }
 
 // checkAndSendReports checks if any reports are due and sends them
@@ -234,9 +327,22 @@ func (rs *ReportScheduler) generateAndSendReport(schedule *ReportSchedule) error
 		return fmt.Errorf("failed to generate report content: %w", err)
 	}
 
+	var attachments []attachment
+	if schedule.IncludeCSVExport && schedule.Format != FormatCSV {
+		csv, err := rs.generateCSVReport(reportData, schedule)
+		if err != nil {
+			return fmt.Errorf("failed to generate CSV export: %w", err)
+		}
+		attachments = append(attachments, attachment{
+			filename:    reportData.PeriodEnd.Format("2006-01-02") + "-metrics.csv",
+			contentType: "text/csv",
+			content:     csv,
+		})
+	}
+
 	// Send email
 	subject := rs.generateSubject(schedule, reportData)
-	return rs.sendEmailReport(schedule.Recipients, subject, content, contentType, schedule.Name)
+	return rs.sendEmailReport(schedule.Recipients, subject, content, contentType, schedule.Name, attachments...)
 }
 
 // generateReportData compiles all data needed for the report
@@ -280,11 +386,10 @@ func (rs *ReportScheduler) generateReportData(schedule *ReportSchedule, periodSt
 		}
 
 		// SSL check if enabled
-		if rs.shouldIncludeSection(schedule, SectionSSLCertificates) {
-			// Get site URL (this would need to be stored or passed somehow)
-			siteURL := rs.getSiteURL(siteName) // You'd need to implement this
-			if siteURL != "" {
-				sslCheck := rs.sslChecker.CheckSSL(siteURL)
+		if rs.sslChecker != nil && rs.shouldIncludeSection(schedule, SectionSSLCertificates) {
+			site := rs.sites[siteName]
+			if strings.HasPrefix(site.URL, "https://") {
+				sslCheck := rs.sslChecker.CheckSite(site)
 				reportData.SSLChecks[siteName] = &sslCheck
 			}
 		}
@@ -400,31 +505,55 @@ func (rs *ReportScheduler) calculateReportPeriod(schedule ScheduleType) (time.Ti
 
 // calculateNextDue calculates when the next report is due
 func (rs *ReportScheduler) calculateNextDue(schedule *ReportSchedule) {
-	now := time.Now()
+	schedule.NextDue = nextDue(schedule, time.Now())
+}
+
+// nextDue returns the first time after now that the schedule's hour falls on
+// its day: every day, the schedule's weekday, or the schedule's day of month
+func nextDue(schedule *ReportSchedule, now time.Time) time.Time {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, schedule.Hour, 0, 0, 0, now.Location())
+	}
 
 	switch schedule.Schedule {
-	case ScheduleDaily:
-		schedule.NextDue = now.AddDate(0, 0, 1)
 	case ScheduleWeekly:
-		schedule.NextDue = now.AddDate(0, 0, 7)
+		days := (int(schedule.DayOfWeek) - int(now.Weekday()) + 7) % 7
+		due := at(now.Year(), now.Month(), now.Day()+days)
+		if !due.After(now) {
+			due = at(now.Year(), now.Month(), now.Day()+days+7)
+		}
+		return due
+
 	case ScheduleMonthly:
-		schedule.NextDue = now.AddDate(0, 1, 0)
+		inMonth := func(year int, month time.Month) time.Time {
+			day := max(schedule.DayOfMonth, 1)
+			lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, now.Location()).Day()
+			return at(year, month, min(day, lastDay))
+		}
+		due := inMonth(now.Year(), now.Month())
+		if !due.After(now) {
+			due = inMonth(now.Year(), now.Month()+1)
+		}
+		return due
+
 	default:
-		schedule.NextDue = now.AddDate(0, 0, 1)
+		due := at(now.Year(), now.Month(), now.Day())
+		if !due.After(now) {
+			due = at(now.Year(), now.Month(), now.Day()+1)
+		}
+		return due
 	}
+}
 
-	// Set to specific time (e.g., 9:00 AM)
-	schedule.NextDue = time.Date(
-		schedule.NextDue.Year(),
-		schedule.NextDue.Month(),
-		schedule.NextDue.Day(),
-		9, 0, 0, 0,
-		schedule.NextDue.Location(),
-	)
+// attachment is a file sent along with a report
+type attachment struct {
+	filename    string
+	contentType string
+	content     []byte
 }
 
 // sendEmailReport sends the report via email
-func (rs *ReportScheduler) sendEmailReport(recipients []string, subject string, content []byte, contentType string, reportName string) error {
+func (rs *ReportScheduler) sendEmailReport(recipients []string, subject string, content []byte, contentType string, reportName string, attachments ...attachment) error {
 	// Parse SMTP server and port
 	serverParts := strings.Split(rs.emailConfig.SMTPServer, ":")
 	if len(serverParts) != 2 {
@@ -443,15 +572,20 @@ func (rs *ReportScheduler) sendEmailReport(recipients []string, subject string,
 		from = rs.emailConfig.Username
 	}
 
+	body, bodyType, err := buildMessageBody(content, contentType, attachments)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
 	for _, recipient := range recipients {
 		msg := fmt.Sprintf("To: %s\r\n"+
 			"From: %s\r\n"+
 			"Subject: %s\r\n"+
 			"MIME-Version: 1.0\r\n"+
-			"Content-Type: %s; charset=UTF-8\r\n"+
+			"Content-Type: %s\r\n"+
 			"\r\n"+
 			"%s",
-			recipient, from, subject, contentType, string(content))
+			recipient, from, subject, bodyType, body)
 
 		// Send the email
 		addr := server + ":" + port
@@ -464,6 +598,46 @@ func (rs *ReportScheduler) sendEmailReport(recipients []string, subject string,
 	return nil
 }
 
+// buildMessageBody returns the email body and its content type: the report
+// itself, or a multipart/mixed body when there are attachments
+func buildMessageBody(content []byte, contentType string, attachments []attachment) (string, string, error) {
+	if len(attachments) == 0 {
+		return string(content), contentType + "; charset=UTF-8", nil
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType + "; charset=UTF-8"}})
+	if err != nil {
+		return "", "", err
+	}
+	part.Write(content)
+
+	for _, file := range attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {file.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", file.filename)},
+		})
+		if err != nil {
+			return "", "", err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(file.content)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", "", err
+	}
+	return buf.String(), "multipart/mixed; boundary=" + writer.Boundary(), nil
+}
+
 // Helper functions for template processing
 func formatDuration(d time.Duration) string {
 	if d < time.Second {
@@ -516,8 +690,16 @@ func (rs *ReportScheduler) getSitesToReport(schedule *ReportSchedule) ([]string,
 		return schedule.Sites, nil
 	}
 
-	// Get all sites from storage (simplified approach)
-	// In a real implementation, you'd get this from config or a site registry
+	if len(rs.sites) > 0 {
+		sites := make([]string, 0, len(rs.sites))
+		for siteName := range rs.sites {
+			sites = append(sites, siteName)
+		}
+		sort.Strings(sites)
+		return sites, nil
+	}
+
+	// Without a configuration, report on every site with recent results
 	allStats, err := rs.storage.GetAllStats(time.Now().Add(-24 * time.Hour))
 	if err != nil {
 		return nil, err
@@ -531,13 +713,17 @@ func (rs *ReportScheduler) getSitesToReport(schedule *ReportSchedule) ([]string,
 	return sites, nil
 }
 
-// getSiteURL returns URL for a site name (placeholder - should be implemented properly)
+// getSiteURL returns the configured URL for a site name, or "" when the site is unknown
 func (rs *ReportScheduler) getSiteURL(siteName string) string {
-	// This is a placeholder. In a real implementation, you would:
-	// 1. Store site URLs in the database
-	// 2. Pass the config to ReportScheduler
-	// 3. Or have a site registry service
-	return "" // Return empty for now, SSL checks will be skipped
+	return rs.sites[siteName].URL
+}
+
+// getSSLWarnDays returns how many days before expiry a site's certificate is flagged
+func (rs *ReportScheduler) getSSLWarnDays(siteName string) int {
+	if site, exists := rs.sites[siteName]; exists && site.SSLWarnDays > 0 {
+		return site.SSLWarnDays
+	}
+	return rs.sslWarnDays
 }
 
 // shouldIncludeSection checks if a section should be included
@@ -621,7 +807,7 @@ func (rs *ReportScheduler) generateRecommendations(data *ReportData) []Recommend
 	var recommendations []Recommendation
 
 	for siteName, sslCheck := range data.SSLChecks {
-		if sslCheck.IsExpiringSoon(30) {
+		if sslCheck.IsExpiringSoon(rs.getSSLWarnDays(siteName)) {
 			recommendations = append(recommendations, Recommendation{
 				Type:        RecommendationSSL,
 				SiteName:    siteName,
@@ -0,0 +1,112 @@
+package reports
+
+import (
+	"mime"
+	"site-monitor/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextDue(t *testing.T) {
+	// Wednesday 2024-01-31 10:30
+	now := time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule ReportSchedule
+		expected time.Time
+	}{
+		{"daily later today", ReportSchedule{Schedule: ScheduleDaily, Hour: 18},
+			time.Date(2024, time.January, 31, 18, 0, 0, 0, time.UTC)},
+		{"daily tomorrow", ReportSchedule{Schedule: ScheduleDaily, Hour: 9},
+			time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{"weekly on monday", ReportSchedule{Schedule: ScheduleWeekly, DayOfWeek: time.Monday, Hour: 9},
+			time.Date(2024, time.February, 5, 9, 0, 0, 0, time.UTC)},
+		{"weekly next wednesday", ReportSchedule{Schedule: ScheduleWeekly, DayOfWeek: time.Wednesday, Hour: 8},
+			time.Date(2024, time.February, 7, 8, 0, 0, 0, time.UTC)},
+		{"monthly clamped to february", ReportSchedule{Schedule: ScheduleMonthly, DayOfMonth: 31, Hour: 6},
+			time.Date(2024, time.February, 29, 6, 0, 0, 0, time.UTC)},
+		{"monthly later today", ReportSchedule{Schedule: ScheduleMonthly, DayOfMonth: 31, Hour: 12},
+			time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)},
+		{"monthly defaults to the first", ReportSchedule{Schedule: ScheduleMonthly, Hour: 9},
+			time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDue(&tt.schedule, now); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReportScheduler_Configure(t *testing.T) {
+	hour := 7
+	cfg := &config.Config{
+		Sites: []config.Site{
+			{Name: "shop", URL: "https://shop.example.com", SSLWarnDays: 14},
+			{Name: "blog", URL: "http://blog.example.com"},
+		},
+		Alerts: &config.AlertConfig{Email: config.EmailConfig{Recipients: []string{"ops@example.com"}}},
+		Reports: &config.ReportsConfig{Email: config.ReportEmailConfig{
+			Enabled: true,
+			Schedules: []config.ReportScheduleConfig{
+				{Name: "weekly", Schedule: "weekly", DayOfWeek: 1, Sections: []string{"overview"}},
+				{Name: "monthly", Schedule: "monthly", DayOfMonth: 15, Hour: &hour, Format: "csv", Recipients: []string{"cfo@example.com"}},
+			},
+		}},
+		SSL:     &config.SSLConfig{Enabled: false, WarningThresholds: []int{45}},
+		Metrics: &config.MetricsConfig{Advanced: config.AdvancedMetricsConfig{Enabled: true}},
+	}
+
+	rs := NewReportScheduler(nil, config.EmailConfig{})
+	if err := rs.Configure(cfg); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+
+	schedules := rs.Schedules()
+	if len(schedules) != 2 {
+		t.Fatalf("Expected 2 schedules, got %d", len(schedules))
+	}
+
+	monthly, weekly := schedules[0], schedules[1]
+	if !weekly.Enabled || weekly.DayOfWeek != time.Monday || weekly.Hour != config.DefaultReportHour || weekly.Format != FormatHTML {
+		t.Errorf("Unexpected weekly schedule %+v", weekly)
+	}
+	if weekly.Recipients[0] != "ops@example.com" || monthly.Recipients[0] != "cfo@example.com" {
+		t.Errorf("Expected recipients to default to the alert recipients")
+	}
+	if monthly.Hour != 7 || monthly.DayOfMonth != 15 || monthly.Format != FormatCSV || monthly.NextDue.IsZero() {
+		t.Errorf("Unexpected monthly schedule %+v", monthly)
+	}
+
+	if rs.sslChecker != nil {
+		t.Errorf("Expected certificates to be skipped when ssl is disabled")
+	}
+	if rs.getSiteURL("shop") != "https://shop.example.com" || rs.getSSLWarnDays("shop") != 14 || rs.getSSLWarnDays("blog") != 45 {
+		t.Errorf("Unexpected site settings")
+	}
+
+	sites, err := rs.getSitesToReport(weekly)
+	if err != nil || strings.Join(sites, ",") != "blog,shop" {
+		t.Errorf("Expected every configured site, got %v (%v)", sites, err)
+	}
+}
+
+func TestBuildMessageBody_Attachment(t *testing.T) {
+	body, contentType, err := buildMessageBody([]byte("<p>report</p>"), "text/html",
+		[]attachment{{filename: "metrics.csv", contentType: "text/csv", content: []byte("a,b\n")}})
+	if err != nil {
+		t.Fatalf("buildMessageBody failed: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/mixed" || params["boundary"] == "" {
+		t.Fatalf("Expected a multipart body, got %q", contentType)
+	}
+	if !strings.Contains(body, "<p>report</p>") || !strings.Contains(body, `filename="metrics.csv"`) || !strings.Contains(body, "YSxiCg==") {
+		t.Errorf("Unexpected body:\n%s", body)
+	}
+}
@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Metrics is a snapshot of the scheduler's health
+type Metrics struct {
+	Checks        int           `json:"checks"`         // Registered checks
+	Workers       int           `json:"workers"`        // Size of the worker pool
+	QueueDepth    int           `json:"queue_depth"`    // Due checks waiting for a worker
+	QueueCapacity int           `json:"queue_capacity"` // Maximum queue depth before dispatching blocks
+	InFlight      int           `json:"in_flight"`      // Checks currently running
+	Dispatched    uint64        `json:"dispatched"`     // Runs handed to the worker pool
+	Started       uint64        `json:"started"`        // Runs picked up by a worker
+	Completed     uint64        `json:"completed"`      // Runs finished and published
+	Skipped       uint64        `json:"skipped"`        // Runs skipped because the previous run was still going
+	Confirmations uint64        `json:"confirmations"`  // Confirmation checks run after a failure
+	LastLag       time.Duration `json:"last_lag"`       // Delay between due time and start of the latest run
+	AvgLag        time.Duration `json:"avg_lag"`
+	MaxLag        time.Duration `json:"max_lag"`
+}
+
+// String returns a formatted representation of the metrics
+func (m Metrics) String() string {
+	return fmt.Sprintf(
+		"⏱️ Scheduler: %d checks, %d/%d workers busy, queue %d/%d, %d completed, %d skipped, %d confirmations, lag avg %v max %v",
+		m.Checks,
+		m.InFlight,
+		m.Workers,
+		m.QueueDepth,
+		m.QueueCapacity,
+		m.Completed,
+		m.Skipped,
+		m.Confirmations,
+		m.AvgLag.Round(time.Millisecond),
+		m.MaxLag.Round(time.Millisecond),
+	)
+}
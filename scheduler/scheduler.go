@@ -0,0 +1,435 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"site-monitor/monitor"
+	"sync"
+	"time"
+)
+
+// Publisher receives every result produced by the scheduler (e.g. a result pipeline)
+type Publisher interface {
+	Publish(result monitor.Result)
+}
+
+// Options configures the scheduler
+type Options struct {
+	Workers   int           // Maximum number of checks running concurrently
+	QueueSize int           // Maximum number of due checks waiting for a worker
+	MaxJitter time.Duration // Upper bound of the random delay added to each run (0 = 10% of the interval)
+
+	// How often the metrics are logged while running (0 = DefaultMetricsInterval, negative = never)
+	MetricsInterval time.Duration
+}
+
+// Default option values
+const (
+	DefaultWorkers         = 10
+	DefaultQueueSize       = 100
+	DefaultMetricsInterval = 5 * time.Minute
+)
+
+// Scheduler owns every check and runs them on a bounded worker pool.
+// Each run is delayed by a random jitter so checks sharing an interval do not
+// fire in lock-step, and a check that is still running when it becomes due
+// again is skipped rather than stacked.
+type Scheduler struct {
+	publisher Publisher
+	options   Options
+
+	jobs    map[string]*job
+	removed map[string]*job // Removed jobs whose run is still in flight
+	due     jobHeap
+	queue   chan queuedRun
+	wake    chan struct{}
+	rand    *rand.Rand
+
+	metrics Metrics
+	lagSum  time.Duration
+	mu      sync.Mutex
+}
+
+// job is a scheduled check
+type job struct {
+	monitor *monitor.Monitor
+	slot    time.Time // Unjittered slot, advanced by the interval on each run
+	next    time.Time // Slot plus jitter: when the job is actually due
+	running bool
+	index   int // Position in the due heap
+}
+
+// queuedRun is a job waiting for a worker
+type queuedRun struct {
+	job       *job
+	monitor   *monitor.Monitor // Captured at dispatch so Replace never races a run
+	scheduled time.Time
+}
+
+// New creates a new scheduler that publishes results to publisher
+func New(publisher Publisher, options Options) *Scheduler {
+	if options.Workers <= 0 {
+		options.Workers = DefaultWorkers
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = DefaultQueueSize
+	}
+	if options.MetricsInterval == 0 {
+		options.MetricsInterval = DefaultMetricsInterval
+	}
+
+	return &Scheduler{
+		publisher: publisher,
+		options:   options,
+		jobs:      make(map[string]*job),
+		removed:   make(map[string]*job),
+		queue:     make(chan queuedRun, options.QueueSize),
+		wake:      make(chan struct{}, 1),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		metrics: Metrics{
+			Workers:       options.Workers,
+			QueueCapacity: options.QueueSize,
+		},
+	}
+}
+
+// Add registers a check. Its first run happens after a random jitter.
+// Checks can be added before or after the scheduler is started. A check
+// removed while its run was in flight is not run again before that run
+// finishes.
+func (s *Scheduler) Add(m *monitor.Monitor) error {
+	if m.Interval <= 0 {
+		return fmt.Errorf("invalid interval %v for %s", m.Interval, m.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[m.Name]; exists {
+		return fmt.Errorf("check %s is already scheduled", m.Name)
+	}
+
+	// Reuse the removed job so it stays marked running until finish
+	j, inFlight := s.removed[m.Name]
+	if inFlight {
+		delete(s.removed, m.Name)
+	} else {
+		j = &job{}
+	}
+	j.monitor = m
+	j.slot = time.Now()
+	j.next = j.slot.Add(s.jitter(m.Interval))
+
+	s.jobs[m.Name] = j
+	heap.Push(&s.due, j)
+	s.metrics.Checks = len(s.jobs)
+	s.notify()
+
+	return nil
+}
+
+// Remove unregisters a check. A run already in progress is allowed to finish.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, exists := s.jobs[name]
+	if !exists {
+		return false
+	}
+
+	delete(s.jobs, name)
+	heap.Remove(&s.due, j.index)
+	if j.running {
+		s.removed[name] = j
+	}
+	s.metrics.Checks = len(s.jobs)
+	s.notify()
+
+	return true
+}
+
+// Replace swaps the monitor of a registered check, e.g. after a config
+// reload, or adds it if no check has its name. A run already in progress
+// finishes with the old monitor and the next run uses the new one. The check
+// keeps its slot unless the interval changed, in which case it is
+// rescheduled from now.
+func (s *Scheduler) Replace(m *monitor.Monitor) error {
+	if m.Interval <= 0 {
+		return fmt.Errorf("invalid interval %v for %s", m.Interval, m.Name)
+	}
+
+	s.mu.Lock()
+	j, exists := s.jobs[m.Name]
+	if !exists {
+		s.mu.Unlock()
+		return s.Add(m)
+	}
+	defer s.mu.Unlock()
+
+	if m.Interval != j.monitor.Interval {
+		j.slot = time.Now()
+		j.next = j.slot.Add(s.jitter(m.Interval))
+		heap.Fix(&s.due, j.index)
+		s.notify()
+	}
+	j.monitor = m
+
+	return nil
+}
+
+// Start runs the scheduler until ctx is cancelled. On cancellation no new
+// checks are dispatched, queued runs are discarded, and Start returns once
+// the checks already in flight have completed and been published.
+func (s *Scheduler) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	// In-flight checks must survive shutdown so their results are not lost
+	checkCtx := context.WithoutCancel(ctx)
+
+	for i := 0; i < s.options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx, checkCtx)
+		}()
+	}
+
+	if s.options.MetricsInterval > 0 {
+		go s.logMetrics(ctx)
+	}
+
+	s.dispatch(ctx)
+
+	close(s.queue)
+	wg.Wait()
+
+	return nil
+}
+
+// Metrics returns a snapshot of the scheduler metrics
+func (s *Scheduler) Metrics() Metrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics := s.metrics
+	metrics.QueueDepth = len(s.queue)
+	if metrics.Started > 0 {
+		metrics.AvgLag = s.lagSum / time.Duration(metrics.Started)
+	}
+	return metrics
+}
+
+// logMetrics logs the metrics every MetricsInterval until ctx is cancelled,
+// so queue build-up, lag and skipped runs show while the daemon is running
+func (s *Scheduler) logMetrics(ctx context.Context) {
+	ticker := time.NewTicker(s.options.MetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Print(s.Metrics())
+		}
+	}
+}
+
+// dispatch waits for the next due check and hands it to the worker pool
+func (s *Scheduler) dispatch(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(s.untilNextDue())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-timer.C:
+			for _, run := range s.collectDue(time.Now()) {
+				// Blocking here is the backpressure: when workers cannot keep
+				// up, dispatching slows down and shows up as lag.
+				select {
+				case s.queue <- run:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// untilNextDue returns how long to sleep until the earliest due check
+func (s *Scheduler) untilNextDue() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.due) == 0 {
+		return time.Hour
+	}
+	if wait := time.Until(s.due[0].next); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// collectDue pops every check due at now and reschedules it
+func (s *Scheduler) collectDue(now time.Time) []queuedRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var runs []queuedRun
+	for len(s.due) > 0 && !s.due[0].next.After(now) {
+		j := s.due[0]
+		scheduled := j.next
+
+		if j.running {
+			s.metrics.Skipped++
+			log.Printf("⏭️ Skipping %s: previous check still running", j.monitor.Name)
+		} else {
+			j.running = true
+			s.metrics.Dispatched++
+			runs = append(runs, queuedRun{job: j, monitor: j.monitor, scheduled: scheduled})
+		}
+
+		// Advance to the next slot; if we fell behind, don't try to catch up
+		j.slot = j.slot.Add(j.monitor.Interval)
+		if j.slot.Before(now) {
+			j.slot = now
+		}
+		j.next = j.slot.Add(s.jitter(j.monitor.Interval))
+		heap.Fix(&s.due, 0)
+	}
+
+	return runs
+}
+
+// work executes queued checks until the queue is closed
+func (s *Scheduler) work(ctx, checkCtx context.Context) {
+	for run := range s.queue {
+		if ctx.Err() != nil {
+			// Shutting down: drop runs that have not started yet
+			s.finish(run.job, false)
+			continue
+		}
+
+		s.recordStart(time.Since(run.scheduled))
+
+		result := s.check(checkCtx, run.monitor)
+		if s.publisher != nil {
+			s.publisher.Publish(result)
+		}
+
+		s.finish(run.job, true)
+	}
+}
+
+// check runs a monitor once. When the monitor asks for confirmation, a
+// failure triggers an immediate out-of-schedule check whose result replaces
+// it, so a single blip is never recorded as downtime.
+func (s *Scheduler) check(ctx context.Context, m *monitor.Monitor) monitor.Result {
+	result := m.Check(ctx)
+	if result.Success || !m.Confirm {
+		return result
+	}
+
+	log.Printf("🔁 %s failed (%s), confirming", m.Name, result.Error)
+	s.mu.Lock()
+	s.metrics.Confirmations++
+	s.mu.Unlock()
+
+	confirmation := m.Check(ctx)
+	confirmation.Attempts += result.Attempts
+	return confirmation
+}
+
+// recordStart updates lag and in-flight metrics when a check starts
+func (s *Scheduler) recordStart(lag time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.metrics.Started++
+	s.metrics.InFlight++
+	s.metrics.LastLag = lag
+	s.lagSum += lag
+	if lag > s.metrics.MaxLag {
+		s.metrics.MaxLag = lag
+	}
+}
+
+// finish marks a run as complete so the job can be dispatched again
+func (s *Scheduler) finish(j *job, started bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j.running = false
+	if s.removed[j.monitor.Name] == j {
+		delete(s.removed, j.monitor.Name)
+	}
+	if started {
+		s.metrics.InFlight--
+		s.metrics.Completed++
+	}
+}
+
+// jitter returns a random delay bounded by MaxJitter (or 10% of the interval)
+func (s *Scheduler) jitter(interval time.Duration) time.Duration {
+	max := s.options.MaxJitter
+	if max <= 0 {
+		max = interval / 10
+	}
+	if max > interval {
+		max = interval
+	}
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(s.rand.Int63n(int64(max)))
+}
+
+// notify wakes the dispatcher so it recomputes the next due time
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// jobHeap orders jobs by their next due time
+type jobHeap []*job
+
+func (h jobHeap) Len() int           { return len(h) }
+func (h jobHeap) Less(i, j int) bool { return h[i].next.Before(h[j].next) }
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	j := x.(*job)
+	j.index = len(*h)
+	*h = append(*h, j)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	j.index = -1
+	*h = old[:n-1]
+	return j
+}
@@ -0,0 +1,364 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"site-monitor/monitor"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// collector records published results
+type collector struct {
+	mu      sync.Mutex
+	results []monitor.Result
+}
+
+func (c *collector) Publish(result monitor.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, result)
+}
+
+func (c *collector) count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, r := range c.results {
+		if r.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func newTestMonitor(name, url string, interval time.Duration) *monitor.Monitor {
+	m := monitor.New(url, interval)
+	m.SetName(name)
+	m.SetTimeout(5 * time.Second)
+	return m
+}
+
+func TestScheduler_RunsChecksRepeatedly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	results := &collector{}
+	s := New(results, Options{Workers: 2, MaxJitter: time.Millisecond})
+
+	if err := s.Add(newTestMonitor("fast", server.URL, 20*time.Millisecond)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if n := results.count("fast"); n < 3 {
+		t.Errorf("Expected at least 3 results, got %d", n)
+	}
+
+	metrics := s.Metrics()
+	if metrics.Completed != uint64(results.count("fast")) {
+		t.Errorf("Expected %d completed runs, got %d", results.count("fast"), metrics.Completed)
+	}
+	if metrics.InFlight != 0 {
+		t.Errorf("Expected no in-flight checks after Start returned, got %d", metrics.InFlight)
+	}
+}
+
+func TestScheduler_SkipsWhileStillRunning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(120 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	results := &collector{}
+	s := New(results, Options{Workers: 4, MaxJitter: time.Millisecond})
+
+	if err := s.Add(newTestMonitor("slow", server.URL, 20*time.Millisecond)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if n := results.count("slow"); n > 2 {
+		t.Errorf("Expected overlapping runs to be skipped, got %d results", n)
+	}
+	if s.Metrics().Skipped == 0 {
+		t.Error("Expected skipped runs to be counted")
+	}
+}
+
+func TestScheduler_BoundsConcurrency(t *testing.T) {
+	var current, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		atomic.AddInt32(&current, -1)
+	}))
+	defer server.Close()
+
+	s := New(&collector{}, Options{Workers: 2, MaxJitter: time.Millisecond})
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		if err := s.Add(newTestMonitor(name, server.URL, 50*time.Millisecond)); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent checks, observed %d", peak)
+	}
+}
+
+func TestScheduler_AddRemove(t *testing.T) {
+	s := New(nil, Options{})
+
+	m := newTestMonitor("site", "http://example.invalid", time.Minute)
+	if err := s.Add(m); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := s.Add(m); err == nil {
+		t.Error("Expected error when adding a duplicate check")
+	}
+	if err := s.Add(newTestMonitor("zero", "http://example.invalid", 0)); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+
+	if !s.Remove("site") {
+		t.Error("Expected Remove to report the check was registered")
+	}
+	if s.Remove("site") {
+		t.Error("Expected second Remove to report nothing was removed")
+	}
+	if s.Metrics().Checks != 0 {
+		t.Errorf("Expected 0 checks, got %d", s.Metrics().Checks)
+	}
+}
+
+func TestScheduler_Replace(t *testing.T) {
+	s := New(nil, Options{MaxJitter: time.Millisecond})
+
+	if err := s.Add(newTestMonitor("site", "http://example.invalid", time.Minute)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	next := s.jobs["site"].next
+
+	// Same interval: the check keeps its slot
+	same := newTestMonitor("site", "http://other.invalid", time.Minute)
+	if err := s.Replace(same); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if s.jobs["site"].monitor != same || !s.jobs["site"].next.Equal(next) {
+		t.Error("Expected the monitor to be swapped without rescheduling")
+	}
+
+	// New interval: the check is rescheduled
+	faster := newTestMonitor("site", "http://other.invalid", time.Second)
+	if err := s.Replace(faster); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if s.jobs["site"].next.Equal(next) {
+		t.Error("Expected the check to be rescheduled for its new interval")
+	}
+
+	// Unknown checks are added
+	if err := s.Replace(newTestMonitor("new", "http://example.invalid", time.Minute)); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if s.Metrics().Checks != 2 {
+		t.Errorf("Expected 2 checks, got %d", s.Metrics().Checks)
+	}
+	if err := s.Replace(newTestMonitor("site", "http://example.invalid", 0)); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+}
+
+// blockingChecker holds every run until release is closed and records
+// whether two runs ever overlapped
+type blockingChecker struct {
+	calls   atomic.Int32
+	running atomic.Int32
+	overlap atomic.Bool
+	release chan struct{}
+}
+
+func (c *blockingChecker) Check(ctx context.Context) monitor.Result {
+	c.calls.Add(1)
+	if c.running.Add(1) > 1 {
+		c.overlap.Store(true)
+	}
+	defer c.running.Add(-1)
+
+	<-c.release
+	return monitor.Result{Success: true, Status: 200}
+}
+
+func TestScheduler_ReAddWaitsForRemovedRun(t *testing.T) {
+	s := New(&collector{}, Options{Workers: 2, MaxJitter: time.Millisecond})
+	checker := &blockingChecker{release: make(chan struct{})}
+	newMonitor := func() *monitor.Monitor {
+		m := newTestMonitor("site", "http://example.invalid", 10*time.Millisecond)
+		m.SetChecker(checker)
+		return m
+	}
+	waitForCalls := func(n int32) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for checker.calls.Load() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d runs, got %d", n, checker.calls.Load())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if err := s.Add(newMonitor()); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Start(ctx)
+	}()
+	release := sync.OnceFunc(func() { close(checker.release) })
+	defer func() {
+		release()
+		cancel()
+		<-done
+	}()
+
+	waitForCalls(1)
+
+	// Removing and re-adding the check while it runs must not start a second run
+	s.Remove("site")
+	if err := s.Add(newMonitor()); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := checker.calls.Load(); n != 1 {
+		t.Fatalf("Expected the re-added check to wait for the removed run, got %d runs", n)
+	}
+
+	// Once the removed run finishes, the re-added check runs again
+	release()
+	waitForCalls(2)
+	if checker.overlap.Load() {
+		t.Error("Expected runs of the same check never to overlap")
+	}
+}
+
+// flakyChecker fails its first call and succeeds afterwards
+type flakyChecker struct {
+	calls atomic.Int32
+}
+
+func (c *flakyChecker) Check(ctx context.Context) monitor.Result {
+	if c.calls.Add(1) == 1 {
+		return monitor.Result{Success: false, Error: "blip"}
+	}
+	return monitor.Result{Success: true, Status: 200}
+}
+
+func TestScheduler_ConfirmsFailures(t *testing.T) {
+	results := &collector{}
+	s := New(results, Options{Workers: 1})
+
+	m := newTestMonitor("flaky", "http://example.invalid", time.Minute)
+	m.SetChecker(&flakyChecker{})
+	m.Confirm = true
+
+	result := s.check(context.Background(), m)
+	if !result.Success {
+		t.Errorf("Expected the confirmation result to replace the failure, got %q", result.Error)
+	}
+	if result.Attempts != 2 {
+		t.Errorf("Expected attempts from both checks, got %d", result.Attempts)
+	}
+	if s.Metrics().Confirmations != 1 {
+		t.Errorf("Expected 1 confirmation, got %d", s.Metrics().Confirmations)
+	}
+
+	// A passing check is never confirmed
+	s.check(context.Background(), m)
+	if s.Metrics().Confirmations != 1 {
+		t.Errorf("Expected no extra confirmation, got %d", s.Metrics().Confirmations)
+	}
+}
+
+// syncBuffer is a log output safe to read while the scheduler writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestScheduler_LogsMetrics(t *testing.T) {
+	output := &syncBuffer{}
+	log.SetOutput(output)
+	defer log.SetOutput(os.Stderr)
+
+	s := New(&collector{}, Options{Workers: 2, QueueSize: 5, MetricsInterval: 10 * time.Millisecond})
+	if err := s.Add(newTestMonitor("site", "http://example.invalid", time.Hour)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Start(ctx)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(output.String(), "Scheduler:") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if line := output.String(); !strings.Contains(line, "⏱️ Scheduler: 1 checks, 0/2 workers busy, queue 0/5") {
+		t.Errorf("Expected the metrics to be logged while running, got %q", line)
+	}
+}
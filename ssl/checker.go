@@ -1,11 +1,16 @@
 package ssl
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"net/url"
+	"site-monitor/config"
+	"site-monitor/monitor"
 	"strings"
 	"time"
 )
@@ -28,6 +33,9 @@ type SSLCheck struct {
 	DNSNames           []string      `json:"dns_names"`
 	IPAddresses        []string      `json:"ip_addresses"`
 	Chain              []Certificate `json:"chain"`
+	ChainError         string        `json:"chain_error,omitempty"`      // Set when verify_chain is on and the chain does not verify
+	Revoked            bool          `json:"revoked,omitempty"`          // Listed in the issuer's CRL
+	RevocationError    string        `json:"revocation_error,omitempty"` // The CRL could not be checked
 	Error              string        `json:"error,omitempty"`
 	CheckedAt          time.Time     `json:"checked_at"`
 	ResponseTime       time.Duration `json:"response_time"`
@@ -48,7 +56,9 @@ type Certificate struct {
 
 // SSLChecker performs SSL certificate checks
 type SSLChecker struct {
-	Timeout time.Duration
+	Timeout         time.Duration
+	VerifyChain     bool // Verify the presented chain against the system roots
+	CheckRevocation bool // Look the leaf up in the CRL it points to
 }
 
 // NewSSLChecker creates a new SSL checker
@@ -61,9 +71,30 @@ func NewSSLChecker(timeout time.Duration) *SSLChecker {
 	}
 }
 
+// NewFromConfig creates an SSL checker from the ssl section of the configuration
+func NewFromConfig(cfg config.SSLConfig) (*SSLChecker, error) {
+	timeout, err := cfg.GetTimeout()
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssl timeout: %w", err)
+	}
+
+	checker := NewSSLChecker(timeout)
+	checker.VerifyChain = cfg.VerifyChain
+	checker.CheckRevocation = cfg.CheckRevocation
+	return checker, nil
+}
+
 // CheckSSL performs SSL certificate validation for a URL
 func (c *SSLChecker) CheckSSL(rawURL string) SSLCheck {
+	return c.CheckSite(config.Site{URL: rawURL})
+}
+
+// CheckSite performs SSL certificate validation for a site, connecting with
+// its tls settings (client certificate, CA bundle, server name and minimum
+// version) through its proxy
+func (c *SSLChecker) CheckSite(site config.Site) SSLCheck {
 	start := time.Now()
+	rawURL := site.URL
 
 	check := SSLCheck{
 		URL:       rawURL,
@@ -98,22 +129,46 @@ func (c *SSLChecker) CheckSSL(rawURL string) SSLCheck {
 	check.Host = host
 	check.Port = port
 
-	// Connect with TLS
-	dialer := &net.Dialer{Timeout: c.Timeout}
+	tlsConfig, err := monitor.NewTLSConfig(site.TLS)
+	if err != nil {
+		check.Error = fmt.Sprintf("Invalid TLS settings: %v", err)
+		check.ResponseTime = time.Since(start)
+		return check
+	}
+	dial, err := monitor.NewDialer(site.Proxy)
+	if err != nil {
+		check.Error = fmt.Sprintf("Invalid proxy settings: %v", err)
+		check.ResponseTime = time.Since(start)
+		return check
+	}
+
+	// The certificate is checked against the server name override if set
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+	serverName := tlsConfig.ServerName
+	roots := tlsConfig.RootCAs
+	// Don't verify during the handshake, we'll do our own validation
+	tlsConfig.InsecureSkipVerify = true
 
-	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, port), &tls.Config{
-		ServerName: host,
-		// Don't verify for now, we'll do our own validation
-		InsecureSkipVerify: true,
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
 
+	rawConn, err := dial(ctx, "tcp", net.JoinHostPort(host, port))
 	if err != nil {
 		check.Error = fmt.Sprintf("TLS connection failed: %v", err)
 		check.ResponseTime = time.Since(start)
 		return check
 	}
+	conn := tls.Client(rawConn, tlsConfig)
 	defer conn.Close()
 
+	if err := conn.HandshakeContext(ctx); err != nil {
+		check.Error = fmt.Sprintf("TLS connection failed: %v", err)
+		check.ResponseTime = time.Since(start)
+		return check
+	}
+
 	check.ResponseTime = time.Since(start)
 
 	// Get certificate chain
@@ -125,7 +180,7 @@ func (c *SSLChecker) CheckSSL(rawURL string) SSLCheck {
 
 	// Analyze the leaf certificate
 	cert := state.PeerCertificates[0]
-	check.Valid = c.validateCertificate(cert, host)
+	check.Valid = c.validateCertificate(cert, serverName)
 	check.ExpiresAt = cert.NotAfter
 	check.DaysUntilExpiry = int(time.Until(cert.NotAfter).Hours() / 24)
 	check.Issuer = cert.Issuer.String()
@@ -158,9 +213,86 @@ func (c *SSLChecker) CheckSSL(rawURL string) SSLCheck {
 		check.Chain = append(check.Chain, certInfo)
 	}
 
+	if c.VerifyChain {
+		if err := c.verifyChain(state.PeerCertificates, serverName, roots); err != nil {
+			check.ChainError = err.Error()
+			check.Valid = false
+		}
+	}
+
+	if c.CheckRevocation && len(state.PeerCertificates) > 1 {
+		revoked, err := c.checkRevocation(cert, state.PeerCertificates[1], site.Proxy)
+		if err != nil {
+			check.RevocationError = err.Error()
+		} else if revoked {
+			check.Revoked = true
+			check.Valid = false
+		}
+	}
+
 	return check
 }
 
+// verifyChain verifies the leaf against roots (the system roots if nil)
+// using the intermediates the server presented
+func (c *SSLChecker) verifyChain(certs []*x509.Certificate, hostname string, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       hostname,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// checkRevocation downloads the leaf's CRL, checks it was signed by the
+// issuer and looks for the leaf's serial number. The CRL is fetched through
+// the site's proxy, if any.
+func (c *SSLChecker) checkRevocation(cert, issuer *x509.Certificate, proxy *config.ProxyConfig) (bool, error) {
+	if len(cert.CRLDistributionPoints) == 0 {
+		return false, fmt.Errorf("certificate has no CRL distribution point")
+	}
+
+	transport, err := monitor.NewProxyTransport(proxy)
+	if err != nil {
+		return false, err
+	}
+	client := &http.Client{Timeout: c.Timeout, Transport: transport}
+	resp, err := client.Get(cert.CRLDistributionPoints[0])
+	if err != nil {
+		return false, fmt.Errorf("failed to download CRL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to download CRL: HTTP %d", resp.StatusCode)
+	}
+
+	der, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return false, fmt.Errorf("failed to read CRL: %w", err)
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse CRL: %w", err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return false, fmt.Errorf("CRL is not signed by the issuer: %w", err)
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // validateCertificate validates the certificate against the hostname
 func (c *SSLChecker) validateCertificate(cert *x509.Certificate, hostname string) bool {
 	now := time.Now()
@@ -258,6 +390,12 @@ func (check *SSLCheck) GetExpiryStatus() string {
 	if check.Error != "" {
 		return "Error"
 	}
+	if check.Revoked {
+		return "Revoked"
+	}
+	if check.ChainError != "" {
+		return "Untrusted Chain"
+	}
 
 	days := check.DaysUntilExpiry
 
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"testing"
+	"time"
+)
+
+// conformingStorage is what every SQL backend provides beyond Storage
+type conformingStorage interface {
+	Storage
+	Migrator
+	HeartbeatStorage
+	Rollup(now time.Time) error
+	ApplyRetention(retention config.RetentionConfig, now time.Time) (int64, int64, error)
+}
+
+// testConformance runs the behaviour shared by every storage backend against
+// fresh, initialized storages returned by open
+func testConformance(t *testing.T, open func(t *testing.T) conformingStorage) {
+	// PostgreSQL keeps timestamps to the microsecond
+	now := time.Now().Truncate(time.Microsecond)
+
+	t.Run("History", func(t *testing.T) {
+		s := open(t)
+		saved := monitor.Result{
+			Name: "web", URL: "https://example.com", Status: 200, Duration: 120 * time.Millisecond,
+			Success: true, Timestamp: now.Add(-2 * time.Minute), Attempts: 2, Severity: monitor.SeverityWarning,
+			Timings:    monitor.Timings{DNS: 5 * time.Millisecond, TTFB: 80 * time.Millisecond},
+			Assertions: []monitor.AssertionResult{{Type: "body", Target: "contains ok", Passed: true}},
+			Metrics:    []monitor.Metric{{Label: "load", Value: 0.5}},
+		}
+		results := []monitor.Result{
+			saved,
+			{Name: "web", URL: "https://example.com", Error: "timeout", Timestamp: now.Add(-time.Minute)},
+			{Name: "api", URL: "https://api.example.com", Success: true, Timestamp: now.Add(-time.Minute)},
+			{Name: "web", URL: "https://example.com", Success: true, Timestamp: now.Add(-2 * time.Hour)},
+		}
+		for _, result := range results {
+			if err := s.SaveResult(result); err != nil {
+				t.Fatalf("SaveResult failed: %v", err)
+			}
+		}
+
+		history, err := s.GetHistory("web", now.Add(-time.Hour))
+		if err != nil || len(history) != 2 {
+			t.Fatalf("Expected 2 recent web results, got %d (%v)", len(history), err)
+		}
+		if history[0].Error != "timeout" || history[0].Success {
+			t.Errorf("Expected the newest result first, got %+v", history[0])
+		}
+		entry := history[1]
+		if entry.Status != 200 || entry.Duration != saved.Duration || entry.Attempts != 2 ||
+			entry.Severity != monitor.SeverityWarning || entry.Timings != saved.Timings ||
+			!entry.Timestamp.Equal(saved.Timestamp) || entry.CreatedAt.IsZero() {
+			t.Errorf("Result did not round trip: %+v", entry)
+		}
+		if len(entry.Assertions) != 1 || !entry.Assertions[0].Passed || len(entry.Metrics) != 1 || entry.Metrics[0].Value != 0.5 {
+			t.Errorf("Expected the assertions and metrics to be stored, got %+v", entry)
+		}
+
+		all, err := s.GetAllHistory(now.Add(-time.Hour))
+		if err != nil || len(all) != 3 {
+			t.Errorf("Expected 3 recent results, got %d (%v)", len(all), err)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		s := open(t)
+		for i, latency := range []time.Duration{100, 200, 300} {
+			result := monitor.Result{Name: "web", URL: "https://example.com", Success: true,
+				Duration: latency * time.Millisecond, Timestamp: now.Add(-time.Duration(3-i) * time.Minute)}
+			if err := s.SaveResult(result); err != nil {
+				t.Fatalf("SaveResult failed: %v", err)
+			}
+		}
+		dual := monitor.Result{Name: "dual", Success: false, Timestamp: now, Families: []monitor.Result{
+			{Name: "dual", URL: "https://dual.example", Success: true, Duration: 50 * time.Millisecond, Timestamp: now, IPVersion: "v4"},
+			{Name: "dual", URL: "https://dual.example", Error: "no route", Timestamp: now, IPVersion: "v6"},
+		}}
+		if err := s.SaveResult(dual); err != nil {
+			t.Fatalf("SaveResult failed: %v", err)
+		}
+
+		stats, err := s.GetStats("web", now.Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("GetStats failed: %v", err)
+		}
+		if stats.TotalChecks != 3 || stats.SuccessRate != 100 || stats.AvgResponseTime != 200*time.Millisecond ||
+			stats.MinResponseTime != 100*time.Millisecond || stats.MaxResponseTime != 300*time.Millisecond {
+			t.Errorf("Unexpected stats: %+v", stats)
+		}
+		if stats.P50ResponseTime < 196*time.Millisecond || stats.P50ResponseTime > 204*time.Millisecond {
+			t.Errorf("Expected a p50 near 200ms, got %v", stats.P50ResponseTime)
+		}
+
+		allStats, err := s.GetAllStats(now.Add(-time.Hour))
+		if err != nil || len(allStats) != 2 {
+			t.Fatalf("Expected stats for 2 sites, got %v (%v)", allStats, err)
+		}
+		families := allStats["dual"].Families
+		if allStats["dual"].TotalChecks != 2 || families["v4"].SuccessRate != 100 || families["v6"].FailedChecks != 1 {
+			t.Errorf("Unexpected dual-stack stats: %+v", allStats["dual"])
+		}
+	})
+
+	t.Run("Rollups", func(t *testing.T) {
+		s := open(t)
+		expected := seedResults(t, s, now)
+
+		if err := s.Rollup(now); err != nil {
+			t.Fatalf("Rollup failed: %v", err)
+		}
+		removed, _, err := s.ApplyRetention(config.RetentionConfig{RawDataDays: 1}, now)
+		if err != nil || removed != 60 {
+			t.Fatalf("Expected 60 expired results removed, got %d (%v)", removed, err)
+		}
+
+		stats, err := s.GetStats("api", now.Add(-30*24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetStats failed: %v", err)
+		}
+		assertStats(t, stats, expected)
+	})
+
+	t.Run("Heartbeats", func(t *testing.T) {
+		s := open(t)
+		for i, kind := range []string{HeartbeatStart, HeartbeatSuccess, HeartbeatFail} {
+			ping := HeartbeatPing{SiteName: "backup", Kind: kind, ExitCode: i, Timestamp: now.Add(time.Duration(i) * time.Second)}
+			if err := s.SaveHeartbeatPing(ping); err != nil {
+				t.Fatalf("SaveHeartbeatPing failed: %v", err)
+			}
+		}
+
+		pings, err := s.GetLatestHeartbeatPings("backup", 2)
+		if err != nil || len(pings) != 2 {
+			t.Fatalf("Expected 2 pings, got %d (%v)", len(pings), err)
+		}
+		if pings[0].Kind != HeartbeatFail || pings[0].ExitCode != 2 || pings[1].Kind != HeartbeatSuccess {
+			t.Errorf("Expected the newest pings first, got %+v", pings)
+		}
+	})
+
+	t.Run("Migrations", func(t *testing.T) {
+		s := open(t)
+		status, err := s.SchemaStatus()
+		if err != nil || status.Current != status.Latest || len(status.Pending()) != 0 {
+			t.Fatalf("Expected an up to date schema, got %+v (%v)", status, err)
+		}
+		if applied, err := s.Migrate(); err != nil || len(applied) != 0 {
+			t.Errorf("Expected nothing to apply, got %v (%v)", applied, err)
+		}
+		if err := s.Init(); err != nil {
+			t.Errorf("Expected Init to be repeatable, got %v", err)
+		}
+	})
+}
+
+func TestSQLiteStorage_Conformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) conformingStorage {
+		s := newTestSQLite(t)
+		if err := s.Init(); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		return s
+	})
+}
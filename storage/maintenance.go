@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"site-monitor/config"
+	"time"
+)
+
+const (
+	// rollupInterval is how often completed buckets are rolled up
+	rollupInterval = 5 * time.Minute
+
+	// purgeInterval is how often expired results and rollups are deleted
+	purgeInterval = time.Hour
+)
+
+// Rollup aggregates the raw results of completed minutes into per-minute
+// rollups, and those into hourly and daily rollups. The backlog is processed
+// in chunks, each in its own transaction, so saving results is not held up.
+func (s *SQLiteStorage) Rollup(now time.Time) error {
+	store := s.store()
+	for {
+		s.mu.Lock()
+		more, err := store.step(now)
+		s.mu.Unlock()
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+// ApplyRetention deletes raw results older than retention.raw_data_days and
+// rollups older than retention.aggregated_data_days, keeping anything not
+// rolled up yet. It returns how many results and rollups were removed.
+func (s *SQLiteStorage) ApplyRetention(retention config.RetentionConfig, now time.Time) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.store().applyRetention(retention, now)
+}
+
+// Vacuum rebuilds the database file to reclaim the space of deleted rows
+func (s *SQLiteStorage) Vacuum() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
+
+// Maintain rolls up results every few minutes, applies storage.retention
+// every hour and vacuums the database every storage.sqlite.vacuum_interval
+// until ctx is cancelled
+func (s *SQLiteStorage) Maintain(ctx context.Context, cfg config.StorageConfig) {
+	vacuumInterval, err := cfg.SQLite.GetVacuumInterval()
+	if err != nil {
+		log.Printf("⚠️ Invalid storage.sqlite.vacuum_interval, not vacuuming: %v", err)
+	}
+	maintain(ctx, s, cfg.Retention, vacuumInterval, s.Vacuum)
+}
+
+// maintained is implemented by the storages Maintain runs jobs against
+type maintained interface {
+	Rollup(now time.Time) error
+	ApplyRetention(retention config.RetentionConfig, now time.Time) (int64, int64, error)
+}
+
+// maintain runs the rollup and retention jobs, and vacuum every
+// vacuumInterval when it is positive, until ctx is cancelled
+func maintain(ctx context.Context, s maintained, retention config.RetentionConfig, vacuumInterval time.Duration, vacuum func() error) {
+	rollup := func() {
+		if err := s.Rollup(time.Now()); err != nil {
+			log.Printf("❌ %v", err)
+		}
+	}
+	purge := func() {
+		results, rollups, err := s.ApplyRetention(retention, time.Now())
+		if err != nil {
+			log.Printf("❌ %v", err)
+		}
+		if results > 0 {
+			log.Printf("🧹 Removed %d results older than %d days", results, retention.RawDataDays)
+		}
+		if rollups > 0 {
+			log.Printf("🧹 Removed %d expired rollups", rollups)
+		}
+	}
+	rollup()
+	purge()
+
+	rollupTicker := time.NewTicker(rollupInterval)
+	defer rollupTicker.Stop()
+
+	purgeTicker := time.NewTicker(purgeInterval)
+	defer purgeTicker.Stop()
+
+	var vacuums <-chan time.Time
+	if vacuumInterval > 0 {
+		vacuumTicker := time.NewTicker(vacuumInterval)
+		defer vacuumTicker.Stop()
+		vacuums = vacuumTicker.C
+	}
+
+	for {
+		select {
+		case <-rollupTicker.C:
+			rollup()
+		case <-purgeTicker.C:
+			purge()
+		case <-vacuums:
+			if err := vacuum(); err != nil {
+				log.Printf("❌ %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when a database was migrated by a newer
+// version of site-monitor than the running one
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of site-monitor supports")
+
+// Migration is a versioned schema change, applied in its own transaction
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *sql.Tx) error
+}
+
+// MigrationState describes a migration and when it was applied
+type MigrationState struct {
+	Version     int       `json:"version"`
+	Description string    `json:"description"`
+	AppliedAt   time.Time `json:"applied_at,omitempty"` // Zero while pending
+}
+
+// SchemaStatus describes the schema version of a database
+type SchemaStatus struct {
+	Current    int              `json:"current"` // Highest applied version, 0 for a new database
+	Latest     int              `json:"latest"`  // Highest version known to this build
+	Migrations []MigrationState `json:"migrations"`
+}
+
+// Pending returns the migrations not applied yet
+func (s SchemaStatus) Pending() []MigrationState {
+	var pending []MigrationState
+	for _, migration := range s.Migrations {
+		if migration.AppliedAt.IsZero() {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// migrator applies migrations to a database, recording them in the
+// schema_migrations table
+type migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	bind       func(query string) string // Rewrites ? placeholders for the driver
+
+	// lock is run first in each migration transaction, serializing
+	// instances sharing the database; empty when there is one writer
+	lock string
+
+	// tableExists counts the schema_migrations tables, so that status can
+	// tell a new database apart without creating anything
+	tableExists string
+}
+
+// ensureTable creates the schema_migrations table. It holds the lock while
+// doing so, since concurrent CREATE TABLE IF NOT EXISTS statements can still
+// collide on PostgreSQL.
+func (m *migrator) ensureTable() error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	defer tx.Rollback()
+
+	if m.lock != "" {
+		if _, err := tx.Exec(m.lock); err != nil {
+			return fmt.Errorf("failed to lock the schema: %w", err)
+		}
+	}
+
+	createSQL := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`
+	if _, err := tx.Exec(createSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// status reports which migrations have been applied. It only reads the
+// database: without a schema_migrations table every migration is pending.
+func (m *migrator) status() (SchemaStatus, error) {
+	var tables int
+	if err := m.db.QueryRow(m.tableExists).Scan(&tables); err != nil {
+		return SchemaStatus{}, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]time.Time)
+	var status SchemaStatus
+	if tables > 0 {
+		current, err := m.applied(applied)
+		if err != nil {
+			return SchemaStatus{}, err
+		}
+		status.Current = current
+	}
+
+	for _, migration := range m.migrations {
+		status.Migrations = append(status.Migrations, MigrationState{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   applied[migration.Version],
+		})
+		if migration.Version > status.Latest {
+			status.Latest = migration.Version
+		}
+	}
+
+	return status, nil
+}
+
+// applied records when each migration was applied in applied and returns
+// the highest version
+func (m *migrator) applied(applied map[int]time.Time) (int, error) {
+	rows, err := m.db.Query("SELECT version, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	current := 0
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return 0, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+		if version > current {
+			current = version
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	return current, nil
+}
+
+// migrate applies the pending migrations in order and returns them. It
+// refuses to touch a database whose schema is newer than the latest
+// migration known to this build.
+func (m *migrator) migrate() ([]MigrationState, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	status, err := m.status()
+	if err != nil {
+		return nil, err
+	}
+	if status.Current > status.Latest {
+		return nil, fmt.Errorf("%w: database is at version %d, this build supports up to %d; upgrade site-monitor",
+			ErrSchemaTooNew, status.Current, status.Latest)
+	}
+
+	var applied []MigrationState
+	for i, state := range status.Migrations {
+		if !state.AppliedAt.IsZero() {
+			continue
+		}
+		state.AppliedAt = time.Now().UTC()
+		done, err := m.apply(m.migrations[i], state.AppliedAt)
+		if err != nil {
+			return applied, err
+		}
+		if done {
+			applied = append(applied, state)
+		}
+	}
+
+	return applied, nil
+}
+
+// apply runs one migration and records it in the same transaction. It
+// reports false when another instance applied it first.
+func (m *migrator) apply(migration Migration, appliedAt time.Time) (bool, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	if m.lock != "" {
+		if _, err := tx.Exec(m.lock); err != nil {
+			return false, fmt.Errorf("migration %d: failed to lock the schema: %w", migration.Version, err)
+		}
+		var count int
+		if err := tx.QueryRow(m.bind("SELECT COUNT(*) FROM schema_migrations WHERE version = ?"), migration.Version).Scan(&count); err != nil {
+			return false, fmt.Errorf("migration %d: %w", migration.Version, err)
+		}
+		if count > 0 {
+			return false, nil
+		}
+	}
+
+	if err := migration.Up(tx); err != nil {
+		return false, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+	}
+
+	insertSQL := m.bind("INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)")
+	if _, err := tx.Exec(insertSQL, migration.Version, migration.Description, appliedAt); err != nil {
+		return false, fmt.Errorf("migration %d: failed to record it: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("migration %d: %w", migration.Version, err)
+	}
+	return true, nil
+}
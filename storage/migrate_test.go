@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"site-monitor/monitor"
+	"testing"
+	"time"
+)
+
+// newTestSQLite opens a SQLite storage in a temp dir without initializing it
+func newTestSQLite(t *testing.T) *SQLiteStorage {
+	t.Helper()
+	s, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "monitor.db"))
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLiteStorage_Migrate(t *testing.T) {
+	s := newTestSQLite(t)
+
+	status, err := s.SchemaStatus()
+	if err != nil {
+		t.Fatalf("SchemaStatus failed: %v", err)
+	}
+	if status.Current != 0 || len(status.Pending()) != len(sqliteMigrations) {
+		t.Fatalf("Expected every migration pending on a new database, got %+v", status)
+	}
+
+	// Reporting the status leaves the database untouched
+	var tables int
+	s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables)
+	if tables != 0 {
+		t.Errorf("Expected SchemaStatus to create no table, found %d", tables)
+	}
+
+	applied, err := s.Migrate()
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(applied) != len(sqliteMigrations) {
+		t.Errorf("Expected %d migrations applied, got %d", len(sqliteMigrations), len(applied))
+	}
+
+	status, _ = s.SchemaStatus()
+	if status.Current != status.Latest || len(status.Pending()) != 0 {
+		t.Errorf("Expected an up to date schema, got %+v", status)
+	}
+
+	// Migrating again is a no-op
+	if applied, err := s.Migrate(); err != nil || len(applied) != 0 {
+		t.Errorf("Expected nothing to apply, got %v (%v)", applied, err)
+	}
+}
+
+func TestSQLiteStorage_MigrateLegacyDatabase(t *testing.T) {
+	s := newTestSQLite(t)
+
+	// A database created before migrations were tracked, lacking the newer columns
+	legacySQL := `
+	CREATE TABLE results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		site_name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		status_code INTEGER DEFAULT 0,
+		response_time_ns INTEGER NOT NULL,
+		success BOOLEAN NOT NULL,
+		error_message TEXT DEFAULT '',
+		timestamp DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		assertions TEXT DEFAULT ''
+	);
+	INSERT INTO results (site_name, url, response_time_ns, success, timestamp)
+	VALUES ('legacy', 'https://example.com', 1000000, 1, '2024-01-01T00:00:00Z');`
+	if _, err := s.db.Exec(legacySQL); err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init failed on a legacy database: %v", err)
+	}
+
+	if err := s.SaveResult(monitor.Result{Name: "legacy", URL: "https://example.com", Success: true,
+		Timestamp: time.Now(), Attempts: 2, IPVersion: "v6"}); err != nil {
+		t.Fatalf("SaveResult failed after migrating: %v", err)
+	}
+	history, err := s.GetHistory("legacy", time.Time{})
+	if err != nil || len(history) != 2 {
+		t.Fatalf("Expected the old and new rows, got %d (%v)", len(history), err)
+	}
+	if history[0].Attempts != 2 || history[0].IPVersion != "v6" {
+		t.Errorf("Expected the new columns to be stored, got %+v", history[0])
+	}
+}
+
+func TestSQLiteStorage_RefusesNewerSchema(t *testing.T) {
+	s := newTestSQLite(t)
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	future := len(sqliteMigrations) + 1
+	if _, err := s.db.Exec("INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)",
+		future, "From the future", time.Now()); err != nil {
+		t.Fatalf("Failed to record a future migration: %v", err)
+	}
+
+	if err := s.Init(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestMigrator_RollsBackFailedMigration(t *testing.T) {
+	s := newTestSQLite(t)
+	m := s.migrator()
+	m.migrations = append(append([]Migration(nil), sqliteMigrations...), Migration{
+		Version:     len(sqliteMigrations) + 1,
+		Description: "Broken",
+		Up: func(tx *sql.Tx) error {
+			if _, err := tx.Exec("CREATE TABLE half_done (id INTEGER)"); err != nil {
+				return err
+			}
+			_, err := tx.Exec("ALTER TABLE missing ADD COLUMN x TEXT")
+			return err
+		},
+	})
+
+	if _, err := m.migrate(); err == nil {
+		t.Fatal("Expected the broken migration to fail")
+	}
+
+	status, _ := m.status()
+	if status.Current != len(sqliteMigrations) {
+		t.Errorf("Expected the earlier migrations to stay applied, got version %d", status.Current)
+	}
+	var count int
+	s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'").Scan(&count)
+	if count != 0 {
+		t.Error("Expected the broken migration to be rolled back")
+	}
+}
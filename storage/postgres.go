@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// Advisory locks serializing the instances sharing a PostgreSQL database
+const (
+	postgresMigrationLock = "SELECT pg_advisory_xact_lock(7305501)"
+	postgresRollupLock    = "SELECT pg_advisory_xact_lock(7305502)"
+)
+
+// PostgresStorage implements Storage interface using PostgreSQL, so several
+// monitor instances and the dashboard can share one database
+type PostgresStorage struct {
+	db *sql.DB
+}
+
+// NewPostgresStorage creates a PostgreSQL storage instance from a DSN, either
+// a postgres:// URL or key=value pairs
+func NewPostgresStorage(dsn string) (*PostgresStorage, error) {
+	return NewPostgresStorageFromConfig(config.PostgresConfig{DSN: dsn})
+}
+
+// NewPostgresStorageFromConfig creates a PostgreSQL storage instance from the
+// storage.postgres section of the configuration
+func NewPostgresStorageFromConfig(cfg config.PostgresConfig) (*PostgresStorage, error) {
+	db, err := sql.Open("postgres", cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Configure connection pool
+	db.SetMaxOpenConns(cfg.GetMaxOpenConns())
+	db.SetMaxIdleConns(max(cfg.GetMaxOpenConns()/2, 1))
+	db.SetConnMaxLifetime(time.Hour)
+
+	return &PostgresStorage{db: db}, nil
+}
+
+// Init brings the schema up to date by applying pending migrations. It fails
+// with ErrSchemaTooNew against a database migrated by a newer version.
+func (s *PostgresStorage) Init() error {
+	_, err := s.Migrate()
+	return err
+}
+
+// Migrate applies the pending migrations and returns them. Instances
+// starting together apply each migration once.
+func (s *PostgresStorage) Migrate() ([]MigrationState, error) {
+	return s.migrator().migrate()
+}
+
+// SchemaStatus reports the schema version and the applied and pending migrations
+func (s *PostgresStorage) SchemaStatus() (SchemaStatus, error) {
+	return s.migrator().status()
+}
+
+// migrator returns the migration runner for the PostgreSQL schema
+func (s *PostgresStorage) migrator() *migrator {
+	return &migrator{
+		db:         s.db,
+		migrations: postgresMigrations,
+		bind:       postgresBind,
+		lock:       postgresMigrationLock,
+		tableExists: `SELECT COUNT(*) FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`,
+	}
+}
+
+// store returns the queries shared with the other SQL backends
+func (s *PostgresStorage) store() *sqlStore {
+	return &sqlStore{
+		db:   s.db,
+		bind: postgresBind,
+		lock: postgresRollupLock,
+	}
+}
+
+// postgresBind rewrites ? placeholders into PostgreSQL's $1, $2...
+func postgresBind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// SaveResult stores a monitoring result in the database. A dual-stack
+// result is stored as one row per address family.
+func (s *PostgresStorage) SaveResult(result monitor.Result) error {
+	return s.store().saveResult(result)
+}
+
+// GetHistory retrieves monitoring history for a specific site
+func (s *PostgresStorage) GetHistory(siteName string, since time.Time) ([]HistoryEntry, error) {
+	return s.store().history("site_name = ? AND timestamp >= ?", siteName, since)
+}
+
+// GetAllHistory retrieves monitoring history for all sites
+func (s *PostgresStorage) GetAllHistory(since time.Time) ([]HistoryEntry, error) {
+	return s.store().history("timestamp >= ?", since)
+}
+
+// GetStats calculates statistics for a specific site. Windows longer than
+// a few hours are read from the rollups.
+func (s *PostgresStorage) GetStats(siteName string, since time.Time) (Stats, error) {
+	return s.store().stats(siteName, since, time.Now())
+}
+
+// GetAllStats calculates statistics for all sites
+func (s *PostgresStorage) GetAllStats(since time.Time) (map[string]Stats, error) {
+	return s.store().allStats(since, time.Now())
+}
+
+// SaveHeartbeatPing records a ping received for a heartbeat monitor
+func (s *PostgresStorage) SaveHeartbeatPing(ping HeartbeatPing) error {
+	return s.store().saveHeartbeatPing(ping)
+}
+
+// GetLatestHeartbeatPings returns up to limit pings for a site, newest first
+func (s *PostgresStorage) GetLatestHeartbeatPings(siteName string, limit int) ([]HeartbeatPing, error) {
+	return s.store().latestHeartbeatPings(siteName, limit)
+}
+
+// Rollup aggregates the raw results of completed minutes into per-minute
+// rollups, and those into hourly and daily rollups. Instances sharing the
+// database take turns, each chunk being rolled up once.
+func (s *PostgresStorage) Rollup(now time.Time) error {
+	store := s.store()
+	for {
+		more, err := store.step(now)
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+// ApplyRetention deletes raw results older than retention.raw_data_days and
+// rollups older than retention.aggregated_data_days, keeping anything not
+// rolled up yet. It returns how many results and rollups were removed.
+func (s *PostgresStorage) ApplyRetention(retention config.RetentionConfig, now time.Time) (int64, int64, error) {
+	return s.store().applyRetention(retention, now)
+}
+
+// Maintain rolls up results every few minutes and applies storage.retention
+// every hour until ctx is cancelled. PostgreSQL reclaims space itself with
+// autovacuum.
+func (s *PostgresStorage) Maintain(ctx context.Context, cfg config.StorageConfig) {
+	maintain(ctx, s, cfg.Retention, 0, nil)
+}
+
+// Close closes the database connection
+func (s *PostgresStorage) Close() error {
+	return s.db.Close()
+}
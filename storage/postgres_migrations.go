@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// postgresMigrations is the PostgreSQL schema history, kept in step with
+// sqliteMigrations. Append new migrations with the next version; never edit
+// one that has been released.
+var postgresMigrations = []Migration{
+	{Version: 1, Description: "Create results and heartbeat_pings tables", Up: postgresBaseline},
+	{Version: 2, Description: "Create result_rollups and rollup_progress tables", Up: postgresRollups},
+}
+
+// postgresBaseline creates the results and heartbeat_pings tables
+func postgresBaseline(tx *sql.Tx) error {
+	createTableSQL := `
+	CREATE TABLE results (
+		id BIGSERIAL PRIMARY KEY,
+		site_name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		status_code INTEGER DEFAULT 0,
+		response_time_ns BIGINT NOT NULL,
+		success BOOLEAN NOT NULL,
+		error_message TEXT DEFAULT '',
+		timestamp TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		assertions TEXT DEFAULT '',
+		dns_ns BIGINT DEFAULT 0,
+		connect_ns BIGINT DEFAULT 0,
+		tls_ns BIGINT DEFAULT 0,
+		ttfb_ns BIGINT DEFAULT 0,
+		transfer_ns BIGINT DEFAULT 0,
+		severity TEXT DEFAULT '',
+		metrics TEXT DEFAULT '',
+		steps TEXT DEFAULT '',
+		attempts INTEGER DEFAULT 0,
+		ip_version TEXT DEFAULT ''
+	);
+	CREATE INDEX idx_site_timestamp ON results(site_name, timestamp DESC);
+	CREATE INDEX idx_timestamp ON results(timestamp DESC);
+	CREATE INDEX idx_site_success ON results(site_name, success);
+	CREATE INDEX idx_success_timestamp ON results(success, timestamp DESC);`
+
+	if _, err := tx.Exec(createTableSQL); err != nil {
+		return fmt.Errorf("failed to create results table: %w", err)
+	}
+
+	createHeartbeatsSQL := `
+	CREATE TABLE heartbeat_pings (
+		id BIGSERIAL PRIMARY KEY,
+		site_name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		exit_code INTEGER DEFAULT 0,
+		timestamp TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_heartbeat_site_id ON heartbeat_pings(site_name, id DESC);`
+
+	if _, err := tx.Exec(createHeartbeatsSQL); err != nil {
+		return fmt.Errorf("failed to create heartbeat_pings table: %w", err)
+	}
+
+	return nil
+}
+
+// postgresRollups creates the tables holding per-minute, hourly and daily
+// aggregates of the results
+func postgresRollups(tx *sql.Tx) error {
+	createRollupsSQL := `
+	CREATE TABLE result_rollups (
+		resolution TEXT NOT NULL,
+		site_name TEXT NOT NULL,
+		bucket TIMESTAMPTZ NOT NULL,
+		ip_version TEXT NOT NULL DEFAULT '',
+		total BIGINT NOT NULL,
+		successes BIGINT NOT NULL,
+		latency_sum_ns BIGINT NOT NULL DEFAULT 0,
+		latency_min_ns BIGINT NOT NULL DEFAULT 0,
+		latency_max_ns BIGINT NOT NULL DEFAULT 0,
+		dns_sum_ns BIGINT NOT NULL DEFAULT 0,
+		connect_sum_ns BIGINT NOT NULL DEFAULT 0,
+		tls_sum_ns BIGINT NOT NULL DEFAULT 0,
+		ttfb_sum_ns BIGINT NOT NULL DEFAULT 0,
+		transfer_sum_ns BIGINT NOT NULL DEFAULT 0,
+		first_check TIMESTAMPTZ NOT NULL,
+		last_check TIMESTAMPTZ NOT NULL,
+		sketch TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (resolution, site_name, bucket, ip_version)
+	);
+	CREATE INDEX idx_rollups_bucket ON result_rollups(resolution, bucket);`
+
+	if _, err := tx.Exec(createRollupsSQL); err != nil {
+		return fmt.Errorf("failed to create result_rollups table: %w", err)
+	}
+
+	createProgressSQL := `
+	CREATE TABLE rollup_progress (
+		resolution TEXT PRIMARY KEY,
+		rolled_until TIMESTAMPTZ NOT NULL
+	);`
+
+	if _, err := tx.Exec(createProgressSQL); err != nil {
+		return fmt.Errorf("failed to create rollup_progress table: %w", err)
+	}
+
+	return nil
+}
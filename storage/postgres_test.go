@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testPostgresDSNEnv points the tests at an existing server instead of
+// starting one; it must allow creating databases
+const testPostgresDSNEnv = "SITE_MONITOR_TEST_POSTGRES_DSN"
+
+// testPostgres is the server shared by the tests of the package, started on
+// first use from the PostgreSQL binaries on the PATH
+var testPostgres struct {
+	once      sync.Once
+	dsn       string // Connects to the maintenance database
+	stop      func()
+	err       error // Why no server is available
+	databases atomic.Int32
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if testPostgres.stop != nil {
+		testPostgres.stop()
+	}
+	os.Exit(code)
+}
+
+// startTestPostgres initializes a throwaway cluster and starts it on a free port
+func startTestPostgres() (string, func(), error) {
+	if dsn := os.Getenv(testPostgresDSNEnv); dsn != "" {
+		return dsn, nil, nil
+	}
+
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		// Debian and Ubuntu keep the server binaries off the PATH
+		matches, _ := filepath.Glob("/usr/lib/postgresql/*/bin/pg_ctl")
+		if len(matches) == 0 {
+			return "", nil, fmt.Errorf("no PostgreSQL found; install it or set %s", testPostgresDSNEnv)
+		}
+		pgCtl = matches[len(matches)-1]
+	}
+	initdb := filepath.Join(filepath.Dir(pgCtl), "initdb")
+
+	// A short directory keeps the socket path under the platform limit
+	dir, err := os.MkdirTemp("", "pg")
+	if err != nil {
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-N").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("initdb failed: %v\n%s", err, out)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -F", port, dir)
+	if out, err := exec.Command(pgCtl, "-D", data, "-l", filepath.Join(dir, "log"), "-o", options, "-w", "start").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("pg_ctl start failed: %v\n%s", err, out)
+	}
+
+	stop := func() {
+		exec.Command(pgCtl, "-D", data, "-m", "immediate", "stop").Run()
+		os.RemoveAll(dir)
+	}
+	return fmt.Sprintf("host=127.0.0.1 port=%d user=postgres dbname=postgres sslmode=disable", port), stop, nil
+}
+
+// newTestPostgres creates an empty database on the test server and opens a
+// storage on it, skipping the test when PostgreSQL is not available outside CI
+func newTestPostgres(t *testing.T) *PostgresStorage {
+	t.Helper()
+
+	testPostgres.once.Do(func() {
+		testPostgres.dsn, testPostgres.stop, testPostgres.err = startTestPostgres()
+	})
+	if testPostgres.err != nil {
+		// CI provides a server, so the backend must never go untested there
+		if os.Getenv("CI") != "" {
+			t.Fatalf("PostgreSQL unavailable: %v", testPostgres.err)
+		}
+		t.Skipf("PostgreSQL unavailable: %v", testPostgres.err)
+	}
+
+	admin, err := sql.Open("postgres", testPostgres.dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer admin.Close()
+
+	name := fmt.Sprintf("site_monitor_test_%d_%d", os.Getpid(), testPostgres.databases.Add(1))
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	s, err := NewPostgresStorage(withDatabase(testPostgres.dsn, name))
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+		if admin, err := sql.Open("postgres", testPostgres.dsn); err == nil {
+			admin.Exec("DROP DATABASE IF EXISTS " + name)
+			admin.Close()
+		}
+	})
+	return s
+}
+
+// withDatabase points a DSN, URL or key=value, at another database
+func withDatabase(dsn, name string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		u.Path = "/" + name
+		return u.String()
+	}
+	return strings.TrimSpace(dsn) + " dbname=" + name
+}
+
+func TestPostgresStorage_Conformance(t *testing.T) {
+	testConformance(t, func(t *testing.T) conformingStorage {
+		s := newTestPostgres(t)
+		if err := s.Init(); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		return s
+	})
+}
+
+func TestPostgresStorage_ConcurrentMigrate(t *testing.T) {
+	s := newTestPostgres(t)
+	instances := []*PostgresStorage{s}
+	for i := 0; i < 3; i++ {
+		other, err := NewPostgresStorage(withDatabase(testPostgres.dsn, mustCurrentDatabase(t, s)))
+		if err != nil {
+			t.Fatalf("Failed to open storage: %v", err)
+		}
+		defer other.Close()
+		instances = append(instances, other)
+	}
+
+	// Instances starting together on an empty database create the
+	// schema_migrations table and apply each migration once
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, len(instances))
+	for i, db := range instances {
+		wg.Add(1)
+		go func(i int, db *PostgresStorage) {
+			defer wg.Done()
+			<-start
+			errs[i] = db.Init()
+		}(i, db)
+	}
+	close(start)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("Init failed: %v", err)
+		}
+	}
+	status, err := s.SchemaStatus()
+	if err != nil || status.Current != len(postgresMigrations) {
+		t.Errorf("Expected the schema at version %d, got %+v (%v)", len(postgresMigrations), status, err)
+	}
+}
+
+// mustCurrentDatabase returns the name of the database a storage uses
+func mustCurrentDatabase(t *testing.T, s *PostgresStorage) string {
+	t.Helper()
+	var name string
+	if err := s.db.QueryRow("SELECT current_database()").Scan(&name); err != nil {
+		t.Fatalf("Failed to read the database name: %v", err)
+	}
+	return name
+}
+
+func TestPostgresBind(t *testing.T) {
+	got := postgresBind("SELECT * FROM results WHERE site_name = ? AND timestamp >= ? LIMIT ?")
+	expected := "SELECT * FROM results WHERE site_name = $1 AND timestamp >= $2 LIMIT $3"
+	if got != expected {
+		t.Errorf("postgresBind() = %q, expected %q", got, expected)
+	}
+
+	if got := withDatabase("postgres://u:p@db:5432/postgres?sslmode=disable", "x"); got != "postgres://u:p@db:5432/x?sslmode=disable" {
+		t.Errorf("Unexpected URL DSN %q", got)
+	}
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"site-monitor/monitor"
+	"time"
+)
+
+// sqlStore implements the queries shared by the SQL backends. Its SQL is
+// portable, with ? placeholders rewritten by bind for the driver.
+type sqlStore struct {
+	db   *sql.DB
+	bind func(query string) string
+
+	// lock is run first in each rollup transaction, serializing instances
+	// sharing the database; empty when there is one writer
+	lock string
+}
+
+// saveResult stores a monitoring result. A dual-stack result is stored as
+// one row per address family.
+func (q *sqlStore) saveResult(result monitor.Result) error {
+	if len(result.Families) == 0 {
+		return q.insertResult(q.db, result)
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save result: %w", err)
+	}
+	for _, family := range result.Families {
+		if err := q.insertResult(tx, family); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save result: %w", err)
+	}
+	return nil
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertResult inserts a single result row
+func (q *sqlStore) insertResult(db execer, result monitor.Result) error {
+	insertSQL := q.bind(`
+	INSERT INTO results (site_name, url, status_code, response_time_ns, success, error_message, timestamp, assertions,
+		dns_ns, connect_ns, tls_ns, ttfb_ns, transfer_ns, severity, metrics, steps, attempts, ip_version)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+
+	assertions, err := encodeJSON(result.Assertions)
+	if err != nil {
+		return fmt.Errorf("failed to encode assertions: %w", err)
+	}
+
+	metrics, err := encodeJSON(result.Metrics)
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+
+	steps, err := encodeJSON(result.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to encode steps: %w", err)
+	}
+
+	_, err = db.Exec(
+		insertSQL,
+		result.Name,
+		result.URL,
+		result.Status,
+		result.Duration.Nanoseconds(),
+		result.Success,
+		result.Error,
+		result.Timestamp,
+		assertions,
+		result.Timings.DNS.Nanoseconds(),
+		result.Timings.Connect.Nanoseconds(),
+		result.Timings.TLS.Nanoseconds(),
+		result.Timings.TTFB.Nanoseconds(),
+		result.Timings.Transfer.Nanoseconds(),
+		string(result.Severity),
+		metrics,
+		steps,
+		result.Attempts,
+		result.IPVersion,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save result: %w", err)
+	}
+
+	return nil
+}
+
+// history retrieves the results matching a condition, newest first
+func (q *sqlStore) history(where string, args ...interface{}) ([]HistoryEntry, error) {
+	querySQL := q.bind(`
+	SELECT id, site_name, url, status_code, response_time_ns, success, error_message, timestamp, created_at, assertions,
+		dns_ns, connect_ns, tls_ns, ttfb_ns, transfer_ns, severity, metrics, steps, attempts, ip_version
+	FROM results
+	WHERE ` + where + `
+	ORDER BY timestamp DESC`)
+
+	rows, err := q.db.Query(querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	return scanHistoryEntries(rows)
+}
+
+// scanHistoryEntries is a helper function to scan database rows into HistoryEntry structs
+func scanHistoryEntries(rows *sql.Rows) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+
+	for rows.Next() {
+		var entry HistoryEntry
+		var responseTimeNs int64
+		var timestampStr, createdAtStr string
+		var assertions, severity, metrics, steps, ipVersion sql.NullString
+		var dnsNs, connectNs, tlsNs, ttfbNs, transferNs, attempts sql.NullInt64
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.SiteName,
+			&entry.URL,
+			&entry.Status,
+			&responseTimeNs,
+			&entry.Success,
+			&entry.Error,
+			&timestampStr,
+			&createdAtStr,
+			&assertions,
+			&dnsNs,
+			&connectNs,
+			&tlsNs,
+			&ttfbNs,
+			&transferNs,
+			&severity,
+			&metrics,
+			&steps,
+			&attempts,
+			&ipVersion,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		entry.Duration = time.Duration(responseTimeNs)
+		entry.Timings = monitor.Timings{
+			DNS:      time.Duration(dnsNs.Int64),
+			Connect:  time.Duration(connectNs.Int64),
+			TLS:      time.Duration(tlsNs.Int64),
+			TTFB:     time.Duration(ttfbNs.Int64),
+			Transfer: time.Duration(transferNs.Int64),
+		}
+
+		entry.Attempts = int(attempts.Int64)
+		entry.IPVersion = ipVersion.String
+		entry.Severity = monitor.Severity(severity.String)
+		if err := decodeJSON(assertions.String, &entry.Assertions); err != nil {
+			return nil, fmt.Errorf("failed to decode assertions: %w", err)
+		}
+		if err := decodeJSON(metrics.String, &entry.Metrics); err != nil {
+			return nil, fmt.Errorf("failed to decode metrics: %w", err)
+		}
+		if err := decodeJSON(steps.String, &entry.Steps); err != nil {
+			return nil, fmt.Errorf("failed to decode steps: %w", err)
+		}
+
+		// Convertir les timestamps strings en time.Time
+		if parsedTime, err := time.Parse(time.RFC3339, timestampStr); err == nil {
+			entry.Timestamp = parsedTime
+		} else if parsedTime, err := time.Parse("2006-01-02 15:04:05.999999999-07:00", timestampStr); err == nil {
+			entry.Timestamp = parsedTime
+		} else if parsedTime, err := time.Parse("2006-01-02T15:04:05Z", timestampStr); err == nil {
+			entry.Timestamp = parsedTime
+		}
+
+		if parsedTime, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
+			entry.CreatedAt = parsedTime
+		} else if parsedTime, err := time.Parse("2006-01-02 15:04:05.999999999-07:00", createdAtStr); err == nil {
+			entry.CreatedAt = parsedTime
+		} else if parsedTime, err := time.Parse("2006-01-02T15:04:05Z", createdAtStr); err == nil {
+			entry.CreatedAt = parsedTime
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return entries, nil
+}
+
+// saveHeartbeatPing records a ping received for a heartbeat monitor
+func (q *sqlStore) saveHeartbeatPing(ping HeartbeatPing) error {
+	insertSQL := q.bind(`
+	INSERT INTO heartbeat_pings (site_name, kind, exit_code, timestamp)
+	VALUES (?, ?, ?, ?)`)
+
+	if _, err := q.db.Exec(insertSQL, ping.SiteName, ping.Kind, ping.ExitCode, ping.Timestamp); err != nil {
+		return fmt.Errorf("failed to save heartbeat ping: %w", err)
+	}
+
+	return nil
+}
+
+// latestHeartbeatPings returns up to limit pings for a site, newest first
+func (q *sqlStore) latestHeartbeatPings(siteName string, limit int) ([]HeartbeatPing, error) {
+	querySQL := q.bind(`
+	SELECT site_name, kind, exit_code, timestamp
+	FROM heartbeat_pings
+	WHERE site_name = ?
+	ORDER BY id DESC
+	LIMIT ?`)
+
+	rows, err := q.db.Query(querySQL, siteName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query heartbeat pings: %w", err)
+	}
+	defer rows.Close()
+
+	var pings []HeartbeatPing
+	for rows.Next() {
+		var ping HeartbeatPing
+		if err := rows.Scan(&ping.SiteName, &ping.Kind, &ping.ExitCode, &ping.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan heartbeat ping: %w", err)
+		}
+		pings = append(pings, ping)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return pings, nil
+}
+
+// encodeJSON serializes an optional slice column as JSON, empty when there is nothing to store
+func encodeJSON[T any](values []T) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// decodeJSON parses a column written by encodeJSON into target
+func decodeJSON(encoded string, target interface{}) error {
+	if encoded == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(encoded), target)
+}
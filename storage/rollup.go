@@ -0,0 +1,634 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"time"
+)
+
+// resolution describes one level of result rollups
+type resolution struct {
+	name      string
+	width     time.Duration // Size of a bucket
+	chunk     time.Duration // Span rolled up per transaction
+	maxWindow time.Duration // Longest stats window read at this resolution, 0 for any
+}
+
+// resolutions lists the rollup levels from finest to coarsest. Minute
+// rollups are built from raw results, each coarser level from the previous one.
+var resolutions = []resolution{
+	{name: "minute", width: time.Minute, chunk: 6 * time.Hour, maxWindow: 7 * 24 * time.Hour},
+	{name: "hour", width: time.Hour, chunk: 7 * 24 * time.Hour, maxWindow: 90 * 24 * time.Hour},
+	{name: "day", width: 24 * time.Hour, chunk: 90 * 24 * time.Hour},
+}
+
+const (
+	// rawStatsWindow is the longest stats window computed from raw results alone
+	rawStatsWindow = 6 * time.Hour
+
+	// minuteRollupRetention is how long minute rollups are kept, covering
+	// every window read at that resolution
+	minuteRollupRetention = 8 * 24 * time.Hour
+
+	// rollupDelay leaves time for the results of a minute to be saved
+	// before the minute is rolled up
+	rollupDelay = time.Minute
+)
+
+// rollupKey identifies the bucket a check is aggregated into
+type rollupKey struct {
+	siteName  string
+	ipVersion string
+	bucket    time.Time
+}
+
+// aggregate accumulates checks into the figures kept by a rollup. Latencies
+// and timings only cover successful checks.
+type aggregate struct {
+	total      int64
+	successes  int64
+	latencySum time.Duration
+	latencyMin time.Duration
+	latencyMax time.Duration
+	timingSums monitor.Timings
+	first      time.Time
+	last       time.Time
+	sketch     latencySketch
+}
+
+// newAggregate creates an empty aggregate
+func newAggregate() *aggregate {
+	return &aggregate{sketch: make(latencySketch)}
+}
+
+// addCheck records one raw result
+func (a *aggregate) addCheck(timestamp time.Time, success bool, latency time.Duration, timings monitor.Timings) {
+	a.total++
+	a.observe(timestamp, timestamp)
+	if !success {
+		return
+	}
+
+	if a.successes == 0 || latency < a.latencyMin {
+		a.latencyMin = latency
+	}
+	if latency > a.latencyMax {
+		a.latencyMax = latency
+	}
+	a.successes++
+	a.latencySum += latency
+	a.timingSums = addTimings(a.timingSums, timings)
+	a.sketch.add(latency)
+}
+
+// merge adds the checks of another aggregate
+func (a *aggregate) merge(other *aggregate) {
+	if other.total == 0 {
+		return
+	}
+
+	if other.successes > 0 {
+		if a.successes == 0 || other.latencyMin < a.latencyMin {
+			a.latencyMin = other.latencyMin
+		}
+		if other.latencyMax > a.latencyMax {
+			a.latencyMax = other.latencyMax
+		}
+	}
+	a.total += other.total
+	a.successes += other.successes
+	a.latencySum += other.latencySum
+	a.timingSums = addTimings(a.timingSums, other.timingSums)
+	a.sketch.merge(other.sketch)
+	a.observe(other.first, other.last)
+}
+
+// observe widens the time span covered by the aggregate
+func (a *aggregate) observe(first, last time.Time) {
+	if a.first.IsZero() || first.Before(a.first) {
+		a.first = first
+	}
+	if last.After(a.last) {
+		a.last = last
+	}
+}
+
+// successRate returns the percentage of successful checks
+func (a *aggregate) successRate() float64 {
+	if a.total == 0 {
+		return 0
+	}
+	return float64(a.successes) / float64(a.total) * 100
+}
+
+// avgLatency returns the average latency of successful checks
+func (a *aggregate) avgLatency() time.Duration {
+	if a.successes == 0 {
+		return 0
+	}
+	return a.latencySum / time.Duration(a.successes)
+}
+
+// addTimings returns the phase-by-phase sum of two timings
+func addTimings(t, other monitor.Timings) monitor.Timings {
+	return monitor.Timings{
+		DNS:      t.DNS + other.DNS,
+		Connect:  t.Connect + other.Connect,
+		TLS:      t.TLS + other.TLS,
+		TTFB:     t.TTFB + other.TTFB,
+		Transfer: t.Transfer + other.Transfer,
+	}
+}
+
+// buildStats turns the aggregates of a site, keyed by address family,
+// into its stats
+func buildStats(siteName string, families map[string]*aggregate) Stats {
+	overall := newAggregate()
+	stats := Stats{SiteName: siteName}
+	for ipVersion, family := range families {
+		overall.merge(family)
+		if ipVersion == "" {
+			continue
+		}
+		if stats.Families == nil {
+			stats.Families = make(map[string]FamilyStats)
+		}
+		stats.Families[ipVersion] = FamilyStats{
+			TotalChecks:      family.total,
+			SuccessfulChecks: family.successes,
+			FailedChecks:     family.total - family.successes,
+			SuccessRate:      family.successRate(),
+			AvgResponseTime:  family.avgLatency(),
+		}
+	}
+
+	stats.TotalChecks = overall.total
+	stats.SuccessfulChecks = overall.successes
+	stats.FailedChecks = overall.total - overall.successes
+	stats.SuccessRate = overall.successRate()
+	stats.AvgResponseTime = overall.avgLatency()
+	stats.MinResponseTime = overall.latencyMin
+	stats.MaxResponseTime = overall.latencyMax
+	stats.P50ResponseTime = overall.sketch.quantile(0.50)
+	stats.P95ResponseTime = overall.sketch.quantile(0.95)
+	stats.P99ResponseTime = overall.sketch.quantile(0.99)
+	stats.FirstCheck = overall.first
+	stats.LastCheck = overall.last
+
+	if overall.successes > 0 {
+		n := time.Duration(overall.successes)
+		stats.AvgTimings = monitor.Timings{
+			DNS:      overall.timingSums.DNS / n,
+			Connect:  overall.timingSums.Connect / n,
+			TLS:      overall.timingSums.TLS / n,
+			TTFB:     overall.timingSums.TTFB / n,
+			Transfer: overall.timingSums.Transfer / n,
+		}
+	}
+
+	// Calculate uptime/downtime (simplified calculation)
+	if !stats.FirstCheck.IsZero() && !stats.LastCheck.IsZero() {
+		totalDuration := stats.LastCheck.Sub(stats.FirstCheck)
+		stats.Uptime = time.Duration(float64(totalDuration) * stats.SuccessRate / 100)
+		stats.Downtime = totalDuration - stats.Uptime
+	}
+
+	return stats
+}
+
+// rawTime converts a bound compared against results.timestamp. SQLite
+// compares the column as text, in the local zone results are saved in.
+func rawTime(t time.Time) time.Time {
+	return t.Local()
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// watermark returns the end of the span rolled up at a resolution, zero
+// when nothing has been rolled up yet
+func (r *sqlStore) watermark(q querier, resolution string) (time.Time, error) {
+	var until time.Time
+	err := q.QueryRow(r.bind("SELECT rolled_until FROM rollup_progress WHERE resolution = ?"), resolution).Scan(&until)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read %s rollup progress: %w", resolution, err)
+	}
+	return until.UTC(), nil
+}
+
+// step rolls up the next chunk of the finest resolution with completed
+// buckets left and reports whether it found one
+func (r *sqlStore) step(now time.Time) (bool, error) {
+	for level, res := range resolutions {
+		end := now.Add(-rollupDelay).UTC().Truncate(res.width)
+		if level > 0 {
+			// Only buckets whose finer rollups are all done
+			finer, err := r.watermark(r.db, resolutions[level-1].name)
+			if err != nil {
+				return false, err
+			}
+			if finer = finer.Truncate(res.width); finer.Before(end) {
+				end = finer
+			}
+		}
+
+		rolled, err := r.watermark(r.db, res.name)
+		if err != nil {
+			return false, err
+		}
+		start := rolled
+		if start.IsZero() {
+			first, found, err := r.firstSource(level)
+			if err != nil {
+				return false, err
+			}
+			if !found {
+				continue
+			}
+			start = first.UTC().Truncate(res.width)
+		}
+		if !start.Before(end) {
+			continue
+		}
+
+		chunkEnd := start.Add(res.chunk)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		return true, r.rollup(level, rolled, start, chunkEnd)
+	}
+	return false, nil
+}
+
+// firstSource returns the time of the oldest data a resolution is built from
+func (r *sqlStore) firstSource(level int) (time.Time, bool, error) {
+	var first time.Time
+	var err error
+	if level == 0 {
+		err = r.db.QueryRow("SELECT timestamp FROM results ORDER BY timestamp LIMIT 1").Scan(&first)
+	} else {
+		err = r.db.QueryRow(r.bind("SELECT bucket FROM result_rollups WHERE resolution = ? ORDER BY bucket LIMIT 1"),
+			resolutions[level-1].name).Scan(&first)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to find data to roll up: %w", err)
+	}
+	return first, true, nil
+}
+
+// rollup aggregates the buckets of [start, end) at a resolution and moves
+// its watermark from rolled to end, in one transaction. Nothing is done if
+// another instance moved the watermark meanwhile.
+func (r *sqlStore) rollup(level int, rolled, start, end time.Time) error {
+	res := resolutions[level]
+	bucket := func(t time.Time) time.Time { return t.UTC().Truncate(res.width) }
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to roll up %s buckets: %w", res.name, err)
+	}
+	defer tx.Rollback()
+
+	if r.lock != "" {
+		if _, err := tx.Exec(r.lock); err != nil {
+			return fmt.Errorf("failed to lock rollups: %w", err)
+		}
+		current, err := r.watermark(tx, res.name)
+		if err != nil {
+			return err
+		}
+		if !current.Equal(rolled) {
+			return nil
+		}
+	}
+
+	var aggregates map[rollupKey]*aggregate
+	if level == 0 {
+		aggregates, err = r.aggregateResults(tx, bucket, "timestamp >= ? AND timestamp < ?", rawTime(start), rawTime(end))
+	} else {
+		aggregates, err = r.aggregateRollups(tx, bucket, "resolution = ? AND bucket >= ? AND bucket < ?",
+			resolutions[level-1].name, start, end)
+	}
+	if err != nil {
+		return err
+	}
+
+	insertSQL := r.bind(`
+	INSERT INTO result_rollups (resolution, site_name, ip_version, bucket, total, successes,
+		latency_sum_ns, latency_min_ns, latency_max_ns,
+		dns_sum_ns, connect_sum_ns, tls_sum_ns, ttfb_sum_ns, transfer_sum_ns, first_check, last_check, sketch)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	for key, agg := range aggregates {
+		sketch, err := encodeSketch(agg.sketch)
+		if err != nil {
+			return fmt.Errorf("failed to encode latency sketch: %w", err)
+		}
+		if _, err := tx.Exec(insertSQL, res.name, key.siteName, key.ipVersion, key.bucket, agg.total, agg.successes,
+			agg.latencySum.Nanoseconds(), agg.latencyMin.Nanoseconds(), agg.latencyMax.Nanoseconds(),
+			agg.timingSums.DNS.Nanoseconds(), agg.timingSums.Connect.Nanoseconds(), agg.timingSums.TLS.Nanoseconds(),
+			agg.timingSums.TTFB.Nanoseconds(), agg.timingSums.Transfer.Nanoseconds(),
+			agg.first, agg.last, sketch); err != nil {
+			return fmt.Errorf("failed to save %s rollup: %w", res.name, err)
+		}
+	}
+
+	if _, err := tx.Exec(r.bind("DELETE FROM rollup_progress WHERE resolution = ?"), res.name); err != nil {
+		return fmt.Errorf("failed to save %s rollup progress: %w", res.name, err)
+	}
+	if _, err := tx.Exec(r.bind("INSERT INTO rollup_progress (resolution, rolled_until) VALUES (?, ?)"), res.name, end); err != nil {
+		return fmt.Errorf("failed to save %s rollup progress: %w", res.name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to roll up %s buckets: %w", res.name, err)
+	}
+	return nil
+}
+
+// aggregateResults aggregates the raw results matching a condition. With a
+// nil bucket function, the checks of each site and family are aggregated
+// together.
+func (r *sqlStore) aggregateResults(q querier, bucket func(time.Time) time.Time, where string, args ...interface{}) (map[rollupKey]*aggregate, error) {
+	querySQL := r.bind(`
+	SELECT site_name, COALESCE(ip_version, ''), timestamp, success, response_time_ns,
+		COALESCE(dns_ns, 0), COALESCE(connect_ns, 0), COALESCE(tls_ns, 0), COALESCE(ttfb_ns, 0), COALESCE(transfer_ns, 0)
+	FROM results
+	WHERE ` + where)
+
+	rows, err := q.Query(querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query results: %w", err)
+	}
+	defer rows.Close()
+
+	aggregates := make(map[rollupKey]*aggregate)
+	for rows.Next() {
+		var key rollupKey
+		var timestamp time.Time
+		var success bool
+		var responseTimeNs, dnsNs, connectNs, tlsNs, ttfbNs, transferNs int64
+		if err := rows.Scan(&key.siteName, &key.ipVersion, &timestamp, &success, &responseTimeNs,
+			&dnsNs, &connectNs, &tlsNs, &ttfbNs, &transferNs); err != nil {
+			return nil, fmt.Errorf("failed to scan result: %w", err)
+		}
+		if bucket != nil {
+			key.bucket = bucket(timestamp)
+		}
+
+		agg, ok := aggregates[key]
+		if !ok {
+			agg = newAggregate()
+			aggregates[key] = agg
+		}
+		agg.addCheck(timestamp, success, time.Duration(responseTimeNs), monitor.Timings{
+			DNS:      time.Duration(dnsNs),
+			Connect:  time.Duration(connectNs),
+			TLS:      time.Duration(tlsNs),
+			TTFB:     time.Duration(ttfbNs),
+			Transfer: time.Duration(transferNs),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return aggregates, nil
+}
+
+// aggregateRollups merges the rollups matching a condition into coarser
+// buckets, or per site and family with a nil bucket function
+func (r *sqlStore) aggregateRollups(q querier, bucket func(time.Time) time.Time, where string, args ...interface{}) (map[rollupKey]*aggregate, error) {
+	querySQL := r.bind(`
+	SELECT site_name, ip_version, bucket, total, successes, latency_sum_ns, latency_min_ns, latency_max_ns,
+		dns_sum_ns, connect_sum_ns, tls_sum_ns, ttfb_sum_ns, transfer_sum_ns, first_check, last_check, sketch
+	FROM result_rollups
+	WHERE ` + where)
+
+	rows, err := q.Query(querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rollups: %w", err)
+	}
+	defer rows.Close()
+
+	aggregates := make(map[rollupKey]*aggregate)
+	for rows.Next() {
+		var key rollupKey
+		var rollup aggregate
+		var sumNs, minNs, maxNs, dnsNs, connectNs, tlsNs, ttfbNs, transferNs int64
+		var sketch string
+		if err := rows.Scan(&key.siteName, &key.ipVersion, &key.bucket, &rollup.total, &rollup.successes,
+			&sumNs, &minNs, &maxNs, &dnsNs, &connectNs, &tlsNs, &ttfbNs, &transferNs,
+			&rollup.first, &rollup.last, &sketch); err != nil {
+			return nil, fmt.Errorf("failed to scan rollup: %w", err)
+		}
+		if rollup.sketch, err = decodeSketch(sketch); err != nil {
+			return nil, fmt.Errorf("failed to decode latency sketch: %w", err)
+		}
+		rollup.latencySum = time.Duration(sumNs)
+		rollup.latencyMin = time.Duration(minNs)
+		rollup.latencyMax = time.Duration(maxNs)
+		rollup.timingSums = monitor.Timings{
+			DNS:      time.Duration(dnsNs),
+			Connect:  time.Duration(connectNs),
+			TLS:      time.Duration(tlsNs),
+			TTFB:     time.Duration(ttfbNs),
+			Transfer: time.Duration(transferNs),
+		}
+
+		key.bucket = key.bucket.UTC()
+		if bucket != nil {
+			key.bucket = bucket(key.bucket)
+		} else {
+			key.bucket = time.Time{}
+		}
+
+		agg, ok := aggregates[key]
+		if !ok {
+			agg = newAggregate()
+			aggregates[key] = agg
+		}
+		agg.merge(&rollup)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return aggregates, nil
+}
+
+// stats calculates the statistics of a site since a time. Long windows are
+// read from the coarsest suitable rollups, rounded down to their buckets,
+// then from finer rollups and raw results for the span not rolled up yet.
+func (r *sqlStore) stats(siteName string, since, now time.Time) (Stats, error) {
+	level := -1
+	if window := now.Sub(since); window > rawStatsWindow {
+		level = 0
+		for resolutions[level].maxWindow > 0 && window > resolutions[level].maxWindow {
+			level++
+		}
+	}
+
+	families := make(map[string]*aggregate)
+	addFamilies := func(aggregates map[rollupKey]*aggregate) {
+		for key, agg := range aggregates {
+			family, ok := families[key.ipVersion]
+			if !ok {
+				family = newAggregate()
+				families[key.ipVersion] = family
+			}
+			family.merge(agg)
+		}
+	}
+
+	from := since
+	for ; level >= 0; level-- {
+		res := resolutions[level]
+		until, err := r.watermark(r.db, res.name)
+		if err != nil {
+			return Stats{}, err
+		}
+		if !until.After(from) {
+			continue
+		}
+
+		aggregates, err := r.aggregateRollups(r.db, nil, "resolution = ? AND site_name = ? AND bucket >= ? AND bucket < ?",
+			res.name, siteName, from.UTC().Truncate(res.width), until)
+		if err != nil {
+			return Stats{}, fmt.Errorf("failed to get stats: %w", err)
+		}
+		addFamilies(aggregates)
+		from = until
+	}
+
+	aggregates, err := r.aggregateResults(r.db, nil, "site_name = ? AND timestamp >= ?", siteName, rawTime(from))
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	addFamilies(aggregates)
+
+	return buildStats(siteName, families), nil
+}
+
+// allStats calculates the statistics of every site with data since a time
+func (r *sqlStore) allStats(since, now time.Time) (map[string]Stats, error) {
+	siteNames, err := r.siteNames(since)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get stats for each site
+	allStats := make(map[string]Stats)
+	for _, siteName := range siteNames {
+		stats, err := r.stats(siteName, since, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stats for %s: %w", siteName, err)
+		}
+		allStats[siteName] = stats
+	}
+
+	return allStats, nil
+}
+
+// siteNames returns the sites with results or rollups since a time
+func (r *sqlStore) siteNames(since time.Time) ([]string, error) {
+	sitesSQL := r.bind(`
+	SELECT site_name FROM results WHERE timestamp >= ?
+	UNION
+	SELECT site_name FROM result_rollups WHERE bucket >= ?`)
+
+	rows, err := r.db.Query(sitesSQL, rawTime(since), since.UTC().Truncate(24*time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get site names: %w", err)
+	}
+	defer rows.Close()
+
+	var siteNames []string
+	for rows.Next() {
+		var siteName string
+		if err := rows.Scan(&siteName); err != nil {
+			return nil, fmt.Errorf("failed to scan site name: %w", err)
+		}
+		siteNames = append(siteNames, siteName)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return siteNames, nil
+}
+
+// applyRetention deletes raw results older than raw_data_days, minute
+// rollups older than minuteRollupRetention and coarser rollups older than
+// aggregated_data_days. Nothing is deleted before it has been rolled up into
+// the next resolution. It returns how many results and rollups were removed.
+func (r *sqlStore) applyRetention(retention config.RetentionConfig, now time.Time) (int64, int64, error) {
+	var results, rollups int64
+
+	if retention.RawDataDays > 0 {
+		before, err := r.rolledBefore(now.AddDate(0, 0, -retention.RawDataDays), resolutions[0].name)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !before.IsZero() {
+			res, err := r.db.Exec(r.bind("DELETE FROM results WHERE timestamp < ?"), rawTime(before))
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to purge results: %w", err)
+			}
+			results, _ = res.RowsAffected()
+		}
+	}
+
+	for level, res := range resolutions {
+		before := now.Add(-minuteRollupRetention)
+		if level > 0 {
+			if retention.AggregatedDataDays <= 0 {
+				continue
+			}
+			before = now.AddDate(0, 0, -retention.AggregatedDataDays)
+		}
+		if level+1 < len(resolutions) {
+			var err error
+			if before, err = r.rolledBefore(before, resolutions[level+1].name); err != nil {
+				return results, rollups, err
+			}
+		}
+		if before.IsZero() {
+			continue
+		}
+
+		deleted, err := r.db.Exec(r.bind("DELETE FROM result_rollups WHERE resolution = ? AND bucket < ?"), res.name, before.UTC())
+		if err != nil {
+			return results, rollups, fmt.Errorf("failed to purge %s rollups: %w", res.name, err)
+		}
+		n, _ := deleted.RowsAffected()
+		rollups += n
+	}
+
+	return results, rollups, nil
+}
+
+// rolledBefore caps a retention cutoff at the watermark of a resolution, so
+// only data already rolled up into it is deleted. It is zero when nothing
+// has been rolled up.
+func (r *sqlStore) rolledBefore(before time.Time, resolution string) (time.Time, error) {
+	until, err := r.watermark(r.db, resolution)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if until.Before(before) {
+		return until, nil
+	}
+	return before, nil
+}
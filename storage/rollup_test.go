@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"math"
+	"site-monitor/config"
+	"site-monitor/monitor"
+	"testing"
+	"time"
+)
+
+// seedResults saves an hour of results three days ago and one a minute ago,
+// returning the stats they should produce
+func seedResults(t *testing.T, s Storage, now time.Time) Stats {
+	t.Helper()
+
+	base := now.Add(-72 * time.Hour)
+	expected := Stats{SiteName: "api", FirstCheck: base, LastCheck: now.Add(-time.Minute)}
+	var latencySum time.Duration
+	save := func(timestamp time.Time, success bool, latency time.Duration) {
+		result := monitor.Result{Name: "api", URL: "https://api.example.com", Success: success,
+			Duration: latency, Timestamp: timestamp, IPVersion: "v4"}
+		if err := s.SaveResult(result); err != nil {
+			t.Fatalf("SaveResult failed: %v", err)
+		}
+		expected.TotalChecks++
+		if !success {
+			return
+		}
+		if expected.SuccessfulChecks == 0 || latency < expected.MinResponseTime {
+			expected.MinResponseTime = latency
+		}
+		if latency > expected.MaxResponseTime {
+			expected.MaxResponseTime = latency
+		}
+		expected.SuccessfulChecks++
+		latencySum += latency
+	}
+
+	for i := 0; i < 60; i++ {
+		save(base.Add(time.Duration(i)*time.Minute), i%4 != 0, time.Duration(i+1)*time.Millisecond)
+	}
+	save(now.Add(-time.Minute), true, 500*time.Millisecond)
+
+	expected.AvgResponseTime = latencySum / time.Duration(expected.SuccessfulChecks)
+	return expected
+}
+
+// assertStats compares the figures rollups are expected to preserve
+func assertStats(t *testing.T, got, expected Stats) {
+	t.Helper()
+	if got.TotalChecks != expected.TotalChecks || got.SuccessfulChecks != expected.SuccessfulChecks {
+		t.Errorf("Expected %d/%d checks, got %d/%d",
+			expected.SuccessfulChecks, expected.TotalChecks, got.SuccessfulChecks, got.TotalChecks)
+	}
+	if got.AvgResponseTime != expected.AvgResponseTime || got.MinResponseTime != expected.MinResponseTime ||
+		got.MaxResponseTime != expected.MaxResponseTime {
+		t.Errorf("Expected avg %v min %v max %v, got avg %v min %v max %v",
+			expected.AvgResponseTime, expected.MinResponseTime, expected.MaxResponseTime,
+			got.AvgResponseTime, got.MinResponseTime, got.MaxResponseTime)
+	}
+	if !got.FirstCheck.Equal(expected.FirstCheck) || !got.LastCheck.Equal(expected.LastCheck) {
+		t.Errorf("Expected checks from %v to %v, got %v to %v",
+			expected.FirstCheck, expected.LastCheck, got.FirstCheck, got.LastCheck)
+	}
+	if got.Families["v4"].TotalChecks != expected.TotalChecks {
+		t.Errorf("Expected the checks under v4, got %+v", got.Families)
+	}
+}
+
+func TestSQLiteStorage_Rollup(t *testing.T) {
+	s := newTestSQLite(t)
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	now := time.Now()
+	expected := seedResults(t, s, now)
+
+	if err := s.Rollup(now); err != nil {
+		t.Fatalf("Rollup failed: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, res := range resolutions {
+		var count int
+		s.db.QueryRow("SELECT COUNT(*) FROM result_rollups WHERE resolution = ?", res.name).Scan(&count)
+		counts[res.name] = count
+	}
+	if counts["minute"] != 60 || counts["hour"] < 1 || counts["day"] < 1 {
+		t.Errorf("Expected minute, hour and day rollups, got %v", counts)
+	}
+
+	// Rolling up again has nothing left to do
+	if err := s.Rollup(now); err != nil {
+		t.Fatalf("Rollup failed: %v", err)
+	}
+	var total int
+	s.db.QueryRow("SELECT COUNT(*) FROM result_rollups").Scan(&total)
+	if total != counts["minute"]+counts["hour"]+counts["day"] {
+		t.Errorf("Expected a second rollup to add nothing, got %d rows", total)
+	}
+
+	for _, window := range []time.Duration{4 * 24 * time.Hour, 30 * 24 * time.Hour, 365 * 24 * time.Hour} {
+		stats, err := s.GetStats("api", now.Add(-window))
+		if err != nil {
+			t.Fatalf("GetStats failed: %v", err)
+		}
+		assertStats(t, stats, expected)
+	}
+
+	// The recent result is read raw until its minute is rolled up
+	stats, err := s.GetStats("api", now.Add(-time.Hour))
+	if err != nil || stats.TotalChecks != 1 {
+		t.Errorf("Expected the recent result only, got %d (%v)", stats.TotalChecks, err)
+	}
+}
+
+func TestSQLiteStorage_ApplyRetention(t *testing.T) {
+	s := newTestSQLite(t)
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	now := time.Now()
+	expected := seedResults(t, s, now)
+	retention := config.RetentionConfig{RawDataDays: 1}
+
+	// Results are kept until they are rolled up
+	if removed, _, err := s.ApplyRetention(retention, now); err != nil || removed != 0 {
+		t.Fatalf("Expected nothing removed before the rollup, got %d (%v)", removed, err)
+	}
+
+	if err := s.Rollup(now); err != nil {
+		t.Fatalf("Rollup failed: %v", err)
+	}
+	removed, _, err := s.ApplyRetention(retention, now)
+	if err != nil || removed != 60 {
+		t.Fatalf("Expected 60 expired results removed, got %d (%v)", removed, err)
+	}
+
+	history, _ := s.GetHistory("api", time.Time{})
+	if len(history) != 1 {
+		t.Errorf("Expected the recent result to be kept, got %d", len(history))
+	}
+
+	// Long windows are still served from the rollups
+	stats, err := s.GetStats("api", now.Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	assertStats(t, stats, expected)
+
+	allStats, err := s.GetAllStats(now.Add(-30 * 24 * time.Hour))
+	if err != nil || allStats["api"].TotalChecks != expected.TotalChecks {
+		t.Errorf("Expected the site in all stats, got %+v (%v)", allStats, err)
+	}
+
+	// Expired aggregates go too
+	retention.AggregatedDataDays = 1
+	if _, rollups, err := s.ApplyRetention(retention, now); err != nil || rollups == 0 {
+		t.Fatalf("Expected expired rollups removed, got %d (%v)", rollups, err)
+	}
+	stats, _ = s.GetStats("api", now.Add(-30*24*time.Hour))
+	if stats.TotalChecks != 1 {
+		t.Errorf("Expected only the recent result left, got %d", stats.TotalChecks)
+	}
+}
+
+func TestLatencySketch_Quantile(t *testing.T) {
+	sketch := make(latencySketch)
+	for i := 1; i <= 1000; i++ {
+		sketch.add(time.Duration(i) * time.Millisecond)
+	}
+
+	// Sketches survive a round trip and merge
+	encoded, err := encodeSketch(sketch)
+	if err != nil {
+		t.Fatalf("encodeSketch failed: %v", err)
+	}
+	decoded, err := decodeSketch(encoded)
+	if err != nil {
+		t.Fatalf("decodeSketch failed: %v", err)
+	}
+	merged := make(latencySketch)
+	merged.merge(decoded)
+
+	for q, expected := range map[float64]time.Duration{
+		0.50: 500 * time.Millisecond,
+		0.95: 950 * time.Millisecond,
+		0.99: 990 * time.Millisecond,
+	} {
+		got := merged.quantile(q)
+		if math.Abs(float64(got-expected))/float64(expected) > 0.02 {
+			t.Errorf("Expected p%.0f near %v, got %v", q*100, expected, got)
+		}
+	}
+
+	if got := make(latencySketch).quantile(0.5); got != 0 {
+		t.Errorf("Expected 0 for an empty sketch, got %v", got)
+	}
+}
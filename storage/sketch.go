@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+)
+
+// sketchGamma is the growth factor between consecutive sketch buckets, which
+// keeps estimated percentiles within 2% of the real latency
+const sketchGamma = 1.04
+
+var sketchLogGamma = math.Log(sketchGamma)
+
+// latencySketch is a mergeable histogram of latencies over logarithmic
+// buckets, so percentiles can be estimated from rolled-up data. Bucket 0
+// holds latencies up to a microsecond; bucket i holds (γ^(i-1), γ^i] µs.
+type latencySketch map[int]int64
+
+// add records a latency
+func (s latencySketch) add(latency time.Duration) {
+	micros := float64(latency) / float64(time.Microsecond)
+	index := 0
+	if micros > 1 {
+		index = int(math.Ceil(math.Log(micros) / sketchLogGamma))
+	}
+	s[index]++
+}
+
+// merge adds the counts of another sketch
+func (s latencySketch) merge(other latencySketch) {
+	for index, count := range other {
+		s[index] += count
+	}
+}
+
+// quantile estimates the latency below which a fraction q of the recorded
+// latencies fall, 0 when the sketch is empty
+func (s latencySketch) quantile(q float64) time.Duration {
+	var total int64
+	indexes := make([]int, 0, len(s))
+	for index, count := range s {
+		indexes = append(indexes, index)
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Ints(indexes)
+
+	rank := int64(math.Ceil(q * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for _, index := range indexes {
+		seen += s[index]
+		if seen >= rank {
+			return sketchValue(index)
+		}
+	}
+	return sketchValue(indexes[len(indexes)-1])
+}
+
+// sketchValue returns the latency representing a bucket, the point with the
+// same relative error to both of its bounds
+func sketchValue(index int) time.Duration {
+	if index <= 0 {
+		return time.Microsecond
+	}
+	micros := 2 * math.Pow(sketchGamma, float64(index)) / (sketchGamma + 1)
+	return time.Duration(micros * float64(time.Microsecond))
+}
+
+// encodeSketch serializes a sketch for a rollup row
+func encodeSketch(s latencySketch) (string, error) {
+	if len(s) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// decodeSketch parses a sketch written by encodeSketch
+func decodeSketch(encoded string) (latencySketch, error) {
+	s := make(latencySketch)
+	if encoded == "" {
+		return s, nil
+	}
+	if err := json.Unmarshal([]byte(encoded), &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
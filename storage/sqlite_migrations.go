@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqliteMigrations is the SQLite schema history. Append new migrations with
+// the next version; never edit one that has been released.
+var sqliteMigrations = []Migration{
+	{Version: 1, Description: "Create results and heartbeat_pings tables", Up: sqliteBaseline},
+	{Version: 2, Description: "Create result_rollups and rollup_progress tables", Up: sqliteRollups},
+}
+
+// sqliteBaseline creates the schema as it was when migrations were
+// introduced. Databases created before that already have the tables, with
+// the columns added since then possibly missing, so those are added here.
+func sqliteBaseline(tx *sql.Tx) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		site_name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		status_code INTEGER DEFAULT 0,
+		response_time_ns INTEGER NOT NULL,
+		success BOOLEAN NOT NULL,
+		error_message TEXT DEFAULT '',
+		timestamp DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		assertions TEXT DEFAULT '',
+		dns_ns INTEGER DEFAULT 0,
+		connect_ns INTEGER DEFAULT 0,
+		tls_ns INTEGER DEFAULT 0,
+		ttfb_ns INTEGER DEFAULT 0,
+		transfer_ns INTEGER DEFAULT 0,
+		severity TEXT DEFAULT '',
+		metrics TEXT DEFAULT '',
+		steps TEXT DEFAULT '',
+		attempts INTEGER DEFAULT 0,
+		ip_version TEXT DEFAULT ''
+	);`
+
+	if _, err := tx.Exec(createTableSQL); err != nil {
+		return fmt.Errorf("failed to create results table: %w", err)
+	}
+
+	legacyColumns := []struct {
+		name       string
+		definition string
+	}{
+		{"assertions", "TEXT DEFAULT ''"},
+		{"dns_ns", "INTEGER DEFAULT 0"},
+		{"connect_ns", "INTEGER DEFAULT 0"},
+		{"tls_ns", "INTEGER DEFAULT 0"},
+		{"ttfb_ns", "INTEGER DEFAULT 0"},
+		{"transfer_ns", "INTEGER DEFAULT 0"},
+		{"severity", "TEXT DEFAULT ''"},
+		{"metrics", "TEXT DEFAULT ''"},
+		{"steps", "TEXT DEFAULT ''"},
+		{"attempts", "INTEGER DEFAULT 0"},
+		{"ip_version", "TEXT DEFAULT ''"},
+	}
+	for _, column := range legacyColumns {
+		exists, err := sqliteColumnExists(tx, "results", column.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE results ADD COLUMN %s %s", column.name, column.definition)); err != nil {
+			return fmt.Errorf("failed to add column results.%s: %w", column.name, err)
+		}
+	}
+
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_site_timestamp ON results(site_name, timestamp DESC);",
+		"CREATE INDEX IF NOT EXISTS idx_timestamp ON results(timestamp DESC);",
+		"CREATE INDEX IF NOT EXISTS idx_site_success ON results(site_name, success);",
+		"CREATE INDEX IF NOT EXISTS idx_success_timestamp ON results(success, timestamp DESC);",
+	}
+	for _, indexSQL := range indexes {
+		if _, err := tx.Exec(indexSQL); err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	createHeartbeatsSQL := `
+	CREATE TABLE IF NOT EXISTS heartbeat_pings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		site_name TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		exit_code INTEGER DEFAULT 0,
+		timestamp DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_heartbeat_site_id ON heartbeat_pings(site_name, id DESC);`
+
+	if _, err := tx.Exec(createHeartbeatsSQL); err != nil {
+		return fmt.Errorf("failed to create heartbeat_pings table: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteRollups creates the tables holding per-minute, hourly and daily
+// aggregates of the results
+func sqliteRollups(tx *sql.Tx) error {
+	createRollupsSQL := `
+	CREATE TABLE result_rollups (
+		resolution TEXT NOT NULL,
+		site_name TEXT NOT NULL,
+		bucket DATETIME NOT NULL,
+		ip_version TEXT NOT NULL DEFAULT '',
+		total INTEGER NOT NULL,
+		successes INTEGER NOT NULL,
+		latency_sum_ns INTEGER NOT NULL DEFAULT 0,
+		latency_min_ns INTEGER NOT NULL DEFAULT 0,
+		latency_max_ns INTEGER NOT NULL DEFAULT 0,
+		dns_sum_ns INTEGER NOT NULL DEFAULT 0,
+		connect_sum_ns INTEGER NOT NULL DEFAULT 0,
+		tls_sum_ns INTEGER NOT NULL DEFAULT 0,
+		ttfb_sum_ns INTEGER NOT NULL DEFAULT 0,
+		transfer_sum_ns INTEGER NOT NULL DEFAULT 0,
+		first_check DATETIME NOT NULL,
+		last_check DATETIME NOT NULL,
+		sketch TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (resolution, site_name, bucket, ip_version)
+	);
+	CREATE INDEX idx_rollups_bucket ON result_rollups(resolution, bucket);`
+
+	if _, err := tx.Exec(createRollupsSQL); err != nil {
+		return fmt.Errorf("failed to create result_rollups table: %w", err)
+	}
+
+	createProgressSQL := `
+	CREATE TABLE rollup_progress (
+		resolution TEXT PRIMARY KEY,
+		rolled_until DATETIME NOT NULL
+	);`
+
+	if _, err := tx.Exec(createProgressSQL); err != nil {
+		return fmt.Errorf("failed to create rollup_progress table: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteColumnExists reports whether a table has a column
+func sqliteColumnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	return count > 0, nil
+}
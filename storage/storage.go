@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"site-monitor/config"
 	"site-monitor/monitor"
 	"time"
 )
@@ -30,6 +32,43 @@ type Storage interface {
 	Init() error
 }
 
+// Open creates the storage selected by storage.driver, without touching its
+// schema; call Init before use
+func Open(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.GetDriver() {
+	case config.StorageSQLite:
+		db, err := NewSQLiteStorageFromConfig(cfg.SQLite)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case config.StoragePostgres:
+		db, err := NewPostgresStorageFromConfig(cfg.Postgres)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// Migrator is implemented by storages with a versioned schema
+type Migrator interface {
+	// Migrate applies the pending migrations and returns them
+	Migrate() ([]MigrationState, error)
+
+	// SchemaStatus reports the schema version and the applied and pending migrations
+	SchemaStatus() (SchemaStatus, error)
+}
+
+// Maintainer is implemented by storages that roll up and expire their data
+// in the background
+type Maintainer interface {
+	// Maintain runs the rollup, retention and compaction jobs until ctx is cancelled
+	Maintain(ctx context.Context, cfg config.StorageConfig)
+}
+
 // HistoryEntry represents a stored monitoring result with metadata
 type HistoryEntry struct {
 	ID        int64         `json:"id"`
@@ -41,6 +80,17 @@ type HistoryEntry struct {
 	Error     string        `json:"error_message,omitempty"`
 	Timestamp time.Time     `json:"timestamp"`
 	CreatedAt time.Time     `json:"created_at"`
+	Attempts  int           `json:"attempts,omitempty"`
+
+	Severity   monitor.Severity          `json:"severity,omitempty"`
+	Timings    monitor.Timings           `json:"timings"`
+	Metrics    []monitor.Metric          `json:"metrics,omitempty"`
+	Assertions []monitor.AssertionResult `json:"assertions,omitempty"`
+	Steps      []monitor.StepResult      `json:"steps,omitempty"`
+
+	// IPVersion is the address family of the probe (v4 or v6), empty when
+	// the site is not pinned to one
+	IPVersion string `json:"ip_version,omitempty"`
 }
 
 // Stats represents calculated statistics for a site
@@ -57,6 +107,27 @@ type Stats struct {
 	FirstCheck       time.Time     `json:"first_check"`
 	Uptime           time.Duration `json:"uptime_duration"`
 	Downtime         time.Duration `json:"downtime_duration"`
+
+	// Response time percentiles of successful checks, estimated within 2%
+	P50ResponseTime time.Duration `json:"p50_response_time_ms"`
+	P95ResponseTime time.Duration `json:"p95_response_time_ms"`
+	P99ResponseTime time.Duration `json:"p99_response_time_ms"`
+
+	// AvgTimings is the average phase breakdown of successful checks
+	AvgTimings monitor.Timings `json:"avg_timings"`
+
+	// Families breaks the checks down per address family (v4, v6) for sites
+	// with an ip_version; empty otherwise
+	Families map[string]FamilyStats `json:"families,omitempty"`
+}
+
+// FamilyStats represents the statistics of a site's probes over one address family
+type FamilyStats struct {
+	TotalChecks      int64         `json:"total_checks"`
+	SuccessfulChecks int64         `json:"successful_checks"`
+	FailedChecks     int64         `json:"failed_checks"`
+	SuccessRate      float64       `json:"success_rate_percent"`
+	AvgResponseTime  time.Duration `json:"avg_response_time_ms"`
 }
 
 // String returns a formatted representation of the stats
@@ -70,3 +141,28 @@ func (s Stats) String() string {
 		s.LastCheck.Format("15:04:05"),
 	)
 }
+
+// Heartbeat ping kinds
+const (
+	HeartbeatSuccess = "success" // Job completed successfully
+	HeartbeatStart   = "start"   // Job started
+	HeartbeatFail    = "fail"    // Job failed (or exited with a non-zero code)
+)
+
+// HeartbeatStorage is implemented by storages that can record heartbeat
+// pings, so push monitors work across the dashboard and monitor processes
+type HeartbeatStorage interface {
+	// SaveHeartbeatPing records a ping received for a heartbeat monitor
+	SaveHeartbeatPing(ping HeartbeatPing) error
+
+	// GetLatestHeartbeatPings returns up to limit pings for a site, newest first
+	GetLatestHeartbeatPings(siteName string, limit int) ([]HeartbeatPing, error)
+}
+
+// HeartbeatPing represents a ping received from a pushing job
+type HeartbeatPing struct {
+	SiteName  string    `json:"site_name"`
+	Kind      string    `json:"kind"`                // success, start or fail
+	ExitCode  int       `json:"exit_code,omitempty"` // Reported exit code, if any
+	Timestamp time.Time `json:"timestamp"`
+}
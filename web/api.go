@@ -0,0 +1,119 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"site-monitor/config"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiMiddleware applies the api section of the configuration (CORS and
+// per-client rate limiting) to the API handler
+func apiMiddleware(cfg *config.APIConfig, next http.Handler) http.Handler {
+	if cfg == nil || !cfg.Enabled {
+		return next
+	}
+
+	handler := next
+	if cfg.RateLimiting.Enabled {
+		handler = newRateLimiter(cfg.RateLimiting.GetRequestsPerMinute()).middleware(handler)
+	}
+	if cfg.CORS.Enabled {
+		handler = corsMiddleware(cfg.CORS.Origins, handler)
+	}
+	return handler
+}
+
+// corsMiddleware allows the configured origins ("*" for any) to call the API
+// and answers preflight requests
+func corsMiddleware(origins []string, next http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if !allowed[origin] && !allowed["*"] {
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimiter allows each client IP a number of requests per minute
+type rateLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Time      // Start of the current minute
+	requests map[string]int // Requests per client in the current minute
+	now      func() time.Time
+}
+
+// newRateLimiter creates a limiter allowing requestsPerMinute per client
+func newRateLimiter(requestsPerMinute int) *rateLimiter {
+	return &rateLimiter{
+		limit:    requestsPerMinute,
+		requests: make(map[string]int),
+		now:      time.Now,
+	}
+}
+
+// allow records a request from client and reports whether it is within the
+// limit, along with the time until the limit resets
+func (rl *rateLimiter) allow(client string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	if now.Sub(rl.window) >= time.Minute {
+		rl.window = now.Truncate(time.Minute)
+		rl.requests = make(map[string]int)
+	}
+
+	rl.requests[client]++
+	return rl.requests[client] <= rl.limit, rl.window.Add(time.Minute).Sub(now)
+}
+
+// middleware rejects requests over the limit with 429 Too Many Requests
+func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+
+		allowed, reset := rl.allow(client)
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(reset.Seconds())+1))
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"site-monitor/config"
+	"testing"
+	"time"
+)
+
+func TestAPIMiddleware(t *testing.T) {
+	cfg := &config.APIConfig{
+		Enabled:      true,
+		CORS:         config.CORSConfig{Enabled: true, Origins: []string{"https://status.example.com"}},
+		RateLimiting: config.RateLimitConfig{Enabled: true, RequestsPerMinute: 2},
+	}
+	handler := apiMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(method, origin, remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/overview", nil)
+		r.RemoteAddr = remoteAddr
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	preflight := request(http.MethodOptions, "https://status.example.com", "10.0.0.1:1000")
+	if preflight.Code != http.StatusNoContent || preflight.Header().Get("Access-Control-Allow-Origin") != "https://status.example.com" {
+		t.Errorf("Expected an allowed preflight, got %d %v", preflight.Code, preflight.Header())
+	}
+	if w := request(http.MethodGet, "https://evil.example.com", "10.0.0.2:1000"); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected no CORS headers for an unknown origin")
+	}
+
+	// Preflights are answered before the limit of 2 per minute applies
+	for i := 0; i < 2; i++ {
+		if w := request(http.MethodGet, "", "10.0.0.1:1001"); w.Code != http.StatusOK {
+			t.Errorf("Expected request %d to pass, got %d", i+1, w.Code)
+		}
+	}
+	w := request(http.MethodGet, "", "10.0.0.1:1002")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected the third request to be limited, got %d", w.Code)
+	}
+	if w := request(http.MethodGet, "", "10.0.0.3:1000"); w.Code != http.StatusOK {
+		t.Errorf("Expected other clients to be unaffected, got %d", w.Code)
+	}
+}
+
+func TestRateLimiter_ResetsEachMinute(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	limiter := newRateLimiter(1)
+	limiter.now = func() time.Time { return now }
+
+	if ok, _ := limiter.allow("a"); !ok {
+		t.Fatal("Expected the first request to be allowed")
+	}
+	if ok, reset := limiter.allow("a"); ok || reset != 30*time.Second {
+		t.Fatalf("Expected the second request to wait 30s, got %v %v", ok, reset)
+	}
+
+	now = now.Add(30 * time.Second)
+	if ok, _ := limiter.allow("a"); !ok {
+		t.Error("Expected the limit to reset in the next minute")
+	}
+}
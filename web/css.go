@@ -303,6 +303,36 @@ body {
     margin-top: 0.25rem;
 }
 
+.site-families {
+    display: flex;
+    gap: 0.5rem;
+    margin-top: 1rem;
+}
+
+.site-family {
+    flex: 1;
+    padding: 0.25rem 0.5rem;
+    border-radius: var(--radius-sm);
+    font-size: 0.75rem;
+    font-weight: 600;
+    text-align: center;
+}
+
+.site-family.healthy {
+    background: #dcfce7;
+    color: var(--success-color);
+}
+
+.site-family.degraded {
+    background: #fef3c7;
+    color: var(--warning-color);
+}
+
+.site-family.down {
+    background: #fecaca;
+    color: var(--error-color);
+}
+
 .charts-section {
     margin-bottom: 3rem;
 }
@@ -397,6 +427,20 @@ body {
     margin-top: 0.25rem;
 }
 
+.activity-steps {
+    list-style: none;
+    margin-top: 0.25rem;
+    font-size: 0.8125rem;
+}
+
+.activity-step.success {
+    color: var(--text-secondary);
+}
+
+.activity-step.error {
+    color: var(--error-color);
+}
+
 .activity-time {
     color: var(--text-secondary);
     font-size: 0.75rem;
@@ -85,6 +85,11 @@ const dashboardHTML = `<!DOCTYPE html>
                         <h3 class="chart-title">Uptime Distribution</h3>
                         <canvas id="uptime-chart" width="400" height="200"></canvas>
                     </div>
+
+                    <div class="chart-container">
+                        <h3 class="chart-title">Request Phases (Avg, Last 24h)</h3>
+                        <canvas id="timing-chart" width="400" height="200"></canvas>
+                    </div>
                 </div>
             </section>
 
@@ -0,0 +1,128 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"site-monitor/config"
+	"site-monitor/metrics"
+	"site-monitor/ssl"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sslCache holds the latest certificate checks so /api/ssl only reconnects
+// to the sites once per ssl.check_interval
+type sslCache struct {
+	mu        sync.Mutex
+	checker   *ssl.SSLChecker
+	interval  time.Duration
+	checks    []ssl.SSLCheck
+	checkedAt time.Time
+}
+
+// newSSLCache creates a certificate cache from the ssl section, or returns
+// nil when ssl.enabled is false
+func newSSLCache(cfg *config.SSLConfig) (*sslCache, error) {
+	if cfg == nil {
+		cfg = &config.SSLConfig{Enabled: true}
+	}
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	checker, err := ssl.NewFromConfig(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := cfg.GetCheckInterval()
+	if err != nil {
+		return nil, err
+	}
+
+	return &sslCache{checker: checker, interval: interval}, nil
+}
+
+// get returns the certificate checks of the https sites, refreshing them once
+// they are older than the check interval
+func (c *sslCache) get(sites []config.Site) []ssl.SSLCheck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.checks != nil && time.Since(c.checkedAt) < c.interval {
+		return c.checks
+	}
+
+	checks := make([]ssl.SSLCheck, 0, len(sites))
+	for _, site := range sites {
+		if strings.HasPrefix(site.URL, "https://") {
+			checks = append(checks, c.checker.CheckSite(site))
+		}
+	}
+
+	c.checks = checks
+	c.checkedAt = time.Now()
+	return checks
+}
+
+// apiSSL returns the certificate status of every https site
+func (d *Dashboard) apiSSL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.sslCache.get(d.currentConfig().Sites)); err != nil {
+		log.Printf("Failed to encode SSL JSON: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// apiMetrics returns the advanced metrics of a site
+func (d *Dashboard) apiMetrics(w http.ResponseWriter, r *http.Request) {
+	siteName := r.URL.Query().Get("site")
+	if siteName == "" {
+		http.Error(w, "site parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	period := "24h"
+	if sinceParam := r.URL.Query().Get("since"); sinceParam != "" {
+		period = sinceParam
+	}
+	since, err := config.ParseDuration(period)
+	if err != nil || since <= 0 {
+		http.Error(w, "invalid since parameter", http.StatusBadRequest)
+		return
+	}
+
+	siteMetrics, err := d.metricsCalc.CalculateAdvancedMetrics(siteName, time.Now().Add(-since), period)
+	if errors.Is(err, metrics.ErrDisabled) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(siteMetrics); err != nil {
+		log.Printf("Failed to encode metrics JSON: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// dashboardSettings is passed to the dashboard script
+type dashboardSettings struct {
+	RefreshIntervalMs int64 `json:"refreshIntervalMs"`
+	RealTimeUpdates   bool  `json:"realTimeUpdates"`
+}
+
+// settingsJS returns the script prefix that defines dashboardSettings
+func (d *Dashboard) settingsJS() string {
+	dashboard := d.currentConfig().Dashboard
+	settings, _ := json.Marshal(dashboardSettings{
+		RefreshIntervalMs: dashboard.GetRefreshInterval().Milliseconds(),
+		RealTimeUpdates:   dashboard.GetFeatures().RealTimeUpdates,
+	})
+	return "var dashboardSettings = " + string(settings) + ";\n"
+}
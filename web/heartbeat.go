@@ -0,0 +1,77 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"site-monitor/config"
+	"site-monitor/heartbeat"
+	"site-monitor/storage"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// handlePing records a ping from a job pushing to a heartbeat monitor.
+// Supported URLs are /ping/{token} (success), /ping/{token}/start,
+// /ping/{token}/fail and /ping/{token}/{exit code}.
+func (d *Dashboard) handlePing(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	site := d.findHeartbeatSite(vars["token"])
+	if site == nil {
+		http.Error(w, "Unknown heartbeat", http.StatusNotFound)
+		return
+	}
+
+	ping := storage.HeartbeatPing{
+		SiteName:  site.Name,
+		Kind:      storage.HeartbeatSuccess,
+		Timestamp: time.Now(),
+	}
+
+	switch action := vars["action"]; action {
+	case "":
+	case "start":
+		ping.Kind = storage.HeartbeatStart
+	case "fail":
+		ping.Kind = storage.HeartbeatFail
+	default:
+		exitCode, err := strconv.Atoi(action)
+		if err != nil || exitCode < 0 || exitCode > 255 {
+			http.Error(w, "Invalid ping action: use start, fail or an exit code", http.StatusBadRequest)
+			return
+		}
+		ping.ExitCode = exitCode
+		if exitCode != 0 {
+			ping.Kind = storage.HeartbeatFail
+		}
+	}
+
+	pings, ok := d.storage.(storage.HeartbeatStorage)
+	if !ok {
+		http.Error(w, "Heartbeats are not supported by this storage", http.StatusNotImplemented)
+		return
+	}
+
+	if err := pings.SaveHeartbeatPing(ping); err != nil {
+		log.Printf("Failed to save heartbeat ping for %s: %v", site.Name, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("OK\n"))
+}
+
+// findHeartbeatSite returns the heartbeat site configured with token
+func (d *Dashboard) findHeartbeatSite(token string) *config.Site {
+	cfg := d.currentConfig()
+	for i := range cfg.Sites {
+		site := &cfg.Sites[i]
+		if site.Type == heartbeat.Type && site.GetHeartbeatToken() == token {
+			return site
+		}
+	}
+	return nil
+}
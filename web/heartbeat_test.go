@@ -0,0 +1,77 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"site-monitor/config"
+	"site-monitor/heartbeat"
+	"site-monitor/monitor"
+	"site-monitor/reload"
+	"site-monitor/storage"
+	"testing"
+)
+
+// fakeScheduler accepts every check a reload schedules
+type fakeScheduler struct {
+	added []*monitor.Monitor
+}
+
+func (s *fakeScheduler) Add(m *monitor.Monitor) error {
+	s.added = append(s.added, m)
+	return nil
+}
+
+func (s *fakeScheduler) Remove(name string) bool          { return true }
+func (s *fakeScheduler) Replace(m *monitor.Monitor) error { return nil }
+
+func TestDashboard_PingsHeartbeatAddedByReload(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "monitor.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage failed: %v", err)
+	}
+	defer store.Close()
+	if err := store.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	cfg := &config.Config{Dashboard: &config.DashboardConfig{Enabled: true}}
+	dashboard, err := NewDashboard(store, cfg, 0)
+	if err != nil {
+		t.Fatalf("NewDashboard failed: %v", err)
+	}
+	scheduler := &fakeScheduler{}
+	reloader := reload.New(cfg, scheduler, store)
+	reloader.SetDashboard(dashboard)
+
+	ping := func() int {
+		w := httptest.NewRecorder()
+		dashboard.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping/nightly-backup", nil))
+		return w.Code
+	}
+	if code := ping(); code != http.StatusNotFound {
+		t.Fatalf("Expected 404 before the reload, got %d", code)
+	}
+
+	backup := config.Site{Name: "Nightly Backup", Type: heartbeat.Type, Interval: "1h"}
+	if err := reloader.Apply(&config.Config{Sites: []config.Site{backup}, Dashboard: cfg.Dashboard}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if code := ping(); code != http.StatusOK {
+		t.Fatalf("Expected the reloaded heartbeat to accept pings, got %d", code)
+	}
+
+	pings, err := store.GetLatestHeartbeatPings(backup.Name, 1)
+	if err != nil || len(pings) != 1 || pings[0].Kind != storage.HeartbeatSuccess {
+		t.Errorf("Expected the ping to be recorded, got %+v (%v)", pings, err)
+	}
+
+	// The scheduled check reads the ping from the same store
+	if len(scheduler.added) != 1 {
+		t.Fatalf("Expected the heartbeat to be scheduled, got %d checks", len(scheduler.added))
+	}
+	if result := scheduler.added[0].Check(context.Background()); !result.Success {
+		t.Errorf("Expected the heartbeat check to see the ping, got %+v", result)
+	}
+}
@@ -19,7 +19,9 @@ SiteMonitorDashboard.prototype.init = function() {
     var checkChart = function() {
         if (typeof Chart !== 'undefined') {
             self.loadInitialData().then(function() {
-                self.initWebSocket();
+                if (dashboardSettings.realTimeUpdates) {
+                    self.initWebSocket();
+                }
                 self.initCharts();
                 self.startPeriodicUpdates();
                 self.hideLoadingOverlay();
@@ -49,6 +51,7 @@ SiteMonitorDashboard.prototype.loadInitialData = function() {
 SiteMonitorDashboard.prototype.initCharts = function() {
     this.initResponseTimeChart();
     this.initUptimeChart();
+    this.initTimingChart();
 };
 
 // ✅ FONCTION CORRIGÉE: Graphique des temps de réponse avec meilleur groupage
@@ -312,6 +315,106 @@ SiteMonitorDashboard.prototype.initUptimeChart = function() {
         });
 };
 
+SiteMonitorDashboard.prototype.timingPhases = [
+    { key: 'dns', label: 'DNS', color: '#6366f1' },
+    { key: 'connect', label: 'Connect', color: '#0ea5e9' },
+    { key: 'tls', label: 'TLS', color: '#f59e0b' },
+    { key: 'ttfb', label: 'TTFB', color: '#059669' },
+    { key: 'transfer', label: 'Transfer', color: '#a855f7' }
+];
+
+SiteMonitorDashboard.prototype.initTimingChart = function() {
+    var ctx = document.getElementById('timing-chart');
+    if (!ctx) return;
+    
+    var self = this;
+    
+    fetch('/api/stats')
+        .then(function(r) { return r.json(); })
+        .then(function(stats) {
+            var chartData = self.processTimingData(stats);
+            
+            if (chartData.labels.length === 0) {
+                self.showChartError('timing-chart', 'No request phase timings available yet.');
+                return;
+            }
+            
+            self.charts.timing = new Chart(ctx, {
+                type: 'bar',
+                data: {
+                    labels: chartData.labels,
+                    datasets: chartData.datasets
+                },
+                options: {
+                    indexAxis: 'y',
+                    responsive: true,
+                    maintainAspectRatio: false,
+                    plugins: {
+                        legend: {
+                            display: true,
+                            position: 'bottom'
+                        },
+                        tooltip: {
+                            callbacks: {
+                                label: function(context) {
+                                    return context.dataset.label + ': ' + context.raw + ' ms';
+                                }
+                            }
+                        }
+                    },
+                    scales: {
+                        x: {
+                            stacked: true,
+                            title: {
+                                display: true,
+                                text: 'Average Time (milliseconds)'
+                            }
+                        },
+                        y: {
+                            stacked: true
+                        }
+                    }
+                }
+            });
+        })
+        .catch(function(error) {
+            console.error('Failed to initialize timing chart:', error);
+            self.showChartError('timing-chart', 'Failed to load timing data');
+        });
+};
+
+// Durations are serialized in nanoseconds
+SiteMonitorDashboard.prototype.processTimingData = function(stats) {
+    var labels = [];
+    var datasets = this.timingPhases.map(function(phase) {
+        return {
+            label: phase.label,
+            data: [],
+            backgroundColor: phase.color
+        };
+    });
+    
+    var names = Object.keys(stats).sort();
+    for (var i = 0; i < names.length; i++) {
+        var timings = stats[names[i]].avg_timings;
+        if (!timings) continue;
+        
+        var total = 0;
+        for (var j = 0; j < this.timingPhases.length; j++) {
+            total += timings[this.timingPhases[j].key] || 0;
+        }
+        if (total === 0) continue;
+        
+        labels.push(names[i]);
+        for (var k = 0; k < this.timingPhases.length; k++) {
+            var ns = timings[this.timingPhases[k].key] || 0;
+            datasets[k].data.push(Math.round(ns / 10000) / 100);
+        }
+    }
+    
+    return { labels: labels, datasets: datasets };
+};
+
 SiteMonitorDashboard.prototype.processUptimeData = function(stats) {
     var totalSuccess = 0;
     var totalFailure = 0;
@@ -419,6 +522,20 @@ SiteMonitorDashboard.prototype.updateCharts = function() {
                 console.error('Failed to update uptime chart:', error);
             });
     }
+    
+    if (this.charts.timing) {
+        fetch('/api/stats')
+            .then(function(r) { return r.json(); })
+            .then(function(stats) {
+                var chartData = self.processTimingData(stats);
+                self.charts.timing.data.labels = chartData.labels;
+                self.charts.timing.data.datasets = chartData.datasets;
+                self.charts.timing.update('none');
+            })
+            .catch(function(error) {
+                console.error('Failed to update timing chart:', error);
+            });
+    }
 };
 
 SiteMonitorDashboard.prototype.showChartError = function(canvasId, message) {
@@ -531,11 +648,29 @@ SiteMonitorDashboard.prototype.createSiteCard = function(site) {
                 '<div class="metric-value">' + site.response_time_ms + 'ms</div>' +
                 '<div class="metric-label">Response Time</div>' +
             '</div>' +
-        '</div>';
+        '</div>' +
+        this.formatFamilies(site.families);
     
     return card;
 };
 
+// formatFamilies shows uptime and response time per address family for
+// sites probed over IPv4 and/or IPv6
+SiteMonitorDashboard.prototype.formatFamilies = function(families) {
+    if (!families) return '';
+    
+    var items = ['v4', 'v6'].filter(function(family) {
+        return families[family];
+    }).map(function(family) {
+        var stats = families[family];
+        var status = stats.uptime >= 99.0 ? 'healthy' : stats.uptime >= 80.0 ? 'degraded' : 'down';
+        return '<div class="site-family ' + status + '">IP' + family + ' ' +
+            stats.uptime.toFixed(1) + '% · ' + stats.response_time_ms + 'ms</div>';
+    });
+    
+    return '<div class="site-families">' + items.join('') + '</div>';
+};
+
 SiteMonitorDashboard.prototype.updateActivityFeed = function(history) {
     var activityList = document.getElementById('activity-list');
     activityList.innerHTML = '';
@@ -574,14 +709,34 @@ SiteMonitorDashboard.prototype.createActivityItem = function(entry) {
     item.innerHTML = 
         '<div class="activity-icon ' + iconClass + '"></div>' +
         '<div class="activity-content">' +
-            '<div class="activity-message">' + this.escapeHtml(entry.site_name) + ' is ' + statusText + '</div>' +
+            '<div class="activity-message">' + this.escapeHtml(entry.site_name) +
+                (entry.ip_version ? ' (IP' + this.escapeHtml(entry.ip_version) + ')' : '') + ' is ' + statusText + '</div>' +
             '<div class="activity-details">' + this.escapeHtml(details) + '</div>' +
+            this.formatSteps(entry.steps) +
         '</div>' +
         '<div class="activity-time">' + timeAgo + '</div>';
     
     return item;
 };
 
+// formatSteps lists transaction steps with their status and duration
+SiteMonitorDashboard.prototype.formatSteps = function(steps) {
+    if (!steps || steps.length === 0) return '';
+    var self = this;
+    
+    var items = steps.map(function(step, i) {
+        var text = (i + 1) + '. ' + step.name + ' - ' + (step.status || '-') + ' - ' +
+            Math.round(step.duration / 1000000) + 'ms';
+        if (!step.success && step.error) {
+            text += ' - ' + step.error;
+        }
+        return '<li class="activity-step ' + (step.success ? 'success' : 'error') + '">' +
+            self.escapeHtml(text) + '</li>';
+    });
+    
+    return '<ol class="activity-steps">' + items.join('') + '</ol>';
+};
+
 SiteMonitorDashboard.prototype.startPeriodicUpdates = function() {
     var self = this;
     setInterval(function() {
@@ -603,7 +758,7 @@ SiteMonitorDashboard.prototype.startPeriodicUpdates = function() {
             .catch(function(error) {
                 console.error('Failed to update dashboard:', error);
             });
-    }, 30000);
+    }, dashboardSettings.refreshIntervalMs);
 };
 
 SiteMonitorDashboard.prototype.hideLoadingOverlay = function() {
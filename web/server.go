@@ -1,16 +1,24 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"site-monitor/config"
 	"site-monitor/export"
+	"site-monitor/heartbeat"
+	"site-monitor/metrics"
+	"site-monitor/monitor"
+	"site-monitor/scheduler"
 	"site-monitor/storage"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -19,19 +27,39 @@ import (
 
 // Dashboard represents the web dashboard server
 type Dashboard struct {
-	storage  storage.Storage
-	config   *config.Config
-	server   *http.Server
-	clients  map[*websocket.Conn]bool
-	upgrader websocket.Upgrader
+	storage         storage.Storage
+	config          *config.Config
+	configMu        sync.RWMutex
+	server          *http.Server
+	clients         map[*websocket.Conn]bool
+	clientsMu       sync.Mutex
+	upgrader        websocket.Upgrader
+	shutdownTimeout time.Duration
+	apiServer       *http.Server // Set when api.port differs from the dashboard port
+	sslCache        *sslCache
+	metricsCalc     *metrics.AdvancedMetricsCalculator
+	scheduler       SchedulerMetrics // nil when the dashboard runs on its own
 }
 
-// NewDashboard creates a new dashboard instance
-func NewDashboard(storage storage.Storage, config *config.Config, port int) *Dashboard {
+// SchedulerMetrics reports the health of the check scheduler
+type SchedulerMetrics interface {
+	Metrics() scheduler.Metrics
+}
+
+// NewDashboard creates a new dashboard instance. A zero port uses
+// dashboard.port from the configuration; the dashboard, ssl, metrics and api
+// sections select which features and API settings are served.
+func NewDashboard(storage storage.Storage, config *config.Config, port int) (*Dashboard, error) {
+	if port == 0 {
+		port = config.Dashboard.GetPort()
+	}
+	features := config.Dashboard.GetFeatures()
+
 	dashboard := &Dashboard{
-		storage: storage,
-		config:  config,
-		clients: make(map[*websocket.Conn]bool),
+		storage:         storage,
+		config:          config,
+		clients:         make(map[*websocket.Conn]bool),
+		shutdownTimeout: 10 * time.Second,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins for development
@@ -47,19 +75,63 @@ func NewDashboard(storage storage.Storage, config *config.Config, port int) *Das
 	router.HandleFunc("/static/dashboard.js", dashboard.serveDashboardJS)
 
 	// API routes
-	api := router.PathPrefix("/api").Subrouter()
+	apiRouter := mux.NewRouter()
+	api := apiRouter.PathPrefix("/api").Subrouter()
 	api.HandleFunc("/stats", dashboard.apiStats).Methods("GET")
 	api.HandleFunc("/history", dashboard.apiHistory).Methods("GET")
 	api.HandleFunc("/sites", dashboard.apiSites).Methods("GET")
-	api.HandleFunc("/alerts", dashboard.apiAlerts).Methods("GET")
 	api.HandleFunc("/overview", dashboard.apiOverview).Methods("GET")
+	api.HandleFunc("/scheduler", dashboard.apiScheduler).Methods("GET")
+
+	if features.AlertManagement {
+		api.HandleFunc("/alerts", dashboard.apiAlerts).Methods("GET")
+	}
+
+	if features.SSLMonitoring {
+		cache, err := newSSLCache(config.SSL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssl configuration: %w", err)
+		}
+		if cache != nil {
+			dashboard.sslCache = cache
+			api.HandleFunc("/ssl", dashboard.apiSSL).Methods("GET")
+		}
+	}
+
+	if features.AdvancedMetrics {
+		dashboard.metricsCalc = metrics.NewAdvancedMetricsCalculator(storage)
+		if config.Metrics != nil {
+			if err := dashboard.metricsCalc.SetConfig(*config.Metrics); err != nil {
+				return nil, fmt.Errorf("invalid metrics configuration: %w", err)
+			}
+		}
+		api.HandleFunc("/metrics", dashboard.apiMetrics).Methods("GET")
+	}
 
 	// Export API routes
 	api.HandleFunc("/export", dashboard.apiExport).Methods("GET")
 	api.HandleFunc("/export/formats", dashboard.apiExportFormats).Methods("GET")
 
+	apiHandler := apiMiddleware(config.API, apiRouter)
+	router.PathPrefix("/api/").Handler(apiHandler)
+
+	// The API is also served on its own port for external clients
+	if config.API != nil && config.API.Enabled && config.API.Port != 0 && config.API.Port != port {
+		dashboard.apiServer = &http.Server{
+			Addr:    fmt.Sprintf(":%d", config.API.Port),
+			Handler: apiHandler,
+		}
+	}
+
+	// Heartbeat ping endpoints for push monitors
+	pingMethods := []string{"GET", "POST", "HEAD"}
+	router.HandleFunc("/ping/{token}", dashboard.handlePing).Methods(pingMethods...)
+	router.HandleFunc("/ping/{token}/{action}", dashboard.handlePing).Methods(pingMethods...)
+
 	// WebSocket endpoint
-	router.HandleFunc("/ws", dashboard.handleWebSocket)
+	if features.RealTimeUpdates {
+		router.HandleFunc("/ws", dashboard.handleWebSocket)
+	}
 
 	// Main dashboard page
 	router.HandleFunc("/", dashboard.serveDashboard).Methods("GET")
@@ -70,17 +142,90 @@ func NewDashboard(storage storage.Storage, config *config.Config, port int) *Das
 		Handler: router,
 	}
 
-	return dashboard
+	// Hijacked WebSocket connections are not tracked by http.Server.Shutdown
+	dashboard.server.RegisterOnShutdown(dashboard.closeClients)
+
+	return dashboard, nil
+}
+
+// Port returns the port the dashboard listens on
+func (d *Dashboard) Port() int {
+	_, port, _ := net.SplitHostPort(d.server.Addr)
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+// SetShutdownTimeout sets how long Start waits for open requests to finish
+// once its context is cancelled
+func (d *Dashboard) SetShutdownTimeout(timeout time.Duration) {
+	d.shutdownTimeout = timeout
+}
+
+// SetScheduler serves the metrics of the scheduler running the checks on
+// /api/scheduler
+func (d *Dashboard) SetScheduler(scheduler SchedulerMetrics) {
+	d.scheduler = scheduler
 }
 
-// Start starts the dashboard server
-func (d *Dashboard) Start() error {
+// SetConfig swaps the configuration the dashboard serves, e.g. after a
+// reload. Sites, heartbeat tokens and alert channels take effect at once;
+// the dashboard, ssl, metrics and api sections still need a restart.
+func (d *Dashboard) SetConfig(cfg *config.Config) {
+	d.configMu.Lock()
+	defer d.configMu.Unlock()
+	d.config = cfg
+}
+
+// currentConfig returns the configuration the dashboard serves
+func (d *Dashboard) currentConfig() *config.Config {
+	d.configMu.RLock()
+	defer d.configMu.RUnlock()
+	return d.config
+}
+
+// Start runs the dashboard server until ctx is cancelled, then shuts it down
+// gracefully, letting in-flight requests complete within the shutdown timeout
+func (d *Dashboard) Start(ctx context.Context) error {
 	log.Printf("🌐 Starting dashboard server on http://localhost%s", d.server.Addr)
-	return d.server.ListenAndServe()
+
+	servers := []*http.Server{d.server}
+	if d.apiServer != nil {
+		log.Printf("🔌 Starting API server on http://localhost%s", d.apiServer.Addr)
+		servers = append(servers, d.apiServer)
+	}
+
+	errChan := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server *http.Server) {
+			errChan <- server.ListenAndServe()
+		}(server)
+	}
+
+	var err error
+	select {
+	case err = <-errChan:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+	case <-ctx.Done():
+		log.Printf("🛑 Shutting down dashboard server...")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), d.shutdownTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if shutdownErr := server.Shutdown(shutdownCtx); err == nil {
+			err = shutdownErr
+		}
+	}
+	return err
 }
 
 // Stop stops the dashboard server
 func (d *Dashboard) Stop() error {
+	if d.apiServer != nil {
+		d.apiServer.Close()
+	}
 	return d.server.Close()
 }
 
@@ -150,6 +295,7 @@ func (d *Dashboard) apiOverview(w http.ResponseWriter, r *http.Request) {
 			ResponseTime: stats.AvgResponseTime.Milliseconds(),
 			LastCheck:    stats.LastCheck,
 			TotalChecks:  stats.TotalChecks,
+			Families:     familyOverviews(stats.Families),
 		})
 	}
 
@@ -253,14 +399,24 @@ func (d *Dashboard) apiHistory(w http.ResponseWriter, r *http.Request) {
 
 // apiSites returns list of monitored sites
 func (d *Dashboard) apiSites(w http.ResponseWriter, r *http.Request) {
-	sites := make([]SiteInfo, len(d.config.Sites))
-	for i, site := range d.config.Sites {
+	cfg := d.currentConfig()
+	sites := make([]SiteInfo, len(cfg.Sites))
+	for i, site := range cfg.Sites {
+		siteType := site.Type
+		if siteType == "" {
+			siteType = monitor.DefaultType
+		}
+
 		sites[i] = SiteInfo{
 			Name:     site.Name,
+			Type:     siteType,
 			URL:      site.URL,
 			Interval: site.Interval,
 			Timeout:  site.Timeout,
 		}
+		if siteType == heartbeat.Type {
+			sites[i].PingURL = "/ping/" + site.GetHeartbeatToken()
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -270,6 +426,20 @@ func (d *Dashboard) apiSites(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// apiScheduler returns the queue depth, lag and skipped runs of the scheduler
+func (d *Dashboard) apiScheduler(w http.ResponseWriter, r *http.Request) {
+	if d.scheduler == nil {
+		http.Error(w, "Scheduler metrics are only served by the monitoring daemon", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.scheduler.Metrics()); err != nil {
+		log.Printf("Failed to encode scheduler JSON: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
 // apiAlerts returns alert configuration status
 func (d *Dashboard) apiAlerts(w http.ResponseWriter, r *http.Request) {
 	alertStatus := AlertStatus{
@@ -278,9 +448,9 @@ func (d *Dashboard) apiAlerts(w http.ResponseWriter, r *http.Request) {
 		TotalChannels:  0,
 	}
 
-	if d.config.Alerts != nil {
-		alertStatus.EmailEnabled = d.config.Alerts.Email.Enabled
-		alertStatus.WebhookEnabled = d.config.Alerts.Webhook.Enabled
+	if alertConfig := d.currentConfig().Alerts; alertConfig != nil {
+		alertStatus.EmailEnabled = alertConfig.Email.Enabled
+		alertStatus.WebhookEnabled = alertConfig.Webhook.Enabled
 
 		if alertStatus.EmailEnabled {
 			alertStatus.TotalChannels++
@@ -453,8 +623,11 @@ func (d *Dashboard) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	defer conn.Close()
 
 	// Register client
+	d.clientsMu.Lock()
 	d.clients[conn] = true
-	log.Printf("📡 WebSocket client connected (total: %d)", len(d.clients))
+	total := len(d.clients)
+	d.clientsMu.Unlock()
+	log.Printf("📡 WebSocket client connected (total: %d)", total)
 
 	// Send initial data
 	d.sendOverviewUpdate(conn)
@@ -463,13 +636,34 @@ func (d *Dashboard) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	for {
 		_, _, err := conn.ReadMessage()
 		if err != nil {
+			d.clientsMu.Lock()
 			delete(d.clients, conn)
-			log.Printf("📡 WebSocket client disconnected (remaining: %d)", len(d.clients))
+			remaining := len(d.clients)
+			d.clientsMu.Unlock()
+			log.Printf("📡 WebSocket client disconnected (remaining: %d)", remaining)
 			break
 		}
 	}
 }
 
+// familyOverviews converts per-family stats for the overview, nil when the
+// site is not probed per family
+func familyOverviews(families map[string]storage.FamilyStats) map[string]FamilyOverview {
+	if len(families) == 0 {
+		return nil
+	}
+
+	overviews := make(map[string]FamilyOverview, len(families))
+	for family, stats := range families {
+		overviews[family] = FamilyOverview{
+			Uptime:       stats.SuccessRate,
+			ResponseTime: stats.AvgResponseTime.Milliseconds(),
+			TotalChecks:  stats.TotalChecks,
+		}
+	}
+	return overviews
+}
+
 // sendOverviewUpdate sends overview data to a WebSocket client
 func (d *Dashboard) sendOverviewUpdate(conn *websocket.Conn) {
 	since := time.Now().Add(-24 * time.Hour)
@@ -511,6 +705,7 @@ func (d *Dashboard) sendOverviewUpdate(conn *websocket.Conn) {
 			ResponseTime: stats.AvgResponseTime.Milliseconds(),
 			LastCheck:    stats.LastCheck,
 			TotalChecks:  stats.TotalChecks,
+			Families:     familyOverviews(stats.Families),
 		})
 	}
 
@@ -531,11 +726,27 @@ func (d *Dashboard) sendOverviewUpdate(conn *websocket.Conn) {
 
 // BroadcastUpdate sends updates to all connected WebSocket clients
 func (d *Dashboard) BroadcastUpdate() {
+	d.clientsMu.Lock()
+	defer d.clientsMu.Unlock()
+
 	for conn := range d.clients {
 		d.sendOverviewUpdate(conn)
 	}
 }
 
+// closeClients sends a close frame to every connected WebSocket client
+func (d *Dashboard) closeClients() {
+	d.clientsMu.Lock()
+	defer d.clientsMu.Unlock()
+
+	message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for conn := range d.clients {
+		if err := conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second)); err != nil {
+			log.Printf("Failed to close WebSocket client: %v", err)
+		}
+	}
+}
+
 // serveDashboardCSS serves the CSS file
 func (d *Dashboard) serveDashboardCSS(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/css")
@@ -552,7 +763,7 @@ func (d *Dashboard) serveDashboardJS(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/javascript")
 	w.Header().Set("Cache-Control", "public, max-age=3600")
 
-	if _, err := w.Write([]byte(dashboardJS)); err != nil {
+	if _, err := w.Write([]byte(d.settingsJS() + dashboardJS)); err != nil {
 		log.Printf("Failed to serve JS: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
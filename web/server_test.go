@@ -0,0 +1,48 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"site-monitor/config"
+	"site-monitor/scheduler"
+	"testing"
+	"time"
+)
+
+// staticScheduler reports fixed scheduler metrics
+type staticScheduler scheduler.Metrics
+
+func (s staticScheduler) Metrics() scheduler.Metrics {
+	return scheduler.Metrics(s)
+}
+
+func TestDashboard_APIScheduler(t *testing.T) {
+	dashboard, err := NewDashboard(nil, &config.Config{}, 0)
+	if err != nil {
+		t.Fatalf("NewDashboard failed: %v", err)
+	}
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		dashboard.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/scheduler", nil))
+		return w
+	}
+
+	// The standalone dashboard has no scheduler to report on
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 without a scheduler, got %d", w.Code)
+	}
+
+	dashboard.SetScheduler(staticScheduler{Checks: 4, QueueDepth: 3, Skipped: 2, MaxLag: 1500 * time.Millisecond})
+	w := get()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var metrics scheduler.Metrics
+	if err := json.NewDecoder(w.Body).Decode(&metrics); err != nil {
+		t.Fatalf("Failed to decode metrics: %v", err)
+	}
+	if metrics.Checks != 4 || metrics.QueueDepth != 3 || metrics.Skipped != 2 || metrics.MaxLag != 1500*time.Millisecond {
+		t.Errorf("Unexpected metrics: %+v", metrics)
+	}
+}
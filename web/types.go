@@ -21,14 +21,26 @@ type SiteOverview struct {
 	ResponseTime int64     `json:"response_time_ms"`
 	LastCheck    time.Time `json:"last_check"`
 	TotalChecks  int64     `json:"total_checks"`
+
+	// Families breaks the site down per address family (v4, v6) when it has an ip_version
+	Families map[string]FamilyOverview `json:"families,omitempty"`
+}
+
+// FamilyOverview represents a site's results over one address family
+type FamilyOverview struct {
+	Uptime       float64 `json:"uptime"`
+	ResponseTime int64   `json:"response_time_ms"`
+	TotalChecks  int64   `json:"total_checks"`
 }
 
 // SiteInfo represents basic site configuration info
 type SiteInfo struct {
 	Name     string `json:"name"`
+	Type     string `json:"type"`
 	URL      string `json:"url"`
 	Interval string `json:"interval"`
 	Timeout  string `json:"timeout"`
+	PingURL  string `json:"ping_url,omitempty"` // Heartbeat monitors only
 }
 
 // AlertStatus represents alert configuration status